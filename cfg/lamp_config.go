@@ -8,25 +8,40 @@ license that can be found in the LICENSE file.
 package cfg
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"github.com/ccding/go-config-reader/config"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 const (
-	confPath        = "LAMP_CONF_PATH"
-	sep      string = string(filepath.Separator)
+	confPath             = "LAMP_CONF_PATH"
+	profileEnv           = "LAMP_PROFILE"
+	profilePrefix        = "profile "
+	aliasSection         = "alias"
+	sep           string = string(filepath.Separator)
 )
 
 var lampConfig *config.Config
 
+// profileSections maps the profile names to the section names they are defined with in the configuration file.
+var profileSections = map[string]string{}
+
+// aliases maps the names of the aliases defined in the [alias] section to the command lines they expand to.
+var aliases = map[string]string{}
+
+var profile = ""
+
 // Verbose is an exported variable to determine command is executing verbose mode or not.
 var Verbose = false
 
 func printVerboseMessage(message string) {
 	if Verbose {
-		fmt.Printf("%s\n", message)
+		fmt.Fprintf(os.Stderr, "%s\n", message)
 	}
 }
 
@@ -47,18 +62,32 @@ func LoadConfigFromGivenPath(confPath string) {
 
 // LoadConfiguration method tries to find and read the configuration file some specific paths.
 func LoadConfiguration() {
+	load(ConfigPath())
+}
+
+// ConfigPath method returns the path of the configuration file read when no path is given.
+func ConfigPath() string {
+	path, _ := ResolveConfigPath()
+	return path
+}
+
+// ResolveConfigPath method returns the path of the configuration file read when no path is given, with the reason it is picked.
+func ResolveConfigPath() (string, string) {
 	confPath := os.Getenv(confPath)
+	reason := "given with LAMP_CONF_PATH environment variable"
 	if confPath == "" {
 		confPath = lampHome() + ".." + sep + "conf" + sep + "opsgenie-integration.conf"
+		reason = "LAMP_CONF_PATH environment variable is not set, the file next to the lamp binary is used"
 		printVerboseMessage("LAMP_CONF_PATH environment variable is not set. Will try to read config from: \n" + confPath)
 	}
 
 	if _, err := os.Stat(confPath); os.IsNotExist(err) {
 		userHomeDir, _ := os.UserHomeDir()
+		reason = confPath + " does not exist, the file in the user configuration directory is used"
 		confPath = userHomeDir + sep + ".config" + sep + "lamp.conf"
 		printVerboseMessage("Could not find the file specified. Will try to read config from: \n" + confPath)
 	}
-	load(confPath)
+	return confPath, reason
 }
 
 func load(confPath string) {
@@ -66,18 +95,115 @@ func load(confPath string) {
 		conf := config.NewConfig(confPath)
 		conf.Read()
 		lampConfig = conf
+		profileSections = readProfileSections(confPath)
+		aliases = readAliases(confPath)
+		resetResolvedValues()
 		configureLog()
 	} else {
 		printVerboseMessage("Could not read config file: " + err.Error())
 	}
 }
 
-// Get method returns the configuration properties value according to the key.
-func Get(key string) string {
-	if lampConfig != nil {
-		return lampConfig.Get("", key)
+/*
+readProfileSections finds the profiles defined in the configuration file. A profile is a section
+named "[profile <name>]" that carries its own apiKey, apiUrl, proxy, timeout and user settings.
+*/
+func readProfileSections(confPath string) map[string]string {
+	sections := map[string]string{}
+	file, err := os.Open(confPath)
+	if err != nil {
+		return sections
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) < 2 || line[0] != '[' || line[len(line)-1] != ']' {
+			continue
+		}
+		section := line[1 : len(line)-1]
+		if strings.HasPrefix(section, profilePrefix) {
+			sections[strings.TrimSpace(strings.TrimPrefix(section, profilePrefix))] = section
+		}
+	}
+	return sections
+}
+
+/*
+readAliases reads the "name = command line" lines of the [alias] section. Lines ending with a
+backslash continue on the next line, so long macros can be split.
+*/
+func readAliases(confPath string) map[string]string {
+	read := map[string]string{}
+	file, err := os.Open(confPath)
+	if err != nil {
+		return read
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	section, line := "", ""
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if line == "" && len(text) >= 2 && text[0] == '[' && text[len(text)-1] == ']' {
+			section = text[1 : len(text)-1]
+			continue
+		}
+		if section != aliasSection || (line == "" && (text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";"))) {
+			continue
+		}
+		line += text
+		if strings.HasSuffix(line, "\\") {
+			line = strings.TrimSpace(strings.TrimSuffix(line, "\\")) + " "
+			continue
+		}
+		if parts := strings.SplitN(line, "=", 2); len(parts) == 2 && strings.TrimSpace(parts[0]) != "" {
+			read[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+		line = ""
+	}
+	return read
+}
+
+// Aliases method returns the aliases defined in the [alias] section of the configuration file.
+func Aliases() map[string]string {
+	return aliases
+}
+
+// SelectProfile method selects the profile to read the configuration from. If the given name is empty,
+// the LAMP_PROFILE environment variable is used. Without a profile only the top level configuration is used.
+func SelectProfile(name string) error {
+	if name == "" {
+		name = os.Getenv(profileEnv)
+	}
+	if name == "" {
+		profile = ""
+		resetResolvedValues()
+		return nil
+	}
+	if _, ok := profileSections[name]; !ok {
+		return errors.New("Could not find the profile " + name + " in the configuration file")
+	}
+	printVerboseMessage("Will use the configuration of profile: " + name)
+	profile = name
+	resetResolvedValues()
+	return nil
+}
+
+// Profile method returns the name of the selected profile.
+func Profile() string {
+	return profile
+}
+
+// Profiles method returns the names of the profiles defined in the configuration file.
+func Profiles() []string {
+	var names []string
+	for name := range profileSections {
+		names = append(names, name)
 	}
-	return ""
+	sort.Strings(names)
+	return names
 }
 
 func configureLog() {
@@ -0,0 +1,167 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	envPrefix     = "LAMP_"
+	fileSuffix    = "File"
+	commandSuffix = "Command"
+)
+
+/*
+Configuration values are resolved from the following sources, the first one that provides a
+non empty value wins:
+
+	1. LAMP_<KEY> environment variable, e.g. LAMP_API_KEY for apiKey
+	2. LAMP_<KEY>_FILE environment variable, the path of a file containing the value
+	3. <key>, <key>File or <key>Command in the selected profile of the configuration file
+	4. <key>, <key>File or <key>Command at the top level of the configuration file
+
+<key>File is the path of a file containing the value, e.g. a mounted secret, and <key>Command
+is a command whose standard output is the value. If the file can not be read or the command
+fails, the key is not read from the next sources, the error is returned by Lookup instead.
+Command line flags such as --apiKey take precedence over all of these sources and are handled
+by the commands.
+*/
+
+// resolvedValues caches the resolved values so that files are read and commands are executed once,
+// resolvedSources keeps where they were read from and resolvedErrors why they could not be read.
+// They are guarded by resolvedValuesMu since commands of a batch read the configuration concurrently.
+var (
+	resolvedValues   = map[string]string{}
+	resolvedSources  = map[string]string{}
+	resolvedErrors   = map[string]error{}
+	resolvedValuesMu sync.Mutex
+)
+
+func resetResolvedValues() {
+	resolvedValuesMu.Lock()
+	defer resolvedValuesMu.Unlock()
+	resolvedValues = map[string]string{}
+	resolvedSources = map[string]string{}
+	resolvedErrors = map[string]error{}
+}
+
+// Get method returns the configuration properties value according to the key, the error of a file or command
+// that can not be read is printed and the value is empty. Use Lookup for keys that must not be empty.
+func Get(key string) string {
+	value, err := Lookup(key)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+	}
+	return value
+}
+
+// Lookup method returns the configuration properties value according to the key, or the error of the file or command it is read from.
+func Lookup(key string) (string, error) {
+	resolvedValuesMu.Lock()
+	defer resolvedValuesMu.Unlock()
+	if value, ok := resolvedValues[key]; ok {
+		return value, resolvedErrors[key]
+	}
+	value, source, err := resolve(key)
+	if source != "" {
+		printVerboseMessage(key + " is read from " + source)
+	}
+	if err != nil {
+		err = errors.New("Error occurred while reading " + key + " from the " + source + ": " + err.Error())
+	}
+	resolvedValues[key] = value
+	resolvedSources[key] = source
+	resolvedErrors[key] = err
+	return value, err
+}
+
+// Source method returns a description of where the value of the key is read from, or empty if the key is not set.
+func Source(key string) string {
+	Get(key)
+	resolvedValuesMu.Lock()
+	defer resolvedValuesMu.Unlock()
+	return resolvedSources[key]
+}
+
+// resolve returns the value of the key, a description of the source it was read from and the error of reading it.
+func resolve(key string) (string, string, error) {
+	envName := EnvName(key)
+	if value := os.Getenv(envName); value != "" {
+		return value, "environment variable " + envName, nil
+	}
+	if path := os.Getenv(envName + "_FILE"); path != "" {
+		value, err := readValueFile(path)
+		return value, "file " + path + " given with environment variable " + envName + "_FILE", err
+	}
+	if lampConfig == nil {
+		return "", "", nil
+	}
+	if profile != "" {
+		if value, source, err := resolveSection(key, profileSections[profile]); source != "" {
+			return value, source + " of profile " + profile, err
+		}
+	}
+	return resolveSection(key, "")
+}
+
+func resolveSection(key string, section string) (string, string, error) {
+	if value := lampConfig.Get(section, key); value != "" {
+		return value, "configuration key " + key, nil
+	}
+	if path := lampConfig.Get(section, key+fileSuffix); path != "" {
+		value, err := readValueFile(path)
+		return value, "file " + path + " given with configuration key " + key + fileSuffix, err
+	}
+	if command := lampConfig.Get(section, key+commandSuffix); command != "" {
+		value, err := runValueCommand(command)
+		return value, "output of the command given with configuration key " + key + commandSuffix, err
+	}
+	return "", "", nil
+}
+
+func readValueFile(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func runValueCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// EnvName method returns the environment variable name of a configuration key, e.g. LAMP_API_KEY for apiKey.
+func EnvName(key string) string {
+	key = strings.TrimPrefix(key, "lamp.")
+	var name []rune
+	for i, r := range key {
+		switch {
+		case r == '.' || r == '-':
+			name = append(name, '_')
+		case unicode.IsUpper(r) && i > 0:
+			name = append(name, '_', r)
+		default:
+			name = append(name, unicode.ToUpper(r))
+		}
+	}
+	return envPrefix + string(name)
+}
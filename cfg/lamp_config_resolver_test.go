@@ -0,0 +1,159 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keyFile := filepath.Join(dir, "api-key")
+	if err := ioutil.WriteFile(keyFile, []byte(" key-from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	missingFile := filepath.Join(dir, "missing")
+
+	tests := []struct {
+		name    string
+		conf    string
+		profile string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "configuration key", conf: "apiKey=key-from-conf\n", want: "key-from-conf"},
+		{name: "not set", conf: "apiUrl=api.eu.opsgenie.com\n", want: ""},
+		{name: "environment variable", conf: "apiKey=key-from-conf\n", env: map[string]string{"LAMP_API_KEY": "key-from-env"}, want: "key-from-env"},
+		{name: "environment variable file", conf: "apiKey=key-from-conf\n", env: map[string]string{"LAMP_API_KEY_FILE": keyFile}, want: "key-from-file"},
+		{name: "environment variable before its file", env: map[string]string{"LAMP_API_KEY": "key-from-env", "LAMP_API_KEY_FILE": keyFile}, want: "key-from-env"},
+		{name: "file key", conf: "apiKeyFile=" + keyFile + "\n", want: "key-from-file"},
+		{name: "command key", conf: "apiKeyCommand=echo key-from-command\n", want: "key-from-command"},
+		{name: "key before file and command", conf: "apiKey=key-from-conf\napiKeyFile=" + keyFile + "\napiKeyCommand=echo key-from-command\n", want: "key-from-conf"},
+		{name: "profile", conf: "apiKey=top\n\n[profile eu]\napiKey=key-of-eu\n", profile: "eu", want: "key-of-eu"},
+		{name: "profile file", conf: "apiKey=top\n\n[profile eu]\napiKeyFile=" + keyFile + "\n", profile: "eu", want: "key-from-file"},
+		{name: "top level for a key missing in the profile", conf: "apiKey=top\n\n[profile eu]\napiUrl=api.eu.opsgenie.com\n", profile: "eu", want: "top"},
+		{name: "missing file", conf: "apiKeyFile=" + missingFile + "\n", wantErr: true},
+		{name: "failing command", conf: "apiKeyCommand=exit 3\n", wantErr: true},
+		{name: "missing file before the top level", conf: "apiKey=top\n\n[profile eu]\napiKeyFile=" + missingFile + "\n", profile: "eu", wantErr: true},
+		{name: "missing environment variable file", conf: "apiKey=key-from-conf\n", env: map[string]string{"LAMP_API_KEY_FILE": missingFile}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			confPath := filepath.Join(dir, "lamp.conf")
+			if err := ioutil.WriteFile(confPath, []byte(test.conf), 0600); err != nil {
+				t.Fatal(err)
+			}
+			for name, value := range test.env {
+				os.Setenv(name, value)
+				defer os.Unsetenv(name)
+			}
+			LoadConfigFromGivenPath(confPath)
+			if err := SelectProfile(test.profile); err != nil {
+				t.Fatal(err)
+			}
+
+			value, err := Lookup("apiKey")
+			if test.wantErr {
+				if err == nil || value != "" {
+					t.Errorf("Lookup returned %q, %v, want an error", value, err)
+				}
+				return
+			}
+			if err != nil || value != test.want {
+				t.Errorf("Lookup returned %q, %v, want %q", value, err, test.want)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "apiKey", want: "LAMP_API_KEY"},
+		{key: "apiUrl", want: "LAMP_API_URL"},
+		{key: "user", want: "LAMP_USER"},
+		{key: "lamp.log.level", want: "LAMP_LOG_LEVEL"},
+		{key: "logMaxBackups", want: "LAMP_LOG_MAX_BACKUPS"},
+		{key: "proxy-host", want: "LAMP_PROXY_HOST"},
+	}
+	for _, test := range tests {
+		if got := EnvName(test.key); got != test.want {
+			t.Errorf("EnvName(%q) = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
+
+func TestGetRunsTheCommandOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	runs := filepath.Join(dir, "runs")
+	confPath := filepath.Join(dir, "lamp.conf")
+	conf := "apiKeyCommand=echo run >> " + runs + " && echo key-from-command\n\n[profile eu]\napiKey=key-of-eu\n"
+	if err := ioutil.WriteFile(confPath, []byte(conf), 0600); err != nil {
+		t.Fatal(err)
+	}
+	LoadConfigFromGivenPath(confPath)
+	if err := SelectProfile(""); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if value := Get("apiKey"); value != "key-from-command" {
+			t.Fatalf("Get returned %q, want key-from-command", value)
+		}
+	}
+	if content, _ := ioutil.ReadFile(runs); string(content) != "run\n" {
+		t.Errorf("the command ran %d times, want once", len(content)/len("run\n"))
+	}
+
+	// selecting a profile resolves the values again
+	if err := SelectProfile("eu"); err != nil {
+		t.Fatal(err)
+	}
+	if value := Get("apiKey"); value != "key-of-eu" {
+		t.Errorf("Get returned %q after selecting the profile, want key-of-eu", value)
+	}
+	if err := SelectProfile("us"); err == nil {
+		t.Error("SelectProfile accepted a profile missing in the configuration file")
+	}
+}
+
+func TestSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	confPath := filepath.Join(dir, "lamp.conf")
+	ioutil.WriteFile(confPath, []byte("apiKey=top\nuserCommand=echo jane\n\n[profile eu]\napiKey=key-of-eu\n"), 0600)
+	LoadConfigFromGivenPath(confPath)
+	defer SelectProfile("")
+
+	os.Setenv("LAMP_API_URL", "api.eu.opsgenie.com")
+	defer os.Unsetenv("LAMP_API_URL")
+	sources := map[string]string{
+		"apiKey":  "configuration key apiKey of profile eu",
+		"apiUrl":  "environment variable LAMP_API_URL",
+		"user":    "output of the command given with configuration key userCommand",
+		"logPath": "",
+	}
+	if err := SelectProfile("eu"); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range sources {
+		if source := Source(key); source != want {
+			t.Errorf("Source(%q) = %q, want %q", key, source, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/*
+WriteValues sets the given keys in the configuration file, at the top of the file or in the section
+of the given profile, which is added when it does not exist. An empty value removes the key. Other
+lines, including comments, are kept as they are. The file is written with permissions allowing only
+its owner to read it, since it holds the API key.
+*/
+func WriteValues(confPath string, profileName string, values map[string]string) error {
+	var lines []string
+	if content, err := ioutil.ReadFile(confPath); err == nil {
+		lines = strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	// start and end are the lines of the section being written, the top of the file ends at the first section
+	start, end, found := 0, len(lines), profileName == ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) < 2 || trimmed[0] != '[' || trimmed[len(trimmed)-1] != ']' {
+			continue
+		}
+		section := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+		if found {
+			end = i
+			break
+		}
+		if strings.HasPrefix(section, profilePrefix) && strings.TrimSpace(strings.TrimPrefix(section, profilePrefix)) == profileName {
+			start, found = i+1, true
+		}
+	}
+	if !found {
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+profilePrefix+profileName+"]")
+		start, end = len(lines), len(lines)
+	}
+
+	written := map[string]bool{}
+	var section []string
+	for _, line := range lines[start:end] {
+		key := strings.TrimSpace(strings.SplitN(line, "=", 2)[0])
+		value, ok := values[key]
+		if !ok || strings.HasPrefix(strings.TrimSpace(line), "#") || !strings.Contains(line, "=") {
+			section = append(section, line)
+			continue
+		}
+		if !written[key] && value != "" {
+			section = append(section, key+"="+value)
+		}
+		written[key] = true
+	}
+	// new keys are added after the last key of the section, before the blank lines and comments following it
+	insertAt := len(section)
+	for insertAt > 0 && (strings.TrimSpace(section[insertAt-1]) == "" || strings.HasPrefix(strings.TrimSpace(section[insertAt-1]), "#")) {
+		insertAt--
+	}
+	if profileName == "" && insertAt == 0 {
+		// the comments at the top of the file are kept before the keys
+		insertAt = len(section)
+		for insertAt > 0 && strings.TrimSpace(section[insertAt-1]) == "" {
+			insertAt--
+		}
+	}
+	var added []string
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !written[key] && values[key] != "" {
+			added = append(added, key+"="+values[key])
+		}
+	}
+	section = append(section[:insertAt], append(added, section[insertAt:]...)...)
+
+	result := append(append(append([]string{}, lines[:start]...), section...), lines[end:]...)
+	if err := os.MkdirAll(filepath.Dir(confPath), 0700); err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(confPath), ".lamp.conf")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.WriteString(strings.Join(result, "\n") + "\n"); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), confPath)
+}
@@ -0,0 +1,73 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// writeValues writes the values into a configuration file with the given content and returns the written content.
+func writeValues(t *testing.T, content string, profile string, values map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "lamp-cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	confPath := filepath.Join(dir, "lamp.conf")
+	if content != "" {
+		if err := ioutil.WriteFile(confPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := WriteValues(confPath, profile, values); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(confPath); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("file mode is %v, want 0600", info.Mode().Perm())
+	}
+	// the temporary file is renamed to the configuration file
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("directory has %d files, want only lamp.conf", len(files))
+	}
+	written, err := ioutil.ReadFile(confPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(written)
+}
+
+func TestWriteValuesToTheTop(t *testing.T) {
+	if got := writeValues(t, "", "", map[string]string{"apiUrl": "api.eu.opsgenie.com", "apiKey": "key"}); got != "apiKey=key\napiUrl=api.eu.opsgenie.com\n" {
+		t.Errorf("new file is %q, want the keys sorted", got)
+	}
+	if got := writeValues(t, "# lamp configuration\n", "", map[string]string{"apiKey": "key"}); got != "# lamp configuration\napiKey=key\n" {
+		t.Errorf("file is %q, want the key after the comments", got)
+	}
+
+	content := "# lamp configuration\napiKey=old\nuser=jane\n\n[profile eu]\napiKey=key-of-eu\n"
+	want := "# lamp configuration\napiKey=new\nuser=jane\n\n[profile eu]\napiKey=key-of-eu\n"
+	if got := writeValues(t, content, "", map[string]string{"apiKey": "new"}); got != want {
+		t.Errorf("updated file is %q, want %q", got, want)
+	}
+	if got := writeValues(t, "apiKey=key\nuser=jane\n", "", map[string]string{"user": ""}); got != "apiKey=key\n" {
+		t.Errorf("file is %q, want the key with an empty value removed", got)
+	}
+}
+
+func TestWriteValuesToAProfile(t *testing.T) {
+	if got := writeValues(t, "apiKey=key\n", "eu", map[string]string{"apiKey": "key-of-eu"}); got != "apiKey=key\n\n[profile eu]\napiKey=key-of-eu\n" {
+		t.Errorf("file is %q, want the profile section added at the end", got)
+	}
+
+	content := "apiKey=key\n\n[profile eu]\napiKey=old\n# end of eu\n\n[profile us]\napiKey=key-of-us\n"
+	want := "apiKey=key\n\n[profile eu]\napiKey=key-of-eu\napiUrl=api.eu.opsgenie.com\n# end of eu\n\n[profile us]\napiKey=key-of-us\n"
+	if got := writeValues(t, content, "eu", map[string]string{"apiKey": "key-of-eu", "apiUrl": "api.eu.opsgenie.com"}); got != want {
+		t.Errorf("file is %q, want %q", got, want)
+	}
+}
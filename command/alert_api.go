@@ -0,0 +1,655 @@
+package command
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
+)
+
+// AlertIdentifier identifies an alert by its ID, or by its tiny id or alias when IdentifierType is tiny or alias.
+type AlertIdentifier struct {
+	ID             string
+	IdentifierType string
+}
+
+func (i AlertIdentifier) identifierType() alert.AlertIdentifier {
+	switch i.IdentifierType {
+	case "tiny":
+		return alert.TINYID
+	case "alias":
+		return alert.ALIAS
+	}
+	return alert.ALERTID
+}
+
+// AlertActionOptions are the options of the actions changing an alert, User is the user of the configuration in lamp.
+type AlertActionOptions struct {
+	AlertIdentifier
+	User   string
+	Source string
+	Note   string
+}
+
+type CreateAlertOptions struct {
+	Message     string
+	Alias       string
+	Description string
+	// Teams, Users, Escalations and Schedules are the names of the responders.
+	Teams       []string
+	Users       []string
+	Escalations []string
+	Schedules   []string
+	Actions     []string
+	Source      string
+	Tags        []string
+	Details     map[string]string
+	Entity      string
+	Priority    string
+	User        string
+	Note        string
+}
+
+// CreateAlert creates an alert at Opsgenie.
+func (a *API) CreateAlert(ctx context.Context, opts CreateAlertOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	req := alert.CreateAlertRequest{
+		Message:     opts.Message,
+		Alias:       opts.Alias,
+		Description: opts.Description,
+		Actions:     opts.Actions,
+		Source:      opts.Source,
+		Tags:        opts.Tags,
+		Details:     opts.Details,
+		Entity:      opts.Entity,
+		Priority:    alert.Priority(opts.Priority),
+		User:        opts.User,
+		Note:        opts.Note,
+	}
+	req.Responders = generateResponders(opts.Teams, alert.TeamResponder)
+	req.Responders = append(req.Responders, generateResponders(opts.Users, alert.UserResponder)...)
+	req.Responders = append(req.Responders, generateResponders(opts.Escalations, alert.EscalationResponder)...)
+	req.Responders = append(req.Responders, generateResponders(opts.Schedules, alert.ScheduleResponder)...)
+	return cli.Create(ctx, &req)
+}
+
+func generateResponders(names []string, responderType alert.ResponderType) []alert.Responder {
+	var responders []alert.Responder
+	for _, name := range names {
+		responders = append(responders, alert.Responder{
+			Name:     name,
+			Username: name,
+			Type:     responderType,
+		})
+	}
+	return responders
+}
+
+// GetAlert retrieves the details of an alert from Opsgenie.
+func (a *API) GetAlert(ctx context.Context, opts AlertIdentifier) (*alert.GetAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Get(ctx, &alert.GetAlertRequest{IdentifierType: opts.identifierType(), IdentifierValue: opts.ID})
+}
+
+type AttachFileOptions struct {
+	AlertIdentifier
+	FilePath  string
+	FileName  string
+	IndexFile string
+	User      string
+}
+
+// AttachFile attaches a file to an alert at Opsgenie.
+func (a *API) AttachFile(ctx context.Context, opts AttachFileOptions) (*alert.CreateAlertAttachmentsResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.CreateAlertAttachments(ctx, &alert.CreateAlertAttachmentRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		FilePath:        opts.FilePath,
+		FileName:        opts.FileName,
+		IndexFile:       opts.IndexFile,
+		User:            opts.User,
+	})
+}
+
+type AttachmentOptions struct {
+	AlertIdentifier
+	AttachmentID string
+}
+
+// GetAttachment retrieves the download link of an alert attachment.
+func (a *API) GetAttachment(ctx context.Context, opts AttachmentOptions) (*alert.GetAttachmentResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.GetAlertAttachment(ctx, &alert.GetAttachmentRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		AttachmentId:    opts.AttachmentID,
+	})
+}
+
+type DownloadAttachmentOptions struct {
+	AttachmentOptions
+	// DestinationPath is the directory of the downloaded file, the working directory if it is empty.
+	DestinationPath string
+}
+
+// DownloadAttachment downloads an alert attachment and returns the path of the downloaded file.
+func (a *API) DownloadAttachment(ctx context.Context, opts DownloadAttachmentOptions) (string, error) {
+	resp, err := a.GetAttachment(ctx, opts.AttachmentOptions)
+	if err != nil {
+		return "", err
+	}
+	path := resp.Name
+	if opts.DestinationPath != "" {
+		path = filepath.Join(opts.DestinationPath, resp.Name)
+	}
+	if err := downloadFileSafely(ctx, downloadClient(a.config), path, resp.Url); err != nil {
+		return "", newError(ExitCodeError, "Error while downloading "+resp.Name+" - "+err.Error())
+	}
+	return path, nil
+}
+
+// ListAlertAttachments retrieves the meta information of the attachments of an alert.
+func (a *API) ListAlertAttachments(ctx context.Context, opts AlertIdentifier) (*alert.ListAttachmentsResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.ListAlertsAttachments(ctx, &alert.ListAttachmentsRequest{IdentifierType: opts.identifierType(), IdentifierValue: opts.ID})
+}
+
+// DeleteAlertAttachment deletes an attachment of an alert.
+func (a *API) DeleteAlertAttachment(ctx context.Context, opts AttachmentOptions) (*alert.DeleteAlertAttachmentResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.DeleteAlertAttachment(ctx, &alert.DeleteAttachmentRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		AttachmentId:    opts.AttachmentID,
+	})
+}
+
+// Acknowledge acknowledges an alert at Opsgenie.
+func (a *API) Acknowledge(ctx context.Context, opts AlertActionOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Acknowledge(ctx, &alert.AcknowledgeAlertRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+type AssignOwnerOptions struct {
+	AlertActionOptions
+	// Owner is the username of the new owner.
+	Owner string
+}
+
+// AssignOwner assigns a user as the owner of an alert at Opsgenie.
+func (a *API) AssignOwner(ctx context.Context, opts AssignOwnerOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.AssignAlert(ctx, &alert.AssignRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Owner:           alert.User{Username: opts.Owner},
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+type AddTeamOptions struct {
+	AlertActionOptions
+	// Team is the name of the team.
+	Team string
+}
+
+// AddTeam adds a team to an alert at Opsgenie.
+func (a *API) AddTeam(ctx context.Context, opts AddTeamOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.AddTeam(ctx, &alert.AddTeamRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Team:            alert.Team{Name: opts.Team},
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+type AddResponderOptions struct {
+	AlertActionOptions
+	// Type is the type of the responder, user or team.
+	Type      string
+	Responder string
+}
+
+// AddResponder adds a responder to an alert at Opsgenie.
+func (a *API) AddResponder(ctx context.Context, opts AddResponderOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	req := alert.AddResponderRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	}
+	if opts.Type != "" && opts.Responder != "" {
+		req.Responder = alert.Responder{
+			Type:     alert.ResponderType(opts.Type),
+			Name:     opts.Responder,
+			Username: opts.Responder,
+		}
+	}
+	return cli.AddResponder(ctx, &req)
+}
+
+type AddTagsOptions struct {
+	AlertActionOptions
+	Tags []string
+}
+
+// AddTags adds tags to an alert at Opsgenie.
+func (a *API) AddTags(ctx context.Context, opts AddTagsOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.AddTags(ctx, &alert.AddTagsRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Tags:            opts.Tags,
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+// AddNote adds a note to an alert at Opsgenie.
+func (a *API) AddNote(ctx context.Context, opts AlertActionOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.AddNote(ctx, &alert.AddNoteRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+type ExecuteActionOptions struct {
+	AlertActionOptions
+	Action string
+}
+
+// ExecuteAction executes a custom action on an alert at Opsgenie.
+func (a *API) ExecuteAction(ctx context.Context, opts ExecuteActionOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.ExecuteCustomAction(ctx, &alert.ExecuteCustomActionAlertRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Action:          opts.Action,
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+// CloseAlert closes an alert at Opsgenie.
+func (a *API) CloseAlert(ctx context.Context, opts AlertActionOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Close(ctx, &alert.CloseAlertRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+type DeleteAlertOptions struct {
+	AlertIdentifier
+	Source string
+}
+
+// DeleteAlert deletes an alert at Opsgenie.
+func (a *API) DeleteAlert(ctx context.Context, opts DeleteAlertOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Delete(ctx, &alert.DeleteAlertRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Source:          opts.Source,
+	})
+}
+
+/*
+ListAlertsOptions are the options of listing alerts. When Query is empty, the query is made of the time ranges
+in epoch milliseconds, Status, Teams and Tags, joined with TagsOperator which is AND by default.
+*/
+type ListAlertsOptions struct {
+	Limit                int
+	Offset               int
+	Sort                 string
+	Order                string
+	SearchIdentifier     string
+	SearchIdentifierType string
+	Query                string
+	CreatedAfter         uint64
+	CreatedBefore        uint64
+	UpdatedAfter         uint64
+	UpdatedBefore        uint64
+	Status               string
+	Teams                []string
+	Tags                 []string
+	TagsOperator         string
+}
+
+// ListAlerts retrieves a page of alerts from Opsgenie.
+func (a *API) ListAlerts(ctx context.Context, opts ListAlertsOptions) (*alert.ListAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	req := generateListAlertRequest(opts)
+	return cli.List(ctx, &req)
+}
+
+// CountAlerts retrieves the number of alerts matching the options, at most Limit of them.
+func (a *API) CountAlerts(ctx context.Context, opts ListAlertsOptions) (int, error) {
+	resp, err := a.ListAlerts(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+	return len(resp.Alerts), nil
+}
+
+func generateListAlertRequest(opts ListAlertsOptions) alert.ListAlertRequest {
+	req := alert.ListAlertRequest{
+		Limit:            opts.Limit,
+		Offset:           opts.Offset,
+		Sort:             alert.SortField(opts.Sort),
+		Order:            alert.Order(opts.Order),
+		SearchIdentifier: opts.SearchIdentifier,
+		Query:            opts.Query,
+	}
+	if opts.SearchIdentifierType != "" {
+		if alert.SearchIdentifierType(opts.SearchIdentifierType) == alert.NAME {
+			req.SearchIdentifierType = alert.NAME
+		} else {
+			req.SearchIdentifierType = alert.ID
+		}
+	}
+	if req.Query == "" {
+		req.Query = generateQueryUsingOldStyleParams(opts)
+	}
+	return req
+}
+
+func generateQueryUsingOldStyleParams(opts ListAlertsOptions) string {
+	var queries []string
+	if opts.CreatedAfter != 0 {
+		queries = append(queries, "createdAt > "+strconv.FormatUint(opts.CreatedAfter, 10))
+	}
+	if opts.CreatedBefore != 0 {
+		queries = append(queries, "createdAt < "+strconv.FormatUint(opts.CreatedBefore, 10))
+	}
+	if opts.UpdatedAfter != 0 {
+		queries = append(queries, "updatedAt > "+strconv.FormatUint(opts.UpdatedAfter, 10))
+	}
+	if opts.UpdatedBefore != 0 {
+		queries = append(queries, "updatedAt < "+strconv.FormatUint(opts.UpdatedBefore, 10))
+	}
+	if opts.Status != "" {
+		queries = append(queries, "status: "+opts.Status)
+	}
+	for _, teamName := range opts.Teams {
+		queries = append(queries, "teams: "+teamName)
+	}
+	if len(opts.Tags) != 0 {
+		operator := opts.TagsOperator
+		if operator == "" {
+			operator = "AND"
+		}
+		queries = append(queries, "tag: ("+strings.Join(opts.Tags, " "+operator+" ")+")")
+	}
+	return strings.Join(queries, " AND ")
+}
+
+// ListAlertLogsOptions are the options of listing the notes or the logs of an alert, Offset is the offset of the previous page.
+type ListAlertLogsOptions struct {
+	AlertIdentifier
+	Limit     uint32
+	Order     string
+	Direction string
+	Offset    string
+}
+
+// ListAlertNotes retrieves a page of the notes of an alert from Opsgenie.
+func (a *API) ListAlertNotes(ctx context.Context, opts ListAlertLogsOptions) (*alert.ListAlertNotesResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.ListAlertNotes(ctx, &alert.ListAlertNotesRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Limit:           opts.Limit,
+		Order:           alert.Order(opts.Order),
+		Direction:       alert.RequestDirection(opts.Direction),
+		Offset:          opts.Offset,
+	})
+}
+
+// ListAlertLogs retrieves a page of the logs of an alert from Opsgenie.
+func (a *API) ListAlertLogs(ctx context.Context, opts ListAlertLogsOptions) (*alert.ListAlertLogsResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.ListAlertLogs(ctx, &alert.ListAlertLogsRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Limit:           opts.Limit,
+		Order:           alert.Order(opts.Order),
+		Direction:       alert.RequestDirection(opts.Direction),
+		Offset:          opts.Offset,
+	})
+}
+
+// ListAlertRecipients retrieves the recipients of an alert from Opsgenie.
+func (a *API) ListAlertRecipients(ctx context.Context, opts AlertIdentifier) (*alert.ListAlertRecipientResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.ListAlertRecipients(ctx, &alert.ListAlertRecipientRequest{IdentifierType: opts.identifierType(), IdentifierValue: opts.ID})
+}
+
+// UnAcknowledge unacknowledges an alert at Opsgenie.
+func (a *API) UnAcknowledge(ctx context.Context, opts AlertActionOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Unacknowledge(ctx, &alert.UnacknowledgeAlertRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+type SnoozeOptions struct {
+	AlertActionOptions
+	EndTime time.Time
+}
+
+// Snooze snoozes an alert at Opsgenie until EndTime.
+func (a *API) Snooze(ctx context.Context, opts SnoozeOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Snooze(ctx, &alert.SnoozeAlertRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		EndTime:         opts.EndTime,
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+type RemoveTagsOptions struct {
+	AlertActionOptions
+	Tags []string
+}
+
+// RemoveTags removes tags from an alert at Opsgenie.
+func (a *API) RemoveTags(ctx context.Context, opts RemoveTagsOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.RemoveTags(ctx, &alert.RemoveTagsRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Tags:            strings.Join(opts.Tags, ","),
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+type AddDetailsOptions struct {
+	AlertActionOptions
+	Details map[string]string
+}
+
+// AddDetails adds details to an alert at Opsgenie.
+func (a *API) AddDetails(ctx context.Context, opts AddDetailsOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.AddDetails(ctx, &alert.AddDetailsRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Details:         opts.Details,
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+type RemoveDetailsOptions struct {
+	AlertActionOptions
+	Keys []string
+}
+
+// RemoveDetails removes details from an alert at Opsgenie.
+func (a *API) RemoveDetails(ctx context.Context, opts RemoveDetailsOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.RemoveDetails(ctx, &alert.RemoveDetailsRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Keys:            strings.Join(opts.Keys, ","),
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
+
+type EscalateToNextOptions struct {
+	AlertActionOptions
+	EscalationID   string
+	EscalationName string
+}
+
+// EscalateToNext processes the next available rule of an escalation for an alert.
+func (a *API) EscalateToNext(ctx context.Context, opts EscalateToNextOptions) (*alert.AsyncAlertResult, error) {
+	cli, release, err := a.alertClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.EscalateToNext(ctx, &alert.EscalateToNextRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Escalation:      alert.Escalation{ID: opts.EscalationID, Name: opts.EscalationName},
+		User:            opts.User,
+		Source:          opts.Source,
+		Note:            opts.Note,
+	})
+}
@@ -1,104 +1,74 @@
 package command
 
 import (
-	"errors"
-	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
-	gcli "github.com/urfave/cli"
-	"io"
-	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
+	gcli "github.com/urfave/cli"
 )
 
 func NewAlertClient(c *gcli.Context) (*alert.Client, error) {
-	alertCli, cliErr := alert.NewClient(getConfigurations(c))
-	if cliErr != nil {
-		message := "Can not create the alert client. " + cliErr.Error()
-		printMessage(ERROR,message)
-		return nil, errors.New(message)
-	}
-	printMessage(DEBUG,"Alert Client created.")
-	return alertCli, nil
-}
-
-// CreateAlertAction creates an alert at Opsgenie.
-func CreateAlertAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
+	cli, _, err := cliAPI(c).alertClient(rootContext())
 	if err != nil {
-		os.Exit(1)
-	}
-	req := alert.CreateAlertRequest{}
-
-	if val, success := getVal("message", c); success {
-		req.Message = val
+		return nil, err
 	}
-	responders := generateResponders(c, alert.TeamResponder, "teams")
-	responders = append(responders, generateResponders(c, alert.UserResponder, "users")...)
-	responders = append(responders, generateResponders(c, alert.EscalationResponder, "escalations")...)
-	responders = append(responders, generateResponders(c, alert.ScheduleResponder, "schedules")...)
-
-	req.Responders = responders
-
-	if val, success := getVal("alias", c); success {
-		req.Alias = val
-	}
-	if val, success := getVal("actions", c); success {
-		req.Actions = strings.Split(val, ",")
-	}
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("tags", c); success {
-		req.Tags = strings.Split(val, ",")
-	}
-	if val, success := getVal("description", c); success {
-		req.Description = val
-	}
-	if val, success := getVal("entity", c); success {
-		req.Entity = val
-	}
-	if val, success := getVal("priority", c); success {
-		req.Priority = alert.Priority(val)
-	}
-
-	req.User = grabUsername(c)
+	printMessage(DEBUG, "Alert Client created.")
+	return cli, nil
+}
 
-	if val, success := getVal("note", c); success {
-		req.Note = val
-	}
-	if c.IsSet("D") {
-		req.Details = extractDetailsFromCommand(c)
-	}
+func grabAlertIdentifier(c *gcli.Context) AlertIdentifier {
+	opts := AlertIdentifier{}
+	opts.ID, _ = getVal("id", c)
+	opts.IdentifierType, _ = getVal("identifier", c)
+	return opts
+}
 
-	printMessage(DEBUG,"Create alert request prepared from flags, sending request to Opsgenie...")
+func grabAlertActionOptions(c *gcli.Context) AlertActionOptions {
+	opts := AlertActionOptions{AlertIdentifier: grabAlertIdentifier(c), User: grabUsername(c)}
+	opts.Source, _ = getVal("source", c)
+	opts.Note, _ = getVal("note", c)
+	return opts
+}
 
-	resp, err := cli.Create(nil, &req)
+// alertRequestID returns the request id of an asynchronous alert action, for the actions applied to the alerts matching a query.
+func alertRequestID(resp *alert.AsyncAlertResult, err error) (string, error) {
 	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
+		return "", err
 	}
-	printMessage(DEBUG,"Alert will be created.")
-	printMessage(INFO, resp.RequestId)
+	return resp.RequestId, nil
 }
 
-func generateResponders(c *gcli.Context, responderType alert.ResponderType, parameter string) []alert.Responder {
-	if val, success := getVal(parameter, c); success {
-		responderNames := strings.Split(val, ",")
+// CreateAlertAction creates an alert at Opsgenie.
+func CreateAlertAction(c *gcli.Context) {
+	opts := CreateAlertOptions{
+		Teams:       grabList("teams", c),
+		Users:       grabList("users", c),
+		Escalations: grabList("escalations", c),
+		Schedules:   grabList("schedules", c),
+		Actions:     grabList("actions", c),
+		Tags:        grabList("tags", c),
+		User:        grabUsername(c),
+	}
+	opts.Message, _ = getVal("message", c)
+	opts.Alias, _ = getVal("alias", c)
+	opts.Source, _ = getVal("source", c)
+	opts.Description, _ = getVal("description", c)
+	opts.Entity, _ = getVal("entity", c)
+	opts.Priority, _ = getVal("priority", c)
+	opts.Note, _ = getVal("note", c)
+	if c.IsSet("D") {
+		opts.Details = extractDetailsFromCommand(c)
+	}
 
-		var responders []alert.Responder
+	printMessage(DEBUG, "Create alert request prepared from flags, sending request to Opsgenie...")
 
-		for _, name := range responderNames {
-			responders = append(responders, alert.Responder{
-				Name:     name,
-				Username: name,
-				Type:     responderType,
-			})
-		}
-		return responders
-	}
-	return nil
+	api := cliAPI(c)
+	resp, err := api.CreateAlert(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Alert will be created.")
+	printAlertRequest(c, api, resp.RequestId, resp.RequestId)
 }
 
 func extractDetailsFromCommand(c *gcli.Context) map[string]string {
@@ -110,9 +80,8 @@ func extractDetailsFromCommand(c *gcli.Context) map[string]string {
 			p := strings.Split(prop, "=")
 			details[p[0]] = strings.Join(p[1:], "=")
 		} else {
-			printMessage(ERROR, "Dynamic parameters should have the value of the form a=b, but got: " + prop + "\n")
 			gcli.ShowCommandHelp(c, c.Command.Name)
-			os.Exit(1)
+			exitOnUsageErr("Dynamic parameters should have the value of the form a=b, but got: " + prop)
 		}
 	}
 
@@ -121,1121 +90,489 @@ func extractDetailsFromCommand(c *gcli.Context) map[string]string {
 
 // GetAlertAction retrieves specified alert details from Opsgenie.
 func GetAlertAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-	req := alert.GetAlertRequest{}
+	printMessage(DEBUG, "Get alert request prepared from flags, sending request to Opsgenie...")
 
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	printMessage(DEBUG,"Get alert request prepared from flags, sending request to Opsgenie...")
-
-	resp, err := cli.Get(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	resp, err := cliAPI(c).GetAlert(rootContext(), grabAlertIdentifier(c))
+	exitOnErr(err)
 
-	outputFormat := strings.ToLower(c.String("output-format"))
-	printMessage(DEBUG,"Got Alert successfully, and will print as " + outputFormat)
-	switch outputFormat {
-	case "yaml":
-		output, err := resultToYAML(resp)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	default:
-		isPretty := c.IsSet("pretty")
-		output, err := resultToJSON(resp, isPretty)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	}
+	printMessage(DEBUG, "Got Alert successfully.")
+	renderResult(c, resp)
 }
 
 // AttachFileAction attaches a file to an alert at Opsgenie.
 func AttachFileAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.CreateAlertAttachmentRequest{}
+	opts := AttachFileOptions{AlertIdentifier: grabAlertIdentifier(c), User: grabUsername(c)}
+	opts.FilePath, _ = getVal("filePath", c)
+	opts.FileName, _ = getVal("fileName", c)
+	opts.IndexFile, _ = getVal("indexFile", c)
 
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-	if val, success := getVal("filePath", c); success {
-		req.FilePath = val
-	}
-	if val, success := getVal("fileName", c); success {
-		req.FileName = val
-	}
-	if val, success := getVal("indexFile", c); success {
-		req.IndexFile = val
-	}
-
-	req.User = grabUsername(c)
+	printMessage(DEBUG, "Attach request prepared from flags, sending request to Opsgenie..")
 
-	printMessage(DEBUG,"Attach request prepared from flags, sending request to Opsgenie..")
+	response, err := cliAPI(c).AttachFile(rootContext(), opts)
+	exitOnErr(err)
 
-	response, err := cli.CreateAlertAttachments(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	printMessage(DEBUG, "File attached to alert successfully.")
+	printResultMessage(c, "Result : "+response.Result+"\n")
+}
 
-	printMessage(DEBUG,"File attached to alert successfully.")
-	printMessage(INFO, "Result : " + response.Result + "\n")
+func grabAttachmentOptions(c *gcli.Context) AttachmentOptions {
+	opts := AttachmentOptions{AlertIdentifier: grabAlertIdentifier(c)}
+	opts.AttachmentID, _ = getVal("attachmentId", c)
+	return opts
 }
 
 // GetAttachmentAction retrieves a download link to specified alert attachment
 func GetAttachmentAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.GetAttachmentRequest{}
+	printMessage(DEBUG, "Get alert attachment request prepared from flags, sending request to Opsgenie..")
 
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	if val, success := getVal("attachmentId", c); success {
-		req.AttachmentId = val
-	}
-
-	printMessage(DEBUG,"Get alert attachment request prepared from flags, sending request to Opsgenie..")
-
-	resp, err := cli.GetAlertAttachment(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	resp, err := cliAPI(c).GetAttachment(rootContext(), grabAttachmentOptions(c))
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Got Alert Attachment successfully, and will print download link.")
-	printMessage(INFO, "Download Link: " + resp.Url)
+	printMessage(DEBUG, "Got Alert Attachment successfully, and will print download link.")
+	printResultMessage(c, "Download Link: "+resp.Url)
 }
 
 // DownloadAttachmentAction downloads the attachment specified with attachmentId for given alert
 func DownloadAttachmentAction(c *gcli.Context) {
-	var destinationPath string
-	cli, err := NewAlertClient(c)
-
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.GetAttachmentRequest{}
+	opts := DownloadAttachmentOptions{AttachmentOptions: grabAttachmentOptions(c)}
+	opts.DestinationPath, _ = getVal("destinationPath", c)
 
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
+	printMessage(DEBUG, "Download alert attachment request prepared from flags, sending request to Opsgenie..")
 
-	if val, success := getVal("attachmentId", c); success {
-		req.AttachmentId = val
-	}
-
-	if val, success := getVal("destinationPath", c); success {
-		destinationPath = val
-	}
-
-	printMessage(DEBUG,"Download alert attachment request prepared from flags, sending request to Opsgenie..")
-
-	resp, err := cli.GetAlertAttachment(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-
-	fileName := resp.Name
-	downloadLink := resp.Url
-
-	var output *os.File
-
-	if destinationPath != "" {
-		output, err = os.Create(destinationPath + "/" + fileName)
-	} else {
-		output, err = os.Create(fileName)
-	}
-
-	if err != nil {
-		printMessage(ERROR, "Error while creating " + fileName + "-" + err.Error())
-		return
-	}
-	defer output.Close()
-
-	response, err := http.Get(downloadLink)
-	if err != nil {
-		printMessage(ERROR, "Error while downloading " + fileName + "-" + err.Error())
-		return
-	}
-	defer response.Body.Close()
-
-	_, err = io.Copy(output, response.Body)
-
-	if err != nil {
-		printMessage(ERROR,"Error while downloading " + fileName + " - " + err.Error())
-		return
-	}
+	path, err := cliAPI(c).DownloadAttachment(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Attachment is downloaded to "+path)
 }
 
 // ListAlertAttachmentsAction returns a list of attachment meta information for specified alert
 func ListAlertAttachmentsAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.ListAttachmentsRequest{}
-
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	printMessage(DEBUG,"List alert attachments request prepared from flags, sending request to Opsgenie..")
-
-	resp, err := cli.ListAlertsAttachments(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	printMessage(DEBUG, "List alert attachments request prepared from flags, sending request to Opsgenie..")
 
-	outputFormat := strings.ToLower(c.String("output-format"))
-	printMessage(DEBUG,"List Alert Attachment successfully, and will print as " + outputFormat)
-	switch outputFormat {
-	case "yaml":
-		output, err := resultToYAML(resp.Attachment)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	default:
-		isPretty := c.IsSet("pretty")
-		output, err := resultToJSON(resp.Attachment, isPretty)
-
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
+	resp, err := cliAPI(c).ListAlertAttachments(rootContext(), grabAlertIdentifier(c))
+	exitOnErr(err)
 
-		printMessage(INFO, output)
-	}
+	printMessage(DEBUG, "List Alert Attachment successfully.")
+	renderResult(c, resp.Attachment)
 }
 
 // DeleteAlertAttachmentAction deletes the specified alert attachment from alert
 func DeleteAlertAttachmentAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.DeleteAttachmentRequest{}
-
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	if val, success := getVal("attachmentId", c); success {
-		req.AttachmentId = val
-	}
+	printMessage(DEBUG, "Delete alert attachment request prepared from flags, sending request to OpsGenie..")
 
-	printMessage(DEBUG,"Delete alert attachment request prepared from flags, sending request to OpsGenie..")
-
-	resp, err := cli.DeleteAlertAttachment(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	resp, err := cliAPI(c).DeleteAlertAttachment(rootContext(), grabAttachmentOptions(c))
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Alert attachment will be deleted. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
-	printMessage(INFO,"Result: " + resp.Result)
+	printMessage(DEBUG, "Alert attachment will be deleted. RequestID: "+resp.RequestId)
+	printResultMessage(c, "RequestID: "+resp.RequestId)
+	printResultMessage(c, "Result: "+resp.Result)
 }
 
 // AcknowledgeAction acknowledges an alert at Opsgenie.
 func AcknowledgeAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.AcknowledgeAlertRequest{}
-
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
+	api := cliAPI(c)
+	opts := grabAlertActionOptions(c)
+
+	if isBulkRequested(c) {
+		runBulkAlertAction(c, api, "acknowledge", func(alertID string) (string, error) {
+			bulkOpts := opts
+			bulkOpts.AlertIdentifier = AlertIdentifier{ID: alertID}
+			return alertRequestID(api.Acknowledge(rootContext(), bulkOpts))
+		})
+		return
 	}
 
-	printMessage(DEBUG,"Acknowledge alert request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Acknowledge alert request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.Acknowledge(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	resp, err := api.Acknowledge(rootContext(), opts)
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Acknowledge request will be processed. RequestID " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Acknowledge request will be processed. RequestID "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // AssignOwnerAction assigns the specified user as the owner of the alert at Opsgenie.
 func AssignOwnerAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.AssignRequest{}
-
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	if val, success := getVal("owner", c); success {
-		req.Owner = alert.User{Username: val}
-	}
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
+	api := cliAPI(c)
+	opts := AssignOwnerOptions{AlertActionOptions: grabAlertActionOptions(c)}
+	opts.Owner, _ = getVal("owner", c)
+
+	if isBulkRequested(c) {
+		runBulkAlertAction(c, api, "assign", func(alertID string) (string, error) {
+			bulkOpts := opts
+			bulkOpts.AlertIdentifier = AlertIdentifier{ID: alertID}
+			return alertRequestID(api.AssignOwner(rootContext(), bulkOpts))
+		})
+		return
 	}
 
-	printMessage(DEBUG,"Assign ownership request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Assign ownership request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.AssignAlert(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	resp, err := api.AssignOwner(rootContext(), opts)
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Ownership assignment request will be processed. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Ownership assignment request will be processed. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // AddTeamAction adds a team to an alert at Opsgenie.
 func AddTeamAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-	req := alert.AddTeamRequest{}
+	opts := AddTeamOptions{AlertActionOptions: grabAlertActionOptions(c)}
+	opts.Team, _ = getVal("team", c)
 
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	if val, success := getVal("team", c); success {
-		req.Team = alert.Team{Name: val}
-	}
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
-	}
-
-	printMessage(DEBUG,"Add team request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Add team request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.AddTeam(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Add team request will be processed. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	api := cliAPI(c)
+	resp, err := api.AddTeam(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Add team request will be processed. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // AddResponderAction adds responder to an alert at Opsgenie.
 func AddResponderAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-	req := alert.AddResponderRequest{}
-
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	if valType, success := getVal("type", c); success {
-		if val, success := getVal("responder", c); success {
-			req.Responder = alert.Responder{
-				Type:     alert.ResponderType(valType),
-				Name:     val,
-				Username: val,
-			}
-		}
-	}
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
-	}
+	opts := AddResponderOptions{AlertActionOptions: grabAlertActionOptions(c)}
+	opts.Type, _ = getVal("type", c)
+	opts.Responder, _ = getVal("responder", c)
 
-	printMessage(DEBUG,"Add responder request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Add responder request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.AddResponder(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Add responder request will be processed. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	api := cliAPI(c)
+	resp, err := api.AddResponder(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Add responder request will be processed. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // AddTagsAction adds tags to an alert at Opsgenie.
 func AddTagsAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.AddTagsRequest{}
-
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-	if val, success := getVal("tags", c); success {
-		req.Tags = strings.Split(val, ",")
-	}
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
+	api := cliAPI(c)
+	opts := AddTagsOptions{AlertActionOptions: grabAlertActionOptions(c), Tags: grabList("tags", c)}
+
+	if isBulkRequested(c) {
+		runBulkAlertAction(c, api, "tag", func(alertID string) (string, error) {
+			bulkOpts := opts
+			bulkOpts.AlertIdentifier = AlertIdentifier{ID: alertID}
+			return alertRequestID(api.AddTags(rootContext(), bulkOpts))
+		})
+		return
 	}
 
-	printMessage(DEBUG,"Add tag request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Add tag request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.AddTags(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Add tags request will be processed. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	resp, err := api.AddTags(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Add tags request will be processed. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // AddNoteAction adds a note to an alert at Opsgenie.
 func AddNoteAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.AddNoteRequest{}
-
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
+	api := cliAPI(c)
+	opts := grabAlertActionOptions(c)
+
+	if isBulkRequested(c) {
+		runBulkAlertAction(c, api, "add a note to", func(alertID string) (string, error) {
+			bulkOpts := opts
+			bulkOpts.AlertIdentifier = AlertIdentifier{ID: alertID}
+			return alertRequestID(api.AddNote(rootContext(), bulkOpts))
+		})
+		return
 	}
 
-	printMessage(DEBUG,"Add note request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Add note request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.AddNote(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Add note request will be processed. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	resp, err := api.AddNote(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Add note request will be processed. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // ExecuteActionAction executes a custom action on an alert at Opsgenie.
 func ExecuteActionAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.ExecuteCustomActionAlertRequest{}
-
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	if action, success := getVal("action", c); success {
-		req.Action = action
-	}
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
-	}
+	opts := ExecuteActionOptions{AlertActionOptions: grabAlertActionOptions(c)}
+	opts.Action, _ = getVal("action", c)
 
-	printMessage(DEBUG,"Execute action request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Execute action request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.ExecuteCustomAction(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Execute custom action request will be processed. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	api := cliAPI(c)
+	resp, err := api.ExecuteAction(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Execute custom action request will be processed. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // CloseAlertAction closes an alert at Opsgenie.
 func CloseAlertAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.CloseAlertRequest{}
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
+	api := cliAPI(c)
+	opts := grabAlertActionOptions(c)
+
+	if isBulkRequested(c) {
+		runBulkAlertAction(c, api, "close", func(alertID string) (string, error) {
+			bulkOpts := opts
+			bulkOpts.AlertIdentifier = AlertIdentifier{ID: alertID}
+			return alertRequestID(api.CloseAlert(rootContext(), bulkOpts))
+		})
+		return
 	}
 
-	printMessage(DEBUG,"Close alert request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Close alert request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.Close(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Alert will be closed. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	resp, err := api.CloseAlert(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Alert will be closed. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // DeleteAlertAction deletes an alert at Opsgenie.
 func DeleteAlertAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.DeleteAlertRequest{}
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
+	opts := DeleteAlertOptions{AlertIdentifier: grabAlertIdentifier(c)}
+	opts.Source, _ = getVal("source", c)
 
-	printMessage(DEBUG,"Delete alert request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Delete alert request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.Delete(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	api := cliAPI(c)
+	resp, err := api.DeleteAlert(rootContext(), opts)
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Alert will be deleted. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Alert will be deleted. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // ListAlertsAction retrieves alert details from Opsgenie.
 func ListAlertsAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := generateListAlertRequest(c)
+	api := cliAPI(c)
+	opts := grabListAlertsOptions(c)
 
-	printMessage(DEBUG,"List alerts request prepared from flags, sending request to Opsgenie..")
-
-	resp, err := cli.List(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-
-	outputFormat := strings.ToLower(c.String("output-format"))
-	printMessage(DEBUG,"Got Alerts successfully, and will print as " + outputFormat)
-	switch outputFormat {
-	case "yaml":
-		output, err := resultToYAML(resp.Alerts)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	default:
-		isPretty := c.IsSet("pretty")
-		output, err := resultToJSON(resp.Alerts, isPretty)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO,output)
-	}
-}
-
-func generateListAlertRequest(c *gcli.Context) alert.ListAlertRequest {
-	req := alert.ListAlertRequest{}
-
-	if val, success := getVal("limit", c); success {
-		limit, err := strconv.ParseUint(val, 10, 64)
-		if err != nil {
-			os.Exit(2)
-		}
-		req.Limit = int(limit)
-	}
-	if val, success := getVal("sort", c); success {
-		req.Sort = alert.SortField(val)
-	}
-	if val, success := getVal("order", c); success {
-		req.Order = alert.Order(val)
-	}
-	if val, success := getVal("searchIdentifier", c); success {
-		req.SearchIdentifier = val
-	}
-
-	if val, success := getVal("searchIdentifierType", c); success {
-		if alert.SearchIdentifierType(val) == alert.NAME {
-			req.SearchIdentifierType = alert.NAME
-		} else {
-			req.SearchIdentifierType = alert.ID
+	printMessage(DEBUG, "List alerts request prepared from flags, sending request to Opsgenie..")
 
+	if isAllPagesRequested(c) {
+		if !c.IsSet("limit") {
+			opts.Limit = 100
 		}
+		renderAllPages(c, func() (interface{}, bool, error) {
+			resp, err := api.ListAlerts(rootContext(), opts)
+			if err != nil {
+				return nil, false, err
+			}
+			opts.Offset += len(resp.Alerts)
+			return resp.Alerts, len(resp.Alerts) == opts.Limit, nil
+		})
+		return
 	}
 
-	if val, success := getVal("offset", c); success {
-		offset, err := strconv.Atoi(val)
-		if err != nil {
-			os.Exit(2)
-		}
-		req.Offset = offset
-	}
+	resp, err := api.ListAlerts(rootContext(), opts)
+	exitOnErr(err)
 
-	if val, success := getVal("query", c); success {
-		req.Query = val
-	} else {
-		generateQueryUsingOldStyleParams(c, &req)
-	}
-	return req
+	printMessage(DEBUG, "Got Alerts successfully.")
+	renderResult(c, resp.Alerts)
 }
 
-func generateQueryUsingOldStyleParams(c *gcli.Context, req *alert.ListAlertRequest) {
-	var queries []string
-	if val, success := getVal("createdAfter", c); success {
-		createdAfter, err := strconv.ParseUint(val, 10, 64)
-		if err != nil {
-			os.Exit(2)
-		}
-		queries = append(queries, "createdAt > "+strconv.FormatUint(createdAfter, 10))
-	}
-	if val, success := getVal("createdBefore", c); success {
-		createdBefore, err := strconv.ParseUint(val, 10, 64)
-		if err != nil {
-			os.Exit(2)
-		}
-		queries = append(queries, "createdAt < "+strconv.FormatUint(createdBefore, 10))
-	}
-	if val, success := getVal("updatedAfter", c); success {
-		updatedAfter, err := strconv.ParseUint(val, 10, 64)
-		if err != nil {
-			os.Exit(2)
-		}
-		queries = append(queries, "updatedAt > "+strconv.FormatUint(updatedAfter, 10))
-	}
-	if val, success := getVal("updatedBefore", c); success {
-		updatedBefore, err := strconv.ParseUint(val, 10, 64)
-		if err != nil {
-			os.Exit(2)
-		}
-		queries = append(queries, "updatedAt < "+strconv.FormatUint(updatedBefore, 10))
-	}
-	if val, success := getVal("status", c); success {
-		queries = append(queries, "status: "+val)
-	}
-	if val, success := getVal("teams", c); success {
-		for _, teamName := range strings.Split(val, ",") {
-			queries = append(queries, "teams: "+teamName)
-
-		}
-	}
-	if val, success := getVal("tags", c); success {
-		var tags []string
-		operator := "AND"
-
-		if val, success := getVal("tagsOperator", c); success {
-			operator = val
-		}
-
-		for _, tag := range strings.Split(val, ",") {
-			tags = append(tags, tag)
-		}
-
-		tagsPart := "tag: (" + strings.Join(tags, " "+operator+" ") + ")"
-		queries = append(queries, tagsPart)
-	}
-	if len(queries) != 0 {
-		req.Query = strings.Join(queries, " AND ")
+func grabListAlertsOptions(c *gcli.Context) ListAlertsOptions {
+	opts := ListAlertsOptions{
+		Limit:         int(grabUintFlag("limit", c)),
+		Offset:        grabIntFlag("offset", c),
+		CreatedAfter:  grabUintFlag("createdAfter", c),
+		CreatedBefore: grabUintFlag("createdBefore", c),
+		UpdatedAfter:  grabUintFlag("updatedAfter", c),
+		UpdatedBefore: grabUintFlag("updatedBefore", c),
+		Teams:         grabList("teams", c),
+		Tags:          grabList("tags", c),
 	}
+	opts.Sort, _ = getVal("sort", c)
+	opts.Order, _ = getVal("order", c)
+	opts.SearchIdentifier, _ = getVal("searchIdentifier", c)
+	opts.SearchIdentifierType, _ = getVal("searchIdentifierType", c)
+	opts.Query, _ = getVal("query", c)
+	opts.Status, _ = getVal("status", c)
+	opts.TagsOperator, _ = getVal("tagsOperator", c)
+	return opts
 }
 
 // CountAlertsAction retrieves number of alerts from Opsgenie.
 func CountAlertsAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-	req := generateListAlertRequest(c)
+	opts := grabListAlertsOptions(c)
 
-	printMessage(DEBUG,"Count alerts request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Count alerts request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.List(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(INFO, string(len(resp.Alerts)))
+	count, err := cliAPI(c).CountAlerts(rootContext(), opts)
+	exitOnErr(err)
+	printResultMessage(c, strconv.Itoa(count))
+}
+
+func grabListAlertLogsOptions(c *gcli.Context) ListAlertLogsOptions {
+	opts := ListAlertLogsOptions{AlertIdentifier: grabAlertIdentifier(c), Limit: uint32(grabUintFlag("limit", c))}
+	opts.Order, _ = getVal("order", c)
+	opts.Direction, _ = getVal("direction", c)
+	opts.Offset, _ = getVal("offset", c)
+	return opts
 }
 
 // ListAlertNotesAction retrieves specified alert notes from Opsgenie.
 func ListAlertNotesAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.ListAlertNotesRequest{}
+	api := cliAPI(c)
+	opts := grabListAlertLogsOptions(c)
 
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
+	printMessage(DEBUG, "List alert notes request prepared from flags, sending request to Opsgenie..")
 
-	if val, success := getVal("limit", c); success {
-		limit, err := strconv.ParseUint(val, 10, 64)
-		if err != nil {
-			os.Exit(2)
+	if isAllPagesRequested(c) {
+		if opts.Direction == "" {
+			opts.Direction = string(alert.NEXT)
 		}
-		req.Limit = uint32(limit)
-	}
-
-	if val, success := getVal("order", c); success {
-		req.Order = alert.Order(val)
-	}
-
-	if val, success := getVal("direction", c); success {
-		req.Direction = alert.RequestDirection(val)
-	}
-
-	if val, success := getVal("offset", c); success {
-		req.Offset = val
+		renderAllPages(c, func() (interface{}, bool, error) {
+			resp, err := api.ListAlertNotes(rootContext(), opts)
+			if err != nil {
+				return nil, false, err
+			}
+			if len(resp.AlertLog) == 0 {
+				return resp.AlertLog, false, nil
+			}
+			opts.Offset = resp.AlertLog[len(resp.AlertLog)-1].Offset
+			return resp.AlertLog, resp.Paging[opts.Direction] != "", nil
+		})
+		return
 	}
 
-	printMessage(DEBUG,"List alert notes request prepared from flags, sending request to Opsgenie..")
-
-	resp, err := cli.ListAlertNotes(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	resp, err := api.ListAlertNotes(rootContext(), opts)
+	exitOnErr(err)
 
-	outputFormat := strings.ToLower(c.String("output-format"))
-	printMessage(DEBUG,"Alert notes listed successfully, and will print as " + outputFormat)
-	switch outputFormat {
-	case "yaml":
-		output, err := resultToYAML(resp.AlertLog)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	default:
-		isPretty := c.IsSet("pretty")
-		output, err := resultToJSON(resp.AlertLog, isPretty)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	}
+	printMessage(DEBUG, "Alert notes listed successfully.")
+	renderResult(c, resp.AlertLog)
 }
 
 // ListAlertLogsAction retrieves specified alert logs from Opsgenie.
 func ListAlertLogsAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-	req := alert.ListAlertLogsRequest{}
+	api := cliAPI(c)
+	opts := grabListAlertLogsOptions(c)
 
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
+	printMessage(DEBUG, "List alert logs request prepared from flags, sending request to Opsgenie..")
 
-	if val, success := getVal("limit", c); success {
-		limit, err := strconv.ParseUint(val, 10, 64)
-		if err != nil {
-			os.Exit(2)
+	if isAllPagesRequested(c) {
+		if opts.Direction == "" {
+			opts.Direction = string(alert.NEXT)
 		}
-		req.Limit = uint32(limit)
-	}
-
-	if val, success := getVal("order", c); success {
-		req.Order = alert.Order(val)
-	}
-
-	if val, success := getVal("direction", c); success {
-		req.Direction = alert.RequestDirection(val)
-	}
-
-	if val, success := getVal("offset", c); success {
-		req.Offset = val
+		renderAllPages(c, func() (interface{}, bool, error) {
+			resp, err := api.ListAlertLogs(rootContext(), opts)
+			if err != nil {
+				return nil, false, err
+			}
+			if len(resp.AlertLog) == 0 {
+				return resp.AlertLog, false, nil
+			}
+			opts.Offset = resp.AlertLog[len(resp.AlertLog)-1].Offset
+			return resp.AlertLog, resp.Paging[opts.Direction] != "", nil
+		})
+		return
 	}
 
-	printMessage(DEBUG,"List alert notes request prepared from flags, sending request to Opsgenie..")
-
-	resp, err := cli.ListAlertLogs(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	resp, err := api.ListAlertLogs(rootContext(), opts)
+	exitOnErr(err)
 
-	outputFormat := strings.ToLower(c.String("output-format"))
-	printMessage(DEBUG,"Alert notes listed successfully, and will print as " + outputFormat)
-	switch outputFormat {
-	case "yaml":
-		output, err := resultToYAML(resp.AlertLog)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	default:
-		isPretty := c.IsSet("pretty")
-		output, err := resultToJSON(resp.AlertLog, isPretty)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	}
+	printMessage(DEBUG, "Alert logs listed successfully.")
+	renderResult(c, resp.AlertLog)
 }
 
 // ListAlertRecipientsAction retrieves specified alert recipients from Opsgenie.
 func ListAlertRecipientsAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-	req := alert.ListAlertRecipientRequest{}
+	printMessage(DEBUG, "List alert recipients request prepared from flags, sending request to Opsgenie..")
 
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	printMessage(DEBUG,"List alert recipients request prepared from flags, sending request to Opsgenie..")
-
-	resp, err := cli.ListAlertRecipients(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	resp, err := cliAPI(c).ListAlertRecipients(rootContext(), grabAlertIdentifier(c))
+	exitOnErr(err)
 
-	outputFormat := strings.ToLower(c.String("output-format"))
-	printMessage(DEBUG,"Alert recipients listed successfully, and will print as " + outputFormat)
-	switch outputFormat {
-	case "yaml":
-		output, err := resultToYAML(resp.AlertRecipients)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	default:
-		isPretty := c.IsSet("pretty")
-		output, err := resultToJSON(resp.AlertRecipients, isPretty)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	}
+	printMessage(DEBUG, "Alert recipients listed successfully.")
+	renderResult(c, resp.AlertRecipients)
 }
 
 // UnAcknowledgeAction unAcknowledges an alert at Opsgenie.
 func UnAcknowledgeAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
+	printMessage(DEBUG, "UnAcknowledge alert request prepared from flags, sending request to Opsgenie..")
 
-	req := alert.UnacknowledgeAlertRequest{}
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
+	api := cliAPI(c)
+	resp, err := api.UnAcknowledge(rootContext(), grabAlertActionOptions(c))
+	exitOnErr(err)
 
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
-	}
-
-	printMessage(DEBUG,"UnAcknowledge alert request prepared from flags, sending request to Opsgenie..")
-
-	resp, err := cli.Unacknowledge(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-
-	printMessage(DEBUG,"Alert will be unAcknowledged. RequestID: " + resp.RequestId)
-	printMessage(INFO, "RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Alert will be unAcknowledged. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // SnoozeAction snoozes an alert at Opsgenie.
 func SnoozeAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.SnoozeAlertRequest{}
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
-	}
-
+	api := cliAPI(c)
+	opts := SnoozeOptions{AlertActionOptions: grabAlertActionOptions(c)}
 	if val, success := getVal("endDate", c); success {
-
 		endTime, err := time.Parse(time.RFC3339, val)
-
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-
-		req.EndTime = endTime
+		exitOnErr(err)
+		opts.EndTime = endTime
 	}
-	printMessage(DEBUG,"Snooze request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.Snooze(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
+	if isBulkRequested(c) {
+		runBulkAlertAction(c, api, "snooze", func(alertID string) (string, error) {
+			bulkOpts := opts
+			bulkOpts.AlertIdentifier = AlertIdentifier{ID: alertID}
+			return alertRequestID(api.Snooze(rootContext(), bulkOpts))
+		})
+		return
 	}
-	printMessage(DEBUG,"will be snoozed. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+
+	printMessage(DEBUG, "Snooze request prepared from flags, sending request to Opsgenie..")
+
+	resp, err := api.Snooze(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "will be snoozed. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // RemoveTagsAction removes tags from an alert at Opsgenie.
 func RemoveTagsAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.RemoveTagsRequest{}
-
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	if val, success := getVal("tags", c); success {
-		req.Tags = val
-	}
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
-	}
+	opts := RemoveTagsOptions{AlertActionOptions: grabAlertActionOptions(c), Tags: grabList("tags", c)}
 
-	printMessage(DEBUG,"Remove tags request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Remove tags request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.RemoveTags(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Tags will be removed. RequestID: " + resp.RequestId)
-	printMessage(INFO, "RequestID: " + resp.RequestId)
+	api := cliAPI(c)
+	resp, err := api.RemoveTags(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Tags will be removed. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // AddDetailsAction adds details to an alert at Opsgenie.
 func AddDetailsAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.AddDetailsRequest{}
-
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
-	}
+	opts := AddDetailsOptions{AlertActionOptions: grabAlertActionOptions(c)}
 	if c.IsSet("D") {
-		req.Details = extractDetailsFromCommand(c)
+		opts.Details = extractDetailsFromCommand(c)
 	}
-	printMessage(DEBUG,"Add details request prepared from flags, sending request to Opsgenie..")
+	printMessage(DEBUG, "Add details request prepared from flags, sending request to Opsgenie..")
 
-	resp, err := cli.AddDetails(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Details will be added. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	api := cliAPI(c)
+	resp, err := api.AddDetails(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Details will be added. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // RemoveDetailsAction removes details from an alert at Opsgenie.
 func RemoveDetailsAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := alert.RemoveDetailsRequest{}
+	opts := RemoveDetailsOptions{AlertActionOptions: grabAlertActionOptions(c), Keys: grabList("keys", c)}
 
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
+	printMessage(DEBUG, "Remove details request prepared from flags, sending request to Opsgenie..")
 
-	if val, success := getVal("keys", c); success {
-		req.Keys = val
-	}
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
-	}
-
-	printMessage(DEBUG,"Remove details request prepared from flags, sending request to Opsgenie..")
-
-	resp, err := cli.RemoveDetails(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Details will be removed. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	api := cliAPI(c)
+	resp, err := api.RemoveDetails(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Details will be removed. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 // EscalateToNextAction processes the next available rule in the specified escalation.
 func EscalateToNextAction(c *gcli.Context) {
-	cli, err := NewAlertClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
+	opts := EscalateToNextOptions{AlertActionOptions: grabAlertActionOptions(c)}
+	opts.EscalationID, _ = getVal("escalationId", c)
+	opts.EscalationName, _ = getVal("escalationName", c)
 
-	req := alert.EscalateToNextRequest{}
+	printMessage(DEBUG, "Escalate to next request prepared from flags, sending request to Opsgenie..")
 
-	if val, success := getVal("id", c); success {
-		req.IdentifierValue = val
-	}
-	req.IdentifierType = grabIdentifierType(c)
-
-	if val, success := getVal("escalationId", c); success {
-		req.Escalation.ID = val
-	}
-	if val, success := getVal("escalationName", c); success {
-		req.Escalation.Name = val
-	}
-	req.User = grabUsername(c)
-	if val, success := getVal("source", c); success {
-		req.Source = val
-	}
-	if val, success := getVal("note", c); success {
-		req.Note = val
-	}
-
-	printMessage(DEBUG,"Escalate to next request prepared from flags, sending request to Opsgenie..")
-
-	resp, err := cli.EscalateToNext(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Escalated to next request will be processed. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
-}
-
-func grabIdentifierType(c *gcli.Context) alert.AlertIdentifier {
-	if val, success := getVal("identifier", c); success {
-		if val == "tiny" {
-			return alert.TINYID
-		} else if val == "alias" {
-			return alert.ALIAS
-		}
-	}
-	return alert.ALERTID
+	api := cliAPI(c)
+	resp, err := api.EscalateToNext(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Escalated to next request will be processed. RequestID: "+resp.RequestId)
+	printAlertRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
@@ -0,0 +1,262 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opsgenie/opsgenie-lamp/cfg"
+	gcli "github.com/urfave/cli"
+)
+
+const (
+	aliasCategory = "Aliases"
+	maxAliasDepth = 10
+)
+
+// aliasPlaceholder matches $1 to $9, $@ and ${env:NAME} or ${env:NAME:-default} in the words of an alias.
+var aliasPlaceholder = regexp.MustCompile(`\$(?:([1-9])|(@)|\{env:([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\})`)
+
+// aliasDepth counts the aliases being run, to stop aliases expanding to themselves.
+var aliasDepth int
+
+/*
+AliasCommands returns a command for each alias of the [alias] section of the configuration file. An alias
+expands to a command line with preset flags, or to a macro of command lines separated by && which run in
+sequence. Commands of lamp take precedence over aliases, which are not read when lamp runs one of its commands.
+*/
+func AliasCommands(builtins []gcli.Command) []gcli.Command {
+	if runsBuiltinCommand(builtins) {
+		return nil
+	}
+	loadStartupConfig()
+	taken := map[string]bool{}
+	for _, cmd := range builtins {
+		for _, name := range cmd.Names() {
+			taken[name] = true
+		}
+	}
+	aliases := cfg.Aliases()
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		if !taken[name] && !strings.ContainsAny(name, " \t") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var commands []gcli.Command
+	for _, name := range names {
+		expansion := aliases[name]
+		commands = append(commands, gcli.Command{Name: name,
+			Category:        aliasCategory,
+			SkipFlagParsing: true,
+			Usage:           "Alias of " + expansion,
+			Action: func(c *gcli.Context) error {
+				runAlias(c, expansion)
+				return nil
+			},
+		})
+	}
+	return commands
+}
+
+/*
+runAlias runs the command lines of an alias with the placeholders replaced by the arguments and environment
+variables. The --config, --profile, --apiKey and --dry-run flags given to the alias are given to every command.
+*/
+func runAlias(c *gcli.Context, expansion string) {
+	name := c.Command.Name
+	if aliasDepth >= maxAliasDepth {
+		exitOnErr(newError(ExitCodeConfiguration, "Alias "+name+" is expanded more than "+strconv.Itoa(maxAliasDepth)+" times, it probably expands to itself"))
+	}
+	aliasDepth++
+	defer func() { aliasDepth-- }()
+
+	words, err := splitShellLine(expansion)
+	if err != nil || len(words) == 0 {
+		exitOnErr(newError(ExitCodeConfiguration, "Alias "+name+" is not a valid command line: "+expansion))
+	}
+	values, args := splitPluginFlags(c.Args())
+	dryRun := false
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--dry-run" || args[i] == "-dry-run" {
+			dryRun = true
+			args = append(args[:i], args[i+1:]...)
+			i--
+		}
+	}
+	steps, err := expandAlias(name, words, args)
+	exitOnErr(err)
+
+	if len(steps) == 1 {
+		stepArgs := wordTexts(steps[0])
+		stepArgs = append(stepArgs, aliasFlagArgs(c, stepArgs[0], values, dryRun)...)
+		printMessage(DEBUG, "Running alias "+name+": "+strings.Join(stepArgs, " "))
+		if err := c.App.Run(append([]string{c.App.Name}, stepArgs...)); err != nil {
+			exitOnUsageErr(err.Error())
+		}
+		return
+	}
+	runMacro(c, steps, values, dryRun)
+}
+
+// expandAlias replaces the $1 to $9, $@ and ${env:NAME} placeholders of the words and splits them into the steps separated by &&.
+// The arguments no placeholder uses are appended to the first step, unless $@ is used.
+func expandAlias(name string, words []shellWord, args []string) ([][]shellWord, error) {
+	var steps [][]shellWord
+	var step []shellWord
+	used := map[int]bool{}
+	spread := false
+	for _, word := range words {
+		if word.text == "&&" && !word.quoted {
+			if len(step) == 0 {
+				return nil, newError(ExitCodeConfiguration, "Alias "+name+" has an empty step")
+			}
+			steps, step = append(steps, step), nil
+			continue
+		}
+		if !word.expand {
+			step = append(step, word)
+			continue
+		}
+		if word.text == "$@" {
+			for _, arg := range args {
+				step = append(step, shellWord{text: arg})
+			}
+			spread = true
+			continue
+		}
+		var err error
+		text := aliasPlaceholder.ReplaceAllStringFunc(word.text, func(placeholder string) string {
+			match := aliasPlaceholder.FindStringSubmatch(placeholder)
+			switch {
+			case match[1] != "":
+				index, _ := strconv.Atoi(match[1])
+				if index > len(args) {
+					err = newError(ExitCodeUsage, fmt.Sprintf("Alias %s needs the argument $%d", name, index))
+					return ""
+				}
+				used[index] = true
+				return args[index-1]
+			case match[2] != "":
+				spread = true
+				return strings.Join(args, " ")
+			}
+			if value := os.Getenv(match[3]); value != "" {
+				return value
+			}
+			if match[4] != "" {
+				return strings.TrimPrefix(match[4], ":-")
+			}
+			err = newError(ExitCodeUsage, "Alias "+name+" needs the environment variable "+match[3])
+			return ""
+		})
+		if err != nil {
+			return nil, err
+		}
+		step = append(step, shellWord{text: text, expand: true})
+	}
+	if len(step) == 0 {
+		return nil, newError(ExitCodeConfiguration, "Alias "+name+" has an empty step")
+	}
+	steps = append(steps, step)
+	if !spread {
+		for i, arg := range args {
+			if !used[i+1] {
+				steps[0] = append(steps[0], shellWord{text: arg})
+			}
+		}
+	}
+	return steps, nil
+}
+
+/*
+runMacro runs the steps of a macro in sequence with a configuration and clients shared by the steps, like the
+shell does, and stops at the first failing step. $last.<field> is replaced with a field of the result of the
+previous step, e.g. $last.alertId of createAlert --wait. Dry runs keep the variables which are not set.
+*/
+func runMacro(c *gcli.Context, steps [][]shellWord, values map[string]string, dryRun bool) {
+	set := flag.NewFlagSet(c.Command.Name, flag.ContinueOnError)
+	for _, name := range pluginFlags {
+		set.String(name, "", "")
+		if value, ok := values[name]; ok {
+			set.Set(name, value)
+		}
+	}
+	set.Bool("dry-run", dryRun, "")
+	if dryRun {
+		set.Set("dry-run", "true")
+	}
+	ctx := gcli.NewContext(c.App, set, nil)
+	ctx.Command = c.Command
+
+	previousConfig, previousBatchMode, previousShellMode := batchConfig, batchMode, shellMode
+	restore := func() {
+		batchConfig, batchMode, shellMode = previousConfig, previousBatchMode, previousShellMode
+		if previousConfig == nil {
+			batchClientsMu.Lock()
+			batchClients = map[string]interface{}{}
+			batchClientsMu.Unlock()
+		}
+	}
+	batchConfig = getConfigurations(ctx)
+	batchMode, shellMode = true, true
+	defer restore()
+
+	session := &shellSession{c: c}
+	for i, step := range steps {
+		args, err := session.expand(step)
+		if err != nil && dryRun {
+			args, err = wordTexts(step), nil
+		}
+		if err != nil {
+			restore()
+			exitOnErr(err)
+		}
+		args = append(args, aliasFlagArgs(c, args[0], values, dryRun)...)
+		printMessage(DEBUG, fmt.Sprintf("Running step %d of %s: %s", i+1, c.Command.Name, strings.Join(args, " ")))
+
+		shellRendered = nil
+		output, err := runCapturedCommand(c, args[0], args[1:])
+		c.App.Writer.Write(output)
+		if err != nil {
+			restore()
+			exitOnErr(err)
+		}
+		session.last = shellRendered
+		if session.last == nil {
+			session.last = shellResult(output)
+		}
+	}
+}
+
+// aliasFlagArgs are the --config, --profile, --apiKey and --dry-run flags given to the alias, for a command having them.
+func aliasFlagArgs(c *gcli.Context, name string, values map[string]string, dryRun bool) []string {
+	cmd := c.App.Command(name)
+	if cmd == nil {
+		return nil
+	}
+	var args []string
+	for _, flagName := range pluginFlags {
+		if value, ok := values[flagName]; ok && (cmd.SkipFlagParsing || commandFlag(cmd, flagName) != nil) {
+			args = append(args, "--"+flagName, value)
+		}
+	}
+	if dryRun && (cmd.SkipFlagParsing || commandFlag(cmd, "dry-run") != nil) {
+		args = append(args, "--dry-run")
+	}
+	return args
+}
+
+func wordTexts(words []shellWord) []string {
+	texts := make([]string, len(words))
+	for i, word := range words {
+		texts[i] = word.text
+	}
+	return texts
+}
@@ -0,0 +1,135 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	gcli "github.com/urfave/cli"
+)
+
+func TestExpandAlias(t *testing.T) {
+	os.Setenv("LAMP_TEST_TEAM", "sre")
+	defer os.Unsetenv("LAMP_TEST_TEAM")
+
+	tests := []struct {
+		expansion string
+		args      []string
+		want      string
+		wantCode  int
+	}{
+		{expansion: "listAlerts --query status:open", args: []string{"--limit", "5"}, want: "listAlerts --query status:open --limit 5"},
+		{expansion: "ack --id $1 --note \"by $2\"", args: []string{"a1", "jane", "-v"}, want: "ack --id a1 --note by jane -v"},
+		{expansion: "createAlert --teams ${env:LAMP_TEST_TEAM} --priority ${env:LAMP_TEST_PRIORITY:-P3}", want: "createAlert --teams sre --priority P3"},
+		{expansion: "createAlert --message '$1' $@", args: []string{"--note", "x"}, want: "createAlert --message $1 --note x"},
+		{expansion: "createAlert --message \"$@\"", args: []string{"disk", "full"}, want: "createAlert --message disk full"},
+		{expansion: "createAlert --message $1 && ack --id $last.id", args: []string{"disk", "-v"}, want: "createAlert --message disk -v | ack --id $last.id"},
+		{expansion: "createAlert \"&&\" ack", want: "createAlert && ack"},
+		{expansion: "ack --id $2", args: []string{"a1"}, wantCode: ExitCodeUsage},
+		{expansion: "createAlert --teams ${env:LAMP_TEST_MISSING}", wantCode: ExitCodeUsage},
+		{expansion: "createAlert && && ack", wantCode: ExitCodeConfiguration},
+		{expansion: "createAlert &&", wantCode: ExitCodeConfiguration},
+	}
+	for _, test := range tests {
+		words, err := splitShellLine(test.expansion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		steps, err := expandAlias("test", words, test.args)
+		if test.wantCode != 0 {
+			if exitCode(err) != test.wantCode {
+				t.Errorf("%q returned %v, want the exit code %d", test.expansion, err, test.wantCode)
+			}
+			continue
+		}
+		var lines []string
+		for _, step := range steps {
+			lines = append(lines, strings.Join(wordTexts(step), " "))
+		}
+		if got := strings.Join(lines, " | "); err != nil || got != test.want {
+			t.Errorf("%q with %q expanded to %q, %v, want %q", test.expansion, test.args, got, err, test.want)
+		}
+	}
+}
+
+func TestAliasCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer unloadConfigFile(dir)
+	config := filepath.Join(dir, "lamp.conf")
+	ioutil.WriteFile(config, []byte(`apiKey=key
+
+[alias]
+# aliases of lamp commands are ignored
+getAlert = listAlerts
+disk = createAlert --message "Disk of $1 is full"
+escalate = createAlert --message $1 && \
+    getAlert --id $last.id
+again = disk
+loop = loop
+`), 0600)
+
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"lamp", "--config", config}
+	startupConfigOnce = sync.Once{}
+	defer func() { startupConfigOnce = sync.Once{} }()
+
+	var output bytes.Buffer
+	app := gcli.NewApp()
+	app.Writer, app.ErrWriter = &output, ioutil.Discard
+	app.Commands = []gcli.Command{
+		{
+			Name:  "createAlert",
+			Flags: []gcli.Flag{gcli.StringFlag{Name: "config"}, gcli.StringFlag{Name: "message"}, gcli.BoolFlag{Name: "dry-run"}},
+			Action: func(c *gcli.Context) {
+				fmt.Fprintf(c.App.Writer, "{\"id\": \"a1\", \"message\": %q, \"dryRun\": %v}\n", c.String("message"), c.Bool("dry-run"))
+			},
+		},
+		{
+			Name:  "getAlert",
+			Flags: []gcli.Flag{gcli.StringFlag{Name: "id"}},
+			Action: func(c *gcli.Context) {
+				fmt.Fprintln(c.App.Writer, "got "+c.String("id"))
+			},
+		},
+	}
+	aliases := AliasCommands(app.Commands)
+	var names []string
+	for _, alias := range aliases {
+		names = append(names, alias.Name)
+	}
+	if strings.Join(names, " ") != "again disk escalate loop" {
+		t.Fatalf("aliases are %q", names)
+	}
+	app.Commands = append(app.Commands, aliases...)
+
+	batchMode = true
+	defer func() { batchMode = false }()
+	run := func(args ...string) (string, error) {
+		output.Reset()
+		err := catchExit(func() { app.Run(append([]string{"lamp"}, args...)) })
+		return output.String(), err
+	}
+
+	if got, err := run("again", "db1", "--config", config, "--dry-run"); err != nil || got != "{\"id\": \"a1\", \"message\": \"Disk of db1 is full\", \"dryRun\": true}\n" {
+		t.Errorf("alias of an alias printed %q, %v", got, err)
+	}
+	if got, err := run("escalate", "disk"); err != nil || got != "{\"id\": \"a1\", \"message\": \"disk\", \"dryRun\": false}\ngot a1\n" {
+		t.Errorf("macro printed %q, %v", got, err)
+	}
+	if _, err := run("disk"); exitCode(err) != ExitCodeUsage {
+		t.Errorf("alias without its argument exited with %v, want a usage error", err)
+	}
+	if _, err := run("loop"); exitCode(err) != ExitCodeConfiguration || aliasDepth != 0 {
+		t.Errorf("alias expanding to itself exited with %v at depth %d, want a configuration error", err, aliasDepth)
+	}
+}
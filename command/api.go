@@ -0,0 +1,323 @@
+package command
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/escalation"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/heartbeat"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/incident"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/integration"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/logs"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/policy"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/schedule"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/service"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/team"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/user"
+	"github.com/sirupsen/logrus"
+	gcli "github.com/urfave/cli"
+)
+
+/*
+API runs the actions of the lamp commands from Go programs. Its methods take the options of the flags of the
+command as a struct and return the result and the error of the command instead of printing them and exiting,
+the errors carry the exit code of lamp, see ExitCode. An API is safe for concurrent use.
+
+The context of a call cancels its requests and stops their retries, waits, pages and downloads, and
+Config.RequestTimeout bounds each request.
+*/
+type API struct {
+	config *client.Config
+	// command is the lamp command making the calls, which the journal records with their requests
+	command string
+	clients *sdkClients
+	// logger logs the progress of the calls, e.g. the polls of a wait
+	logger *logrus.Logger
+}
+
+/*
+sdkClients keeps the idle SDK clients of each API family. The SDK does not pass the context given to its calls to
+the requests, so a client is bound to the context of the one call using it and returns to its family when the call
+ends. A family has as many clients as the calls running at the same time, one for commands calling the API in turn.
+*/
+type sdkClients struct {
+	mu      sync.Mutex
+	idle    map[string][]*sdkClient
+	created int
+}
+
+// sdkClient is an SDK client with the context of the call using it.
+type sdkClient struct {
+	client interface{}
+	ctx    context.Context
+}
+
+/*
+sdkClientMu orders the creation of the SDK clients with their calls: creating a client sets the user agent of the SDK
+package, which every request reads. The calls hold its read lock while they use a client.
+*/
+var sdkClientMu sync.RWMutex
+
+func newSDKClients() *sdkClients {
+	return &sdkClients{idle: map[string][]*sdkClient{}}
+}
+
+// Config configures an API like the configuration file configures lamp.
+type Config struct {
+	APIKey string
+	// APIURL is api.opsgenie.com by default, e.g. api.eu.opsgenie.com or http://localhost:8080 for the mock server.
+	APIURL string
+	// RetryCount is the number of retries of requests failing with 429 and 5xx responses, 4 if it is 0.
+	RetryCount     int
+	RequestTimeout time.Duration
+	HTTPClient     *http.Client
+	// Logger is the logger of the SDK and of the progress of the calls, e.g. the polls of a wait. It logs warnings and
+	// errors to the standard error if it is nil.
+	Logger *logrus.Logger
+}
+
+// NewAPI creates an API with the given configuration, the configuration file of lamp is not read.
+func NewAPI(config Config) (*API, error) {
+	if config.APIKey == "" {
+		return nil, newError(ExitCodeConfiguration, "APIKey should be given")
+	}
+	apiURL := config.APIURL
+	if apiURL == "" {
+		apiURL = string(client.API_URL)
+	}
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.WarnLevel)
+	}
+	return newAPI(&client.Config{
+		ApiKey:         config.APIKey,
+		OpsGenieAPIURL: client.ApiUrl(strings.TrimPrefix(strings.TrimPrefix(apiURL, "https://"), "http://")),
+		HttpClient:     httpClient,
+		RequestTimeout: config.RequestTimeout,
+		RetryCount:     config.RetryCount,
+		Logger:         logger,
+	}), nil
+}
+
+func newAPI(config *client.Config) *API {
+	return &API{config: config, clients: newSDKClients(), logger: config.Logger}
+}
+
+// cliAPI returns the API of a command configured by its flags and the configuration file, the lines of a batch or
+// the shell share its SDK clients.
+func cliAPI(c *gcli.Context) *API {
+	clients, _ := sharedClient("sdkClients", func() (interface{}, error) {
+		return newSDKClients(), nil
+	})
+	logger, _ := sharedClient("cliLogger", func() (interface{}, error) {
+		return newCLILogger(), nil
+	})
+	return &API{config: cliConfig(c), command: c.Command.Name, clients: clients.(*sdkClients), logger: logger.(*logrus.Logger)}
+}
+
+// cliConfig returns the configuration of the running command, it is built once as building it configures logging,
+// the journal and the rate limits. The lines of a batch or the shell share it.
+func cliConfig(c *gcli.Context) *client.Config {
+	config, _ := sharedClient("config", func() (interface{}, error) {
+		return getConfigurations(c), nil
+	})
+	return config.(*client.Config)
+}
+
+// ExitCode returns the exit code of lamp for an error returned by the API, e.g. ExitCodeNotFound.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+	return exitCode(err)
+}
+
+/*
+client takes an idle SDK client of an API family, or creates one, and binds it to the context of a call. The call
+gives the client back with the returned release function once it does not use it anymore.
+*/
+func (a *API) client(ctx context.Context, name string, create func(config *client.Config) (interface{}, error)) (interface{}, func(), error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if a.command != "" {
+		ctx = withJournalCommand(ctx, a.command)
+	}
+	clients := a.clients
+	clients.mu.Lock()
+	var cli *sdkClient
+	if idle := clients.idle[name]; len(idle) != 0 {
+		cli = idle[len(idle)-1]
+		clients.idle[name] = idle[:len(idle)-1]
+	}
+	clients.mu.Unlock()
+
+	if cli == nil {
+		cli = &sdkClient{}
+		config := *a.config
+		httpClient := *a.config.HttpClient
+		config.HttpClient = &httpClient
+		bindContext(&config, func() context.Context { return cli.ctx })
+		// the SDK logs its configuration whenever a client is created
+		config.Logger = quietLogger
+		sdkClientMu.Lock()
+		created, err := create(&config)
+		sdkClientMu.Unlock()
+		config.Logger = a.config.Logger
+		if err != nil {
+			return nil, nil, newError(ExitCodeConfiguration, "Can not create the "+name+" client. "+err.Error())
+		}
+		cli.client = created
+		clients.mu.Lock()
+		clients.created++
+		clients.mu.Unlock()
+	}
+	sdkClientMu.RLock()
+	cli.ctx = ctx
+	release := func() {
+		sdkClientMu.RUnlock()
+		clients.mu.Lock()
+		clients.idle[name] = append(clients.idle[name], cli)
+		clients.mu.Unlock()
+	}
+	return cli.client, release, nil
+}
+
+func (a *API) alertClient(ctx context.Context) (*alert.Client, func(), error) {
+	cli, release, err := a.client(ctx, "alert", func(config *client.Config) (interface{}, error) { return alert.NewClient(config) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli.(*alert.Client), release, nil
+}
+
+func (a *API) incidentClient(ctx context.Context) (*incident.Client, func(), error) {
+	cli, release, err := a.client(ctx, "incident", func(config *client.Config) (interface{}, error) { return incident.NewClient(config) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli.(*incident.Client), release, nil
+}
+
+func (a *API) teamClient(ctx context.Context) (*team.Client, func(), error) {
+	cli, release, err := a.client(ctx, "team", func(config *client.Config) (interface{}, error) { return team.NewClient(config) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli.(*team.Client), release, nil
+}
+
+func (a *API) scheduleClient(ctx context.Context) (*schedule.Client, func(), error) {
+	cli, release, err := a.client(ctx, "schedule", func(config *client.Config) (interface{}, error) { return schedule.NewClient(config) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli.(*schedule.Client), release, nil
+}
+
+func (a *API) escalationClient(ctx context.Context) (*escalation.Client, func(), error) {
+	cli, release, err := a.client(ctx, "escalation", func(config *client.Config) (interface{}, error) { return escalation.NewClient(config) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli.(*escalation.Client), release, nil
+}
+
+func (a *API) heartbeatClient(ctx context.Context) (*heartbeat.Client, func(), error) {
+	cli, release, err := a.client(ctx, "heartbeat", func(config *client.Config) (interface{}, error) { return heartbeat.NewClient(config) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli.(*heartbeat.Client), release, nil
+}
+
+func (a *API) serviceClient(ctx context.Context) (*service.Client, func(), error) {
+	cli, release, err := a.client(ctx, "service", func(config *client.Config) (interface{}, error) { return service.NewClient(config) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli.(*service.Client), release, nil
+}
+
+func (a *API) integrationClient(ctx context.Context) (*integration.Client, func(), error) {
+	cli, release, err := a.client(ctx, "integration", func(config *client.Config) (interface{}, error) { return integration.NewClient(config) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli.(*integration.Client), release, nil
+}
+
+func (a *API) policyClient(ctx context.Context) (*policy.Client, func(), error) {
+	cli, release, err := a.client(ctx, "policy", func(config *client.Config) (interface{}, error) { return policy.NewClient(config) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli.(*policy.Client), release, nil
+}
+
+func (a *API) userClient(ctx context.Context) (*user.Client, func(), error) {
+	cli, release, err := a.client(ctx, "user", func(config *client.Config) (interface{}, error) { return user.NewClient(config) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli.(*user.Client), release, nil
+}
+
+func (a *API) logsClient(ctx context.Context) (*logs.Client, func(), error) {
+	cli, release, err := a.client(ctx, "logs", func(config *client.Config) (interface{}, error) { return logs.NewClient(config) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli.(*logs.Client), release, nil
+}
+
+// WaitForAlertRequest waits until Opsgenie processes an alert request, at most timeout which is a minute if it is 0, and returns its outcome.
+func (a *API) WaitForAlertRequest(ctx context.Context, requestID string, timeout time.Duration) (*RequestOutcome, error) {
+	return pollRequestStatus(ctx, a.logger, timeout, requestID, alertStatusFetcher(a, requestID))
+}
+
+// WaitForIncidentRequest waits until Opsgenie processes an incident request, at most timeout which is a minute if it is 0, and returns its outcome.
+func (a *API) WaitForIncidentRequest(ctx context.Context, requestID string, timeout time.Duration) (*RequestOutcome, error) {
+	return pollRequestStatus(ctx, a.logger, timeout, requestID, incidentStatusFetcher(a, requestID))
+}
+
+type GetRequestStatusOptions struct {
+	RequestID string
+	// Type is alert by default, or incident.
+	Type string
+	// Wait waits until the request is processed, at most WaitTimeout which is a minute if it is 0.
+	Wait        bool
+	WaitTimeout time.Duration
+}
+
+// GetRequestStatus gets the outcome of an asynchronous alert or incident request, it fails with ExitCodeNotFound if the request is not processed yet.
+func (a *API) GetRequestStatus(ctx context.Context, opts GetRequestStatusOptions) (*RequestOutcome, error) {
+	var fetchStatus statusFetcher
+	switch opts.Type {
+	case "", "alert":
+		fetchStatus = alertStatusFetcher(a, opts.RequestID)
+	case "incident":
+		fetchStatus = incidentStatusFetcher(a, opts.RequestID)
+	default:
+		return nil, newError(ExitCodeUsage, "Invalid type "+opts.Type+", specify alert or incident")
+	}
+
+	if opts.Wait {
+		return pollRequestStatus(ctx, a.logger, opts.WaitTimeout, opts.RequestID, fetchStatus)
+	}
+	outcome, err := fetchStatus(ctx)
+	if isRequestNotProcessed(err) {
+		return nil, newError(ExitCodeNotFound, "Request "+opts.RequestID+" is not processed yet, give the wait flag to wait for it")
+	}
+	return outcome, err
+}
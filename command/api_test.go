@@ -0,0 +1,145 @@
+package command
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opsgenie/opsgenie-lamp/mockserver"
+	"github.com/sirupsen/logrus"
+)
+
+func TestNewAPI(t *testing.T) {
+	if _, err := NewAPI(Config{APIURL: "api.eu.opsgenie.com"}); ExitCode(err) != ExitCodeConfiguration {
+		t.Errorf("NewAPI without an API key returned %v, want a configuration error", err)
+	}
+
+	urls := map[string]string{
+		"":                         "api.opsgenie.com",
+		"api.eu.opsgenie.com":      "api.eu.opsgenie.com",
+		"https://api.opsgenie.com": "api.opsgenie.com",
+		"http://localhost:8080":    "localhost:8080",
+	}
+	for apiURL, want := range urls {
+		api, err := NewAPI(Config{APIKey: "key", APIURL: apiURL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(api.config.OpsGenieAPIURL); got != want || api.config.HttpClient == nil || api.config.Logger.Level != logrus.WarnLevel {
+			t.Errorf("NewAPI with the url %q configured %s, %v, %v", apiURL, got, api.config.HttpClient, api.config.Logger.Level)
+		}
+	}
+}
+
+func TestAPIRoundTrip(t *testing.T) {
+	server := httptest.NewServer(mockserver.New(mockserver.Options{APIKey: "key", ProcessingDelay: 100 * time.Millisecond}))
+	defer server.Close()
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	api, err := NewAPI(Config{APIKey: "key", APIURL: server.URL, Logger: logger, RetryCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	created, err := api.CreateAlert(ctx, CreateAlertOptions{Message: "Disk is full", Alias: "disk-full", Tags: []string{"disk"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetRequestStatus(ctx, GetRequestStatusOptions{RequestID: created.RequestId}); ExitCode(err) != ExitCodeNotFound {
+		t.Errorf("status of the new request is %v, want it not processed", err)
+	}
+	outcome, err := api.WaitForAlertRequest(ctx, created.RequestId, time.Second)
+	if err != nil || !outcome.Success || outcome.AlertID == "" {
+		t.Fatalf("waiting for the request returned %+v, %v", outcome, err)
+	}
+
+	// the methods are safe for concurrent use
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := api.GetAlert(ctx, AlertIdentifier{ID: "disk-full", IdentifierType: "alias"})
+			if err == nil && got.Id != outcome.AlertID {
+				t.Errorf("got the alert %s, want %s", got.Id, outcome.AlertID)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// the context of a call cancels its requests
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := api.ListAlerts(canceled, ListAlertsOptions{}); ExitCode(err) != ExitCodeInterrupted {
+		t.Errorf("listing with a canceled context returned %v, want it interrupted", err)
+	}
+
+	// the errors carry the exit code of lamp
+	if _, err := api.GetAlert(ctx, AlertIdentifier{ID: "missing", IdentifierType: "alias"}); ExitCode(err) != ExitCodeNotFound {
+		t.Errorf("getting a missing alert returned %v, want not found", err)
+	}
+	if _, err := api.GetRequestStatus(ctx, GetRequestStatusOptions{RequestID: created.RequestId, Type: "team"}); ExitCode(err) != ExitCodeUsage {
+		t.Errorf("status of an invalid type returned %v, want a usage error", err)
+	}
+	wrongKey, _ := NewAPI(Config{APIKey: "wrong", APIURL: server.URL, Logger: logger})
+	if _, err := wrongKey.ListAlerts(ctx, ListAlertsOptions{}); ExitCode(err) != ExitCodeUnauthorized {
+		t.Errorf("listing with a wrong key returned %v, want unauthorized", err)
+	}
+}
+
+func TestAPIReusesClients(t *testing.T) {
+	server := httptest.NewServer(mockserver.New(mockserver.Options{}))
+	defer server.Close()
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	api, err := NewAPI(Config{APIKey: "key", APIURL: server.URL, Logger: logger, RetryCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := api.ListAlerts(context.Background(), ListAlertsOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if api.clients.created != 1 || len(api.clients.idle["alert"]) != 1 {
+		t.Fatalf("calls in turn created %d clients, want 1", api.clients.created)
+	}
+
+	// a client bound to a canceled call serves the next call with its context
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := api.ListAlerts(canceled, ListAlertsOptions{}); ExitCode(err) != ExitCodeInterrupted {
+		t.Errorf("listing with a canceled context returned %v, want it interrupted", err)
+	}
+	if _, err := api.ListAlerts(context.Background(), ListAlertsOptions{}); err != nil {
+		t.Errorf("listing after a canceled call returned %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := api.ListAlerts(context.Background(), ListAlertsOptions{}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if created := api.clients.created; created > 6 || created != len(api.clients.idle["alert"]) {
+		t.Errorf("concurrent calls created %d clients and gave %d back, want at most 6", created, len(api.clients.idle["alert"]))
+	}
+}
@@ -0,0 +1,315 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	gcli "github.com/urfave/cli"
+)
+
+const defaultBatchWorkers = 4
+
+var (
+	// batchMode is set while a batch runs, errors of commands then end the line instead of the process.
+	batchMode   bool
+	batchConfig *client.Config
+
+	batchClientsMu sync.Mutex
+	batchClients   = map[string]interface{}{}
+)
+
+// batchLine is a line of a batch file, naming a lamp command with its flags and arguments.
+type batchLine struct {
+	Command string                 `json:"command"`
+	Flags   map[string]interface{} `json:"flags"`
+	Args    []string               `json:"args"`
+}
+
+// batchResult is printed as a JSON line for each line of a batch, in the order of the lines.
+type batchResult struct {
+	Line     int          `json:"line"`
+	Command  string       `json:"command,omitempty"`
+	ExitCode int          `json:"exitCode"`
+	Skipped  bool         `json:"skipped,omitempty"`
+	Output   interface{}  `json:"output,omitempty"`
+	Error    *errorOutput `json:"error,omitempty"`
+}
+
+// batchExit is panicked by exitOnErr in batch mode and recovered by the worker running the line.
+type batchExit struct {
+	err error
+}
+
+type batchTask struct {
+	index  int
+	number int
+	line   batchLine
+	err    error
+}
+
+// sharedClient returns the client created by create, which is created once for the command and shared by all lines in batch mode.
+func sharedClient(name string, create func() (interface{}, error)) (interface{}, error) {
+	batchClientsMu.Lock()
+	defer batchClientsMu.Unlock()
+	if cli, ok := batchClients[name]; ok {
+		return cli, nil
+	}
+	cli, err := create()
+	if err == nil {
+		batchClients[name] = cli
+	}
+	return cli, err
+}
+
+// BatchAction runs the commands listed in a file or the standard input, one JSON object per line, with a pool of workers.
+func BatchAction(c *gcli.Context) {
+	input := io.Reader(os.Stdin)
+	if val, success := getVal("file", c); success && val != "-" {
+		file, err := os.Open(val)
+		if err != nil {
+			exitOnErr(newError(ExitCodeUsage, "Could not open the batch file: "+err.Error()))
+		}
+		defer file.Close()
+		input = file
+	}
+	tasks, err := readBatchTasks(input)
+	exitOnErr(err)
+
+	workers := defaultBatchWorkers
+	if c.IsSet("workers") {
+		workers = c.Int("workers")
+		if workers <= 0 {
+			exitOnUsageErr("workers should be a positive number")
+		}
+	}
+
+	batchConfig = getConfigurations(c)
+	batchMode = true
+	defer func() {
+		batchMode = false
+		batchConfig = nil
+		batchClients = map[string]interface{}{}
+	}()
+
+	results := runBatch(c, tasks, workers, c.Bool("stop-on-error"))
+
+	failed, skipped, code := 0, 0, ExitCodeOK
+	for _, result := range results {
+		if result.Skipped {
+			skipped++
+		} else if result.ExitCode != ExitCodeOK {
+			failed++
+			if code == ExitCodeOK {
+				code = result.ExitCode
+			}
+		}
+	}
+	printSummary(fmt.Sprintf("Batch completed: %d lines, %d succeeded, %d failed, %d skipped",
+		len(results), len(results)-failed-skipped, failed, skipped))
+	if stop := interruption(); stop != nil {
+		batchMode = false
+		exitOnErr(stop)
+	}
+	if code != ExitCodeOK {
+		os.Exit(code)
+	}
+}
+
+// readBatchTasks reads the lines of a batch, empty lines and lines starting with # are ignored.
+func readBatchTasks(input io.Reader) ([]batchTask, error) {
+	var tasks []batchTask
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		task := batchTask{index: len(tasks), number: number}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&task.line); err != nil {
+			task.err = newError(ExitCodeUsage, "Invalid batch line: "+err.Error())
+		} else if task.line.Command == "" {
+			task.err = newError(ExitCodeUsage, "Invalid batch line: command is not given")
+		}
+		tasks = append(tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, newError(ExitCodeUsage, "Could not read the batch: "+err.Error())
+	}
+	return tasks, nil
+}
+
+// runBatch runs the tasks with the given number of workers and prints their results in the order of the lines.
+func runBatch(c *gcli.Context, tasks []batchTask, workers int, stopOnError bool) []batchResult {
+	results := make([]batchResult, len(tasks))
+	done := make([]bool, len(tasks))
+	var mu sync.Mutex
+	next, printed, stopped := 0, 0, false
+
+	// printReady prints the results of the lines completed without a gap after the last printed line.
+	printReady := func() {
+		for printed < len(tasks) && done[printed] {
+			b, _ := json.Marshal(results[printed])
+			fmt.Fprintln(c.App.Writer, string(b))
+			printed++
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if next == len(tasks) {
+					mu.Unlock()
+					return
+				}
+				task := tasks[next]
+				next++
+				skip := stopped || rootContext().Err() != nil
+				mu.Unlock()
+
+				result := batchResult{Line: task.number, Command: task.line.Command, Skipped: skip}
+				if !skip {
+					result = runBatchTask(c, task)
+				}
+
+				mu.Lock()
+				if result.ExitCode != ExitCodeOK && stopOnError {
+					stopped = true
+				}
+				results[task.index] = result
+				done[task.index] = true
+				printReady()
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runBatchTask runs the command of a line, capturing its output.
+func runBatchTask(c *gcli.Context, task batchTask) batchResult {
+	result := batchResult{Line: task.number, Command: task.line.Command}
+	if task.err != nil {
+		result.ExitCode, result.Error = exitCode(task.err), newErrorOutput(task.err)
+		return result
+	}
+	output, err := runCapturedCommand(c, task.line.Command, batchArgs(task.line))
+	result.Output = batchOutput(output)
+	if err != nil {
+		result.ExitCode, result.Error = exitCode(err), newErrorOutput(err)
+	}
+	return result
+}
+
+/*
+runCapturedCommand runs a command with the action of the command in batch mode, capturing its
+output. The error ending the command is returned instead of exiting, the error ending a dry run
+is not a failure. Commands running other commands or servers are refused.
+*/
+func runCapturedCommand(c *gcli.Context, name string, args []string) ([]byte, error) {
+	found := c.App.Command(name)
+	if found == nil {
+		return nil, newError(ExitCodeUsage, "Command "+name+" is not found")
+	}
+	if found.Name == "batch" || found.Name == "shell" || found.Name == "mockServer" {
+		return nil, newError(ExitCodeUsage, "Command "+name+" can not be run in a "+c.Command.Name)
+	}
+	cmd := *found
+	cmd.Flags = append([]gcli.Flag{}, found.Flags...)
+
+	var output bytes.Buffer
+	app := *c.App
+	app.Writer = &output
+	app.ErrWriter = &output
+	args = append([]string{cmd.Name}, args...)
+	set := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	set.Parse(args)
+
+	err := catchExit(func() {
+		if err := cmd.Run(gcli.NewContext(&app, set, nil)); err != nil {
+			exitOnErr(newError(ExitCodeUsage, err.Error()))
+		}
+	})
+	if isDryRunErr(err) {
+		err = nil
+	}
+	return output.Bytes(), err
+}
+
+// catchExit runs run in batch mode and returns the error of exitOnErr, if run ends with an error.
+func catchExit(run func()) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			exit, ok := recovered.(*batchExit)
+			if !ok {
+				exit = &batchExit{err: fmt.Errorf("unexpected error: %v", recovered)}
+			}
+			err = exit.err
+		}
+	}()
+	run()
+	return nil
+}
+
+// batchArgs converts the flags of a line to command line arguments, followed by the arguments of the line.
+func batchArgs(line batchLine) []string {
+	names := make([]string, 0, len(line.Flags))
+	for name := range line.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var args []string
+	for _, name := range names {
+		flagName := "--" + strings.TrimLeft(name, "-")
+		if len(strings.TrimLeft(name, "-")) == 1 {
+			flagName = "-" + strings.TrimLeft(name, "-")
+		}
+		values, ok := line.Flags[name].([]interface{})
+		if !ok {
+			values = []interface{}{line.Flags[name]}
+		}
+		for _, value := range values {
+			switch v := value.(type) {
+			case bool:
+				if v {
+					args = append(args, flagName)
+				}
+			case nil:
+			default:
+				args = append(args, flagName, fmt.Sprint(v))
+			}
+		}
+	}
+	return append(args, line.Args...)
+}
+
+// batchOutput is the captured output of a line, embedded as JSON when it is valid JSON.
+func batchOutput(output []byte) interface{} {
+	output = bytes.TrimSpace(output)
+	if len(output) == 0 {
+		return nil
+	}
+	if json.Valid(output) {
+		return json.RawMessage(output)
+	}
+	return string(output)
+}
@@ -0,0 +1,159 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	gcli "github.com/urfave/cli"
+)
+
+func TestReadBatchTasks(t *testing.T) {
+	input := strings.Join([]string{
+		`# alerts of the migration`,
+		`{"command": "createAlert", "flags": {"message": "Disk is full"}}`,
+		``,
+		`   {"command": "closeAlert", "flags": {"id": "a1"}}   `,
+		`{"command": "createAlert", "flags": `,
+		`{"flags": {"id": "a1"}}`,
+		`{"command": "listAlerts"}`,
+	}, "\n")
+	tests := []struct {
+		number  int
+		command string
+		wantErr bool
+	}{
+		{number: 2, command: "createAlert"},
+		{number: 4, command: "closeAlert"},
+		{number: 5, wantErr: true},
+		{number: 6, wantErr: true},
+		{number: 7, command: "listAlerts"},
+	}
+
+	tasks, err := readBatchTasks(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != len(tests) {
+		t.Fatalf("read %d tasks, want %d", len(tasks), len(tests))
+	}
+	for i, test := range tests {
+		task := tasks[i]
+		if task.index != i || task.number != test.number || task.line.Command != test.command {
+			t.Errorf("task %d is line %d index %d command %q, want line %d index %d command %q",
+				i, task.number, task.index, task.line.Command, test.number, i, test.command)
+		}
+		if test.wantErr != (task.err != nil) || (test.wantErr && exitCode(task.err) != ExitCodeUsage) {
+			t.Errorf("task of line %d has the error %v, want an error: %v", test.number, task.err, test.wantErr)
+		}
+	}
+}
+
+func TestBatchArgs(t *testing.T) {
+	// each line maps to the arguments it is run with, joined by spaces
+	lines := map[string]string{
+		`{"command": "listAlerts"}`: ``,
+		`{"command": "createAlert", "flags": {"message": "Disk", "alias": "disk-full"}}`:     `--alias disk-full --message Disk`,
+		`{"command": "getAlert", "flags": {"--id": "a1", "-v": true}}`:                       `--id a1 -v`,
+		`{"command": "getAlert", "flags": {"v": true, "id": "a1"}}`:                          `--id a1 -v`,
+		`{"command": "listAlerts", "flags": {"all": true, "pretty": false}}`:                 `--all`,
+		`{"command": "listAlerts", "flags": {"limit": 100, "createdAfter": 1546300800000}}`:  `--createdAfter 1546300800000 --limit 100`,
+		`{"command": "createAlert", "flags": {"tags": ["disk", "prod"], "message": "Disk"}}`: `--message Disk --tags disk --tags prod`,
+		`{"command": "createAlert", "flags": {"alias": null, "message": "Disk"}}`:            `--message Disk`,
+		`{"command": "getAlert", "flags": {"id": "a1"}, "args": ["extra"]}`:                  `--id a1 extra`,
+	}
+	for line, want := range lines {
+		var parsed batchLine
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.UseNumber()
+		if err := decoder.Decode(&parsed); err != nil {
+			t.Fatal(err)
+		}
+		if args := strings.Join(batchArgs(parsed), " "); args != want {
+			t.Errorf("batchArgs of %s is %q, want %q", line, args, want)
+		}
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	batchMode = true
+	defer func() { batchMode = false }()
+
+	app := gcli.NewApp()
+	app.Commands = []gcli.Command{
+		{Name: "batch"},
+		{
+			Name:  "echo",
+			Flags: []gcli.Flag{gcli.StringFlag{Name: "message"}},
+			Action: func(c *gcli.Context) {
+				fmt.Fprintf(c.App.Writer, `{"message": %q}`, c.String("message"))
+			},
+		},
+		{
+			Name: "fail",
+			Action: func(c *gcli.Context) {
+				fmt.Fprint(c.App.Writer, "looking for the alert")
+				exitOnErr(newError(ExitCodeNotFound, "Alert does not exist"))
+			},
+		},
+	}
+	var output bytes.Buffer
+	app.Writer = &output
+	c := gcli.NewContext(app, flag.NewFlagSet("batch", flag.ContinueOnError), nil)
+	c.Command = app.Commands[0]
+
+	input := strings.Join([]string{
+		`{"command": "echo", "flags": {"message": "first"}}`,
+		`{"command": "fail"}`,
+		`{"command": "batch"}`,
+		`{"command": "echo", "flags": {"message": "last"}}`,
+	}, "\n")
+	tasks, err := readBatchTasks(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := runBatch(c, tasks, 3, false)
+	codes := make([]int, len(results))
+	for i, result := range results {
+		codes[i] = result.ExitCode
+	}
+	if want := []int{ExitCodeOK, ExitCodeNotFound, ExitCodeUsage, ExitCodeOK}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("lines exited with %v, want %v", codes, want)
+	}
+	if results[1].Output != "looking for the alert" || results[1].Error.Message != "Alert does not exist" {
+		t.Errorf("failed line has the output %v and error %+v", results[1].Output, results[1].Error)
+	}
+
+	// the results are printed in the order of the lines whatever the order the workers complete them in
+	want := `{"line":1,"command":"echo","exitCode":0,"output":{"message":"first"}}` + "\n"
+	if printed := strings.SplitAfter(output.String(), "\n"); len(printed) != 5 || printed[0] != want ||
+		!strings.HasPrefix(printed[3], `{"line":4,"command":"echo","exitCode":0,"output":{"message":"last"}}`) {
+		t.Errorf("printed\n%s", output.String())
+	}
+}
+
+func TestRunBatchStopsOnError(t *testing.T) {
+	batchMode = true
+	defer func() { batchMode = false }()
+
+	var ran []string
+	app := gcli.NewApp()
+	app.Writer = &bytes.Buffer{}
+	app.Commands = []gcli.Command{
+		{Name: "ok", Action: func(c *gcli.Context) { ran = append(ran, "ok") }},
+		{Name: "fail", Action: func(c *gcli.Context) { exitOnErr(newError(ExitCodeBadRequest, "Invalid request")) }},
+	}
+	c := gcli.NewContext(app, flag.NewFlagSet("batch", flag.ContinueOnError), nil)
+	tasks, _ := readBatchTasks(strings.NewReader("{\"command\": \"fail\"}\n{\"command\": \"ok\"}\n{\"command\": \"ok\"}\n"))
+
+	// a single worker runs the lines one after the other, so the lines after the failed one are skipped
+	results := runBatch(c, tasks, 1, true)
+	if len(ran) != 0 || !results[1].Skipped || !results[2].Skipped || results[0].ExitCode != ExitCodeBadRequest {
+		t.Errorf("ran %v with the results %+v, want the lines after the failure skipped", ran, results)
+	}
+}
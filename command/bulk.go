@@ -0,0 +1,164 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
+	gcli "github.com/urfave/cli"
+)
+
+const (
+	bulkPageSize       = 100
+	bulkPreviewSize    = 10
+	defaultBulkWorkers = 4
+)
+
+// bulkResult is the outcome of applying an action to one of the alerts matching the query.
+type bulkResult struct {
+	AlertID   string `json:"alertId"`
+	TinyID    string `json:"tinyId"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// bulkApply applies the action to the alert with the given id and returns the request id.
+type bulkApply func(alertID string) (requestID string, err error)
+
+func isBulkRequested(c *gcli.Context) bool {
+	return c.IsSet("query")
+}
+
+/*
+runBulkAlertAction applies an action to every alert matching the query flag. The matching alerts
+are listed first, so the action does not change the pages being listed, then the count and a
+preview are printed and the action is applied concurrently once it is confirmed. The result of
+each alert is rendered at the end, and the command fails if the action fails for any alert.
+*/
+func runBulkAlertAction(c *gcli.Context, api *API, action string, apply bulkApply) {
+	if c.IsSet("id") {
+		exitOnUsageErr("id and query can not be given together")
+	}
+	workers := defaultBulkWorkers
+	if c.IsSet("workers") {
+		workers = c.Int("workers")
+		if workers <= 0 {
+			exitOnUsageErr("workers should be a positive number")
+		}
+	}
+
+	alerts := listBulkAlerts(c, api)
+	if len(alerts) == 0 {
+		printSummary("No alert matches the query " + c.String("query") + ", nothing to " + action + ".")
+		return
+	}
+	printBulkPreview(alerts, action)
+	if !c.Bool("yes") && !confirmBulkAction(action, len(alerts)) {
+		exitOnErr(newError(ExitCodeError, "Aborted, no alert is changed."))
+	}
+
+	results := make([]bulkResult, len(alerts))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	completed, failed, next := 0, 0, 0
+	for i := 0; i < workers && i < len(alerts); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if next == len(alerts) {
+					mu.Unlock()
+					return
+				}
+				index := next
+				next++
+				mu.Unlock()
+
+				a := alerts[index]
+				result := bulkResult{AlertID: a.Id, TinyID: a.TinyID, Message: a.Message}
+				requestID, err := apply(a.Id)
+				if err == nil && isWaitRequested(c) {
+					err = waitBulkRequest(c, api, requestID)
+				}
+				if err != nil {
+					result.Error = err.Error()
+				}
+				result.RequestID = requestID
+
+				mu.Lock()
+				results[index] = result
+				completed++
+				if err != nil {
+					failed++
+				}
+				printSummary(fmt.Sprintf("Progress: %d/%d alerts, %d failed", completed, len(alerts), failed))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	renderResult(c, results)
+	printSummary(fmt.Sprintf("Requested to %s %d alerts, %d failed.", action, len(alerts)-failed, failed))
+	if failed != 0 {
+		exitOnErr(newError(ExitCodeError, strconv.Itoa(failed)+" of "+strconv.Itoa(len(alerts))+" alerts could not be changed."))
+	}
+}
+
+// waitBulkRequest waits until the request of an alert is processed, failing if the processing failed.
+func waitBulkRequest(c *gcli.Context, api *API, requestID string) error {
+	outcome, err := api.WaitForAlertRequest(rootContext(), requestID, grabWaitTimeout(c))
+	if err != nil {
+		return err
+	}
+	if !outcome.Success {
+		return newError(ExitCodeError, "Request "+requestID+" is processed with failure: "+outcome.Status)
+	}
+	return nil
+}
+
+// listBulkAlerts lists all alerts matching the query, at most max of them when the max flag is given.
+func listBulkAlerts(c *gcli.Context, api *API) []alert.Alert {
+	max := grabMaxResults(c)
+	opts := ListAlertsOptions{Query: c.String("query"), Limit: bulkPageSize, Sort: string(alert.CreatedAt), Order: string(alert.Asc)}
+	var alerts []alert.Alert
+	for {
+		resp, err := api.ListAlerts(rootContext(), opts)
+		exitOnErr(err)
+		alerts = append(alerts, resp.Alerts...)
+		if max > 0 && len(alerts) >= max {
+			return alerts[:max]
+		}
+		if len(resp.Alerts) < opts.Limit {
+			return alerts
+		}
+		opts.Offset += len(resp.Alerts)
+	}
+}
+
+func printBulkPreview(alerts []alert.Alert, action string) {
+	fmt.Fprintf(os.Stderr, "%d alerts match the query, they will be requested to %s:\n", len(alerts), action)
+	for i, a := range alerts {
+		if i == bulkPreviewSize {
+			fmt.Fprintf(os.Stderr, "  ... and %d more\n", len(alerts)-bulkPreviewSize)
+			break
+		}
+		fmt.Fprintf(os.Stderr, "  #%s [%s] %s\n", a.TinyID, a.Status, a.Message)
+	}
+}
+
+// confirmBulkAction asks for confirmation on the standard input, which is not possible in a batch.
+func confirmBulkAction(action string, count int) bool {
+	if batchMode && !shellMode {
+		exitOnUsageErr("Confirmation can not be asked in a batch, give the yes flag to " + action + " the alerts matching the query")
+	}
+	fmt.Fprintf(os.Stderr, "Do you want to %s %d alerts? [y/N]: ", action, count)
+	answer, _ := stdinReader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
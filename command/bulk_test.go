@@ -0,0 +1,164 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/opsgenie/opsgenie-lamp/mockserver"
+	"github.com/sirupsen/logrus"
+	gcli "github.com/urfave/cli"
+)
+
+// newMockAlertAPI returns an API sending its requests to a mock server with the given alerts.
+func newMockAlertAPI(t *testing.T, messages ...string) (*API, func()) {
+	t.Helper()
+	server := httptest.NewServer(mockserver.New(mockserver.Options{}))
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	api, err := NewAPI(Config{APIKey: "key", APIURL: server.URL, Logger: logger, RetryCount: 1})
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	for _, message := range messages {
+		tags := strings.Fields(message)[:1]
+		if _, err := api.CreateAlert(context.Background(), CreateAlertOptions{Message: message, Tags: tags}); err != nil {
+			server.Close()
+			t.Fatal(err)
+		}
+	}
+	return api, server.Close
+}
+
+// runBulk runs the bulk action with the given flags and returns its output and the error it exits with.
+func runBulk(t *testing.T, api *API, apply bulkApply, args ...string) (output string, err error) {
+	t.Helper()
+	set := flag.NewFlagSet("closeAlert", flag.ContinueOnError)
+	set.String("id", "", "")
+	set.String("query", "", "")
+	set.String("max", "", "")
+	set.Int("workers", 0, "")
+	set.Bool("yes", false, "")
+	set.String("output-format", "", "")
+	set.String("columns", "", "")
+	set.String("template", "", "")
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	app := gcli.NewApp()
+	app.Writer = &buffer
+
+	// in batch mode the exit of the action is recovered instead of ending the test
+	batchMode = true
+	defer func() {
+		batchMode = false
+		if recovered := recover(); recovered != nil {
+			exit, ok := recovered.(*batchExit)
+			if !ok {
+				panic(recovered)
+			}
+			output, err = buffer.String(), exit.err
+		}
+	}()
+	runBulkAlertAction(gcli.NewContext(app, set, nil), api, "close", apply)
+	return buffer.String(), nil
+}
+
+func TestRunBulkAlertAction(t *testing.T) {
+	api, closeServer := newMockAlertAPI(t, "disk is full on db1", "cpu is high", "disk is full on db2", "disk is slow")
+	defer closeServer()
+
+	closeAlert := func(alertID string) (string, error) {
+		resp, err := api.CloseAlert(context.Background(), AlertActionOptions{AlertIdentifier: AlertIdentifier{ID: alertID}})
+		if err != nil {
+			return "", err
+		}
+		return resp.RequestId, nil
+	}
+	output, err := runBulk(t, api, closeAlert, "--query", "tag:disk", "--yes", "--workers", "2",
+		"--output-format", "csv", "--columns", "message,error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the alerts are listed oldest first
+	if want := "message,error\ndisk is full on db1,\ndisk is full on db2,\ndisk is slow,\n"; output != want {
+		t.Errorf("printed %q, want %q", output, want)
+	}
+
+	open, err := api.ListAlerts(context.Background(), ListAlertsOptions{Query: "status:open"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open.Alerts) != 1 || open.Alerts[0].Message != "cpu is high" {
+		t.Errorf("open alerts are %+v, want only the alert not matching the query", open.Alerts)
+	}
+}
+
+func TestRunBulkAlertActionFailures(t *testing.T) {
+	api, closeServer := newMockAlertAPI(t, "disk is full", "disk is slow", "disk is gone")
+	defer closeServer()
+
+	applied := map[string]bool{}
+	failSlow := func(alertID string) (string, error) {
+		got, err := api.GetAlert(context.Background(), AlertIdentifier{ID: alertID})
+		if err != nil {
+			return "", err
+		}
+		if got.Message == "disk is slow" {
+			return "", errors.New("alert is locked")
+		}
+		applied[got.Message] = true
+		return "r-" + got.TinyId, nil
+	}
+
+	output, err := runBulk(t, api, failSlow, "--query", "disk", "--yes", "--workers", "1", "--max", "2")
+	if exitCode(err) != ExitCodeError || err.Error() != "1 of 2 alerts could not be changed." {
+		t.Fatalf("bulk action exited with %v, want the count of failed alerts", err)
+	}
+	var results []bulkResult
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		t.Fatalf("printed %q: %v", output, err)
+	}
+	var messages []string
+	for _, result := range results {
+		messages = append(messages, result.Message+": "+result.Error)
+	}
+	sort.Strings(messages)
+	if strings.Join(messages, "; ") != "disk is full: ; disk is slow: alert is locked" || !applied["disk is full"] || applied["disk is gone"] {
+		t.Errorf("results are %q after applying to %v, want the first two alerts", messages, applied)
+	}
+}
+
+func TestRunBulkAlertActionUsageErrors(t *testing.T) {
+	api, closeServer := newMockAlertAPI(t, "disk is full")
+	defer closeServer()
+
+	apply := func(alertID string) (string, error) {
+		t.Errorf("the action is applied to %s", alertID)
+		return "", nil
+	}
+	for _, args := range [][]string{
+		{"--query", "disk", "--id", "a1"},
+		{"--query", "disk", "--workers", "0"},
+		// confirmation can not be asked in a batch
+		{"--query", "disk"},
+	} {
+		if _, err := runBulk(t, api, apply, args...); exitCode(err) != ExitCodeUsage {
+			t.Errorf("bulk action with %q exited with %v, want a usage error", args, err)
+		}
+	}
+
+	// an empty match is not an error
+	if output, err := runBulk(t, api, apply, "--query", "cpu"); err != nil || output != "" {
+		t.Errorf("bulk action without a matching alert printed %q and exited with %v", output, err)
+	}
+}
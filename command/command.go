@@ -18,10 +18,9 @@ import (
 	"fmt"
 	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
 	"github.com/opsgenie/opsgenie-lamp/cfg"
+	"github.com/sirupsen/logrus"
 	gcli "github.com/urfave/cli"
 	"gopkg.in/yaml.v2"
-	"log"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -29,44 +28,35 @@ import (
 
 var verbose = false
 
-var logFilePath  = ""
-
 type LogLevel string
 
 const (
 	INFO LogLevel = "INFO"
 	ERROR LogLevel = "ERROR"
 	DEBUG LogLevel = "DEBUG"
+	WARN LogLevel = "WARN"
 )
 
-func printMessage(logLevel LogLevel, message string) {
-	if logLevel == DEBUG {
-		if verbose {
-			log.Println(string(logLevel) + " : " + message)
-			logToFile(DEBUG, message)
-		}
-		logger := client.Config{}.Logger
-		if logger != nil {
-			logger.Debug(message)
-		}
-	} else {
-		log.Println(string(logLevel) + " : " + message)
-		logToFile(logLevel, message)
-	}
+// printResultMessage prints a message reporting the result of a command, e.g. its request id.
+// It is written to the output of the command, which is the output of the batch line in batch mode.
+func printResultMessage(c *gcli.Context, message string) {
+	printResult(c, message, nil)
 }
 
-func logToFile(logLevel LogLevel, message string) {
-	if logFilePath != "" {
-		file, fileError := os.OpenFile(grabLogPath(),
-			os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if fileError != nil {
-			log.Println(fileError)
-		}
+// printResult prints a result message to the output of the command and logs it with fields to the log file, e.g. the request id.
+func printResult(c *gcli.Context, message string, fields logFields) {
+	fmt.Fprintln(c.App.Writer, strings.TrimSpace(message))
+	lampLog.logFile(INFO, message, fields)
+}
 
-		defer file.Close()
-		logger := log.New(file, string(logLevel+" : "), log.LstdFlags)
-		logger.Println(message)
-	}
+// printSummary prints the progress or the outcome of a command to the standard error whatever the log level is,
+// e.g. the summary of a batch, which is not part of the command output.
+func printSummary(message string) {
+	lampLog.logResult(message, nil)
+}
+
+func printMessage(logLevel LogLevel, message string) {
+	lampLog.log(logLevel, message, nil)
 }
 
 /*
@@ -74,21 +64,23 @@ The 'API Key' is the most common parameter for all commands.
 It is provided either on command line or on the configuration file.
 */
 func grabAPIKey(c *gcli.Context) string {
-	if val, success := getVal("apiKey", c); success {
-		return val
-	}
-	apiKey := cfg.Get("apiKey")
-	printMessage(DEBUG,"apiKey flag is not set in the command, reading apiKey from config..")
+	apiKey, err := lookupAPIKey(c)
+	exitOnErr(err)
 	return apiKey
 }
 
-func grabLogPath() string {
-	logPath := cfg.Get("logPath")
-	date := time.Now().Format("2006-01-02")
-	if logPath != "" {
-		return logPath +  "/" + date + "-Lamp.log"
+// lookupAPIKey returns the API key, or a configuration error if its file or command can not be read.
+func lookupAPIKey(c *gcli.Context) (string, error) {
+	if val, success := getVal("apiKey", c); success {
+		printMessage(DEBUG,"apiKey is read from the apiKey flag.")
+		return val, nil
 	}
-	return ""
+	printMessage(DEBUG,"apiKey flag is not set in the command, reading apiKey from config..")
+	apiKey, err := cfg.Lookup("apiKey")
+	if err != nil {
+		return "", newError(ExitCodeConfiguration, err.Error())
+	}
+	return apiKey, nil
 }
 
 func grabUsername(c *gcli.Context) string {
@@ -98,16 +90,51 @@ func grabUsername(c *gcli.Context) string {
 	return cfg.Get("user")
 }
 
-// getVal method returns the given argument names value if command context contains it.
+// getVal method returns the given argument names value if command context contains it, names given to ID flags are resolved to IDs.
 func getVal(argName string, c *gcli.Context) (string, bool) {
 	if c.IsSet(argName) {
 		arg := c.String(argName)
 		isEmpty(argName, arg, c)
+		if kind := resolvedKind(argName, c); kind != "" {
+			arg = resolveID(c, kind, arg)
+		}
 		return arg, true
 	}
 	return "", false
 }
 
+// grabList returns the comma separated values of the given argument, nil if it is not given.
+func grabList(argName string, c *gcli.Context) []string {
+	if val, success := getVal(argName, c); success {
+		return strings.Split(val, ",")
+	}
+	return nil
+}
+
+// grabUintFlag returns the value of the given numeric argument, 0 if it is not given.
+func grabUintFlag(argName string, c *gcli.Context) uint64 {
+	if val, success := getVal(argName, c); success {
+		number, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			exitOnUsageErr("Invalid " + argName + " value " + val)
+		}
+		return number
+	}
+	return 0
+}
+
+// grabIntFlag returns the value of the given numeric argument, 0 if it is not given.
+func grabIntFlag(argName string, c *gcli.Context) int {
+	if val, success := getVal(argName, c); success {
+		number, err := strconv.Atoi(val)
+		if err != nil {
+			exitOnUsageErr("Invalid " + argName + " value " + val)
+		}
+		return number
+	}
+	return 0
+}
+
 // isEmpty method check is the given argument is empty or not. Parameter with empty values are not allowed in opsgenie-lamp
 func isEmpty(argName string, arg string, c *gcli.Context) bool {
 	var prefix string
@@ -118,15 +145,19 @@ func isEmpty(argName string, arg string, c *gcli.Context) bool {
 			prefix = "--"
 		}
 		if strings.EqualFold(arg, prefix+name) {
-			fmt.Printf("Value of argument '%s' is empty\n", argName)
 			gcli.ShowCommandHelp(c, c.Command.Name)
-			os.Exit(1)
+			exitOnUsageErr(fmt.Sprintf("Value of argument '%s' is empty", argName))
 		}
 	}
 	return false
 }
 
 func getConfigurations(c *gcli.Context) *client.Config {
+	if batchConfig != nil {
+		return batchConfig
+	}
+	grabErrorFormat(c)
+	dryRunMode = isDryRun(c)
 	if c.IsSet("v") {
 		verbose = true
 		printMessage(DEBUG,"Will execute command in verbose mode.")
@@ -134,39 +165,32 @@ func getConfigurations(c *gcli.Context) *client.Config {
 
 	readConfigFile(c)
 	apiKey := grabAPIKey(c)
-	apiURL := cfg.Get("apiUrl")
-	if apiURL == "" {
-		apiURL = string(client.API_URL)
-	}
-	logFilePath = grabLogPath()
-	if logFilePath == "" {
-		log.Println(string(INFO) + ": Logging to file is disabled, To enable Logging to file Please specify logPath in configuration")
+	apiURL := grabAPIURL()
+	configureLogging(c)
+	if cfg.Get("logPath") == "" {
+		printMessage(INFO, "Logging to file is disabled, To enable Logging to file Please specify logPath in configuration")
 	}
 	config := client.Config{
 		ApiKey:         apiKey,
 		OpsGenieAPIURL: client.ApiUrl(apiURL),
 	}
-	proxyHost := cfg.Get("proxyHost")
-	var port = 0
-	var err error
-	if cfg.Get("proxyPort") != "" {
-		port, err = strconv.Atoi(cfg.Get("proxyPort"))
-		if err != nil {
-			printMessage(DEBUG,"Invalid proxy port.")
-		}
-	}
-
-	if proxyHost != "" {
-		printMessage(DEBUG,"Configuring proxy settings with host " + proxyHost)
-		config.ProxyConfiguration = &client.ProxyConfiguration{
-			Username: cfg.Get("proxyUsername"),
-			Password: cfg.Get("proxyPassword"),
-			Host:     proxyHost,
-			Protocol: proxyProtocol(cfg.Get("proxyProtocol")),
-			Port:     port,
-		}
+	config.HttpClient = newHTTPClient(c)
+	configureRetries(c, &config)
+	configureTimeout(c)
+	if isDryRun(c) {
+		configureDryRun(c, &config)
+	} else {
+		configureRateLimits(c, &config)
+		enableJournal(c, &config)
 	}
+	bindRootContext(&config)
 	config.ConfigureLogLevel(cfg.Get("lamp.log.level"))
+	config.Logger = newSDKLogger(config.LogLevel)
+	if isDryRun(c) {
+		// the SDK logs errDryRun of every request as an error, validation errors are still printed by exitOnErr
+		config.Logger.SetLevel(logrus.FatalLevel)
+	}
+	subscribeMetrics()
 	if cfg.Get("requestTimeout") != "" {
 		timeout, err := strconv.Atoi(cfg.Get("requestTimeout"))
 		if err != nil {
@@ -180,6 +204,17 @@ func getConfigurations(c *gcli.Context) *client.Config {
 	return &config
 }
 
+// grabAPIURL returns the apiUrl setting without its scheme, the SDK adds the scheme itself: plain http is used
+// for hosts without "api" in their name, e.g. localhost.
+func grabAPIURL() string {
+	apiURL := cfg.Get("apiUrl")
+	if apiURL == "" {
+		apiURL = string(client.API_URL)
+		printMessage(DEBUG, "apiUrl is not configured, will use the default "+apiURL)
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(apiURL, "https://"), "http://")
+}
+
 func proxyProtocol(protocol string) client.Protocol {
 	switch protocol {
 	case "http":
@@ -233,6 +268,10 @@ func readConfigFile(c *gcli.Context) {
 	} else {
 		cfg.LoadConfiguration()
 	}
+	profile, _ := getVal("profile", c)
+	if err := cfg.SelectProfile(profile); err != nil {
+		exitOnErr(newError(ExitCodeConfiguration, err.Error()))
+	}
 }
 
 func getListLogsCommandDefaultSize() int{
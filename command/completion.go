@@ -0,0 +1,338 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	gcli "github.com/urfave/cli"
+)
+
+const completionRequestTimeout = 5 * time.Second
+
+// Kinds of Opsgenie resources whose names are completed.
+const (
+	teamNames       = "teams"
+	userNames       = "users"
+	scheduleNames   = "schedules"
+	escalationNames = "escalations"
+	heartbeatNames  = "heartbeats"
+)
+
+// completedFlags maps the flags taking resource names, whatever the command is, to the kind of the resource.
+var completedFlags = map[string]string{
+	"teams":          teamNames,
+	"team":           teamNames,
+	"teamName":       teamNames,
+	"ownerTeam":      teamNames,
+	"users":          userNames,
+	"user":           userNames,
+	"userName":       userNames,
+	"schedules":      scheduleNames,
+	"escalations":    escalationNames,
+	"escalationName": escalationNames,
+}
+
+// listedFlags take a comma separated list of names, each name of the list is completed.
+var listedFlags = map[string]bool{"teams": true, "users": true, "schedules": true, "escalations": true}
+
+// responderTypes maps the responder types to the kind of the resource named by a responder of the type.
+var responderTypes = map[string]string{
+	"team":       teamNames,
+	"user":       userNames,
+	"escalation": escalationNames,
+	"schedule":   scheduleNames,
+}
+
+const bashCompletion = `# bash completion for lamp, load it with: source <(lamp completion bash)
+_lamp_completion() {
+    local cur words cword
+    if declare -F _get_comp_words_by_ref >/dev/null 2>&1; then
+        _get_comp_words_by_ref -n =: cur words cword
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}"
+        words=("${COMP_WORDS[@]}")
+        cword=$COMP_CWORD
+    fi
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$("${words[0]}" __complete "${words[@]:1:cword}" 2>/dev/null)" -- "$cur"))
+    if declare -F __ltrim_colon_completions >/dev/null 2>&1; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+complete -o default -F _lamp_completion lamp
+`
+
+const zshCompletion = `#compdef lamp
+# zsh completion for lamp, load it with: source <(lamp completion zsh)
+_lamp() {
+    local -a candidates
+    candidates=("${(@f)$(${words[1]} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    (( ${#candidates} )) && compadd -Q -- "${candidates[@]}"
+}
+if (( $+functions[compdef] )); then
+    compdef _lamp lamp
+fi
+`
+
+const fishCompletion = `# fish completion for lamp, load it with: lamp completion fish | source
+function __lamp_complete
+    set -l tokens (commandline -opc)
+    set -l lamp $tokens[1]
+    set -e tokens[1]
+    set -l current (commandline -ct)
+    $lamp __complete $tokens "$current" 2>/dev/null
+end
+complete -c lamp -f -a '(__lamp_complete)'
+`
+
+// CompletionAction prints the completion script of the given shell.
+func CompletionAction(c *gcli.Context) {
+	switch shell := c.Args().First(); shell {
+	case "bash":
+		fmt.Fprint(c.App.Writer, bashCompletion)
+	case "zsh":
+		fmt.Fprint(c.App.Writer, zshCompletion)
+	case "fish":
+		fmt.Fprint(c.App.Writer, fishCompletion)
+	default:
+		exitOnUsageErr("Shell should be given as bash, zsh or fish, e.g. lamp completion bash")
+	}
+}
+
+/*
+CompleteAction prints the candidates completing the last of the given words, one per line. The
+words are the command line after lamp, the last one is the word being completed. Command names,
+flag names and the names of teams, users, schedules, escalations and heartbeats are completed,
+the names are read from a cache refreshed from Opsgenie once it is older than completionCacheTTL.
+*/
+func CompleteAction(c *gcli.Context) {
+	words := c.Args()
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	// the global flags and their values given before the command are skipped
+	start := 0
+	for start < len(words)-1 && strings.HasPrefix(words[start], "-") {
+		f := appFlag(c.App, words[start])
+		start++
+		if takesValue(f, words[start-1]) {
+			if start == len(words)-1 {
+				return
+			}
+			start++
+		}
+	}
+	commandWords := words[start:]
+	if len(commandWords) == 1 {
+		if strings.HasPrefix(current, "-") {
+			printFlagCandidates(c, c.App.Flags)
+			return
+		}
+		for _, cmd := range c.App.Commands {
+			if !cmd.Hidden {
+				printCandidates(c, cmd.Names()...)
+			}
+		}
+		return
+	}
+
+	cmd := c.App.Command(commandWords[0])
+	if cmd == nil {
+		return
+	}
+	if previous := commandWords[len(commandWords)-2]; strings.HasPrefix(previous, "-") && !strings.HasPrefix(current, "-") {
+		if f := commandFlag(cmd, strings.TrimLeft(previous, "-")); f != nil {
+			if _, isBool := f.(gcli.BoolFlag); !isBool {
+				completeFlagValue(c, cmd, strings.TrimLeft(previous, "-"), words, current)
+				return
+			}
+		}
+	}
+	printFlagCandidates(c, cmd.Flags)
+}
+
+// appFlag returns the global flag given in the word, nil if the word is not a global flag.
+func appFlag(app *gcli.App, word string) gcli.Flag {
+	name := strings.SplitN(strings.TrimLeft(word, "-"), "=", 2)[0]
+	for _, f := range app.Flags {
+		for _, flagName := range flagNames(f) {
+			if flagName == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// takesValue returns whether the flag given in the word is followed by its value.
+func takesValue(f gcli.Flag, word string) bool {
+	if f == nil || strings.Contains(word, "=") {
+		return false
+	}
+	switch f.(type) {
+	case gcli.BoolFlag, gcli.BoolTFlag:
+		return false
+	}
+	return true
+}
+
+func printFlagCandidates(c *gcli.Context, flags []gcli.Flag) {
+	for _, f := range flags {
+		for _, name := range flagNames(f) {
+			if len(name) == 1 {
+				printCandidates(c, "-"+name)
+			} else {
+				printCandidates(c, "--"+name)
+			}
+		}
+	}
+}
+
+func printCandidates(c *gcli.Context, candidates ...string) {
+	for _, candidate := range candidates {
+		fmt.Fprintln(c.App.Writer, candidate)
+	}
+}
+
+func flagNames(f gcli.Flag) []string {
+	var names []string
+	for _, name := range strings.Split(f.GetName(), ",") {
+		names = append(names, strings.TrimSpace(name))
+	}
+	return names
+}
+
+func commandFlag(cmd *gcli.Command, name string) gcli.Flag {
+	for _, f := range cmd.Flags {
+		for _, flagName := range flagNames(f) {
+			if flagName == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// completeFlagValue prints the names completing the value of a flag, if the flag takes the name of a resource.
+func completeFlagValue(c *gcli.Context, cmd *gcli.Command, flagName string, words []string, current string) {
+	name := flagNames(commandFlag(cmd, flagName))[0]
+	kind, prefix := completedFlags[name], ""
+	switch {
+	case name == "name" && strings.Contains(cmd.Name, "Heartbeat"):
+		kind = heartbeatNames
+	case name == "name" && !strings.HasPrefix(cmd.Name, "create") && isTeamCommand(cmd.Name):
+		kind = teamNames
+	case name == "name" && !strings.HasPrefix(cmd.Name, "create") && isScheduleCommand(cmd.Name):
+		kind = scheduleNames
+	case name == "responder":
+		// the responder type is given with the type flag, otherwise responders are completed as type:name
+		if responderType := wordsFlagValue(words, "type"); responderType != "" {
+			kind = responderTypes[strings.ToLower(responderType)]
+		} else {
+			for responderType, responderKind := range responderTypes {
+				if strings.HasPrefix(current, responderType+":") {
+					kind, prefix = responderKind, responderType+":"
+				}
+			}
+			if kind == "" {
+				types := make([]string, 0, len(responderTypes))
+				for responderType := range responderTypes {
+					types = append(types, responderType+":")
+				}
+				sort.Strings(types)
+				printCandidates(c, types...)
+				return
+			}
+		}
+	}
+	if kind == "" {
+		return
+	}
+	if listedFlags[name] {
+		if i := strings.LastIndex(current, ","); i >= 0 {
+			prefix = current[:i+1]
+		}
+	}
+	for _, resourceName := range completionNames(c, cmd, words, kind) {
+		printCandidates(c, prefix+resourceName)
+	}
+}
+
+func isTeamCommand(name string) bool {
+	for _, part := range []string{"Team", "Member", "Role", "RoutingRule"} {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func isScheduleCommand(name string) bool {
+	return (strings.Contains(name, "Schedule") || strings.Contains(name, "Oncall")) &&
+		!strings.Contains(name, "Rotation") && !strings.Contains(name, "Override")
+}
+
+// wordsFlagValue returns the value of a flag given in the words of a command line.
+func wordsFlagValue(words []string, name string) string {
+	for i := 0; i < len(words)-2; i++ {
+		if strings.TrimLeft(words[i], "-") == name && strings.HasPrefix(words[i], "-") {
+			return words[i+1]
+		}
+	}
+	return ""
+}
+
+// completionNames returns the names of the resources of a kind from the cache, refreshing the cache when it is stale.
+func completionNames(c *gcli.Context, cmd *gcli.Command, words []string, kind string) []string {
+	ctx := completionContext(c, cmd, words)
+	readConfigFile(ctx)
+	apiKey, err := lookupAPIKey(ctx)
+	if err != nil || apiKey == "" {
+		return nil
+	}
+	account := &client.Config{ApiKey: apiKey, OpsGenieAPIURL: client.ApiUrl(grabAPIURL())}
+	resources, fresh := cachedResources(account, kind, cacheTTL(ctx))
+	if fresh {
+		return resourceNames(resources)
+	}
+
+	completionConfig := *getConfigurations(ctx)
+	completionConfig.RequestTimeout = completionRequestTimeout
+	completionConfig.RetryPolicy = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		return false, err
+	}
+	listed, err := refreshResources(newAPI(&completionConfig), kind, "")
+	if err != nil {
+		printMessage(DEBUG, "Could not list the "+kind+" to complete: "+err.Error())
+		return resourceNames(resources)
+	}
+	return resourceNames(listed)
+}
+
+// completionContext is a context with the common flags given in the words, e.g. the config and profile flags, before or
+// after the command.
+func completionContext(c *gcli.Context, cmd *gcli.Command, words []string) *gcli.Context {
+	set := flag.NewFlagSet("__complete", flag.ContinueOnError)
+	for _, f := range cmd.Flags {
+		f.Apply(set)
+	}
+	for _, f := range c.App.Flags {
+		if set.Lookup(flagNames(f)[0]) == nil {
+			f.Apply(set)
+		}
+	}
+	for _, name := range []string{"config", "profile", "apiKey"} {
+		if value := wordsFlagValue(words, name); value != "" && set.Lookup(name) != nil {
+			set.Set(name, value)
+		}
+	}
+	return gcli.NewContext(c.App, set, nil)
+}
@@ -0,0 +1,152 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/team"
+	"github.com/opsgenie/opsgenie-lamp/cfg"
+	"github.com/opsgenie/opsgenie-lamp/mockserver"
+	gcli "github.com/urfave/cli"
+)
+
+// complete runs CompleteAction with the words of a command line and returns the printed candidates.
+func complete(t *testing.T, app *gcli.App, words ...string) []string {
+	t.Helper()
+	var output bytes.Buffer
+	app.Writer = &output
+	set := flag.NewFlagSet("__complete", flag.ContinueOnError)
+	if err := set.Parse(append([]string{"--"}, words...)); err != nil {
+		t.Fatal(err)
+	}
+	CompleteAction(gcli.NewContext(app, set, nil))
+	return strings.Fields(output.String())
+}
+
+// unloadConfigFile loads an empty configuration file in place of the files loaded by a test.
+func unloadConfigFile(dir string) {
+	empty := filepath.Join(dir, "empty.conf")
+	ioutil.WriteFile(empty, nil, 0600)
+	cfg.LoadConfigFromGivenPath(empty)
+	cfg.SelectProfile("")
+}
+
+func newCompletionApp() *gcli.App {
+	app := gcli.NewApp()
+	app.Commands = []gcli.Command{
+		{
+			Name: "createAlert",
+			Flags: []gcli.Flag{
+				gcli.StringFlag{Name: "config"},
+				gcli.StringFlag{Name: "message, m"},
+				gcli.StringFlag{Name: "teams"},
+				gcli.StringFlag{Name: "responder"},
+				gcli.StringFlag{Name: "type"},
+				gcli.BoolFlag{Name: "wait"},
+			},
+		},
+		{Name: "getTeam", Flags: []gcli.Flag{gcli.StringFlag{Name: "config"}, gcli.StringFlag{Name: "name"}}},
+		{Name: "createTeam", Flags: []gcli.Flag{gcli.StringFlag{Name: "name"}}},
+		{Name: "__complete", Hidden: true},
+	}
+	return app
+}
+
+func TestCompleteAction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-completion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer unloadConfigFile(dir)
+	os.Setenv("XDG_CACHE_HOME", dir)
+	defer os.Unsetenv("XDG_CACHE_HOME")
+	config := filepath.Join(dir, "lamp.conf")
+	ioutil.WriteFile(config, []byte("apiKey=key\n"), 0600)
+
+	// the names are read from a fresh cache without asking Opsgenie
+	writeResourceCache(t, string(client.API_URL), teamNames, namedResource{ID: "t1", Name: "sre"}, namedResource{ID: "t2", Name: "web"})
+
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{words: nil, want: "createAlert getTeam createTeam"},
+		{words: []string{"crea"}, want: "createAlert getTeam createTeam"},
+		{words: []string{"createAlert", "-"}, want: "--config --message -m --teams --responder --type --wait"},
+		{words: []string{"createAlert", "--wait", ""}, want: "--config --message -m --teams --responder --type --wait"},
+		{words: []string{"createAlert", "--message", ""}, want: ""},
+		{words: []string{"createAlert", "--responder", ""}, want: "escalation: schedule: team: user:"},
+		{words: []string{"createAlert", "--config", config, "--teams", "sre,"}, want: "sre,sre sre,web"},
+		{words: []string{"createAlert", "--config", config, "--responder", "team:s"}, want: "team:sre team:web"},
+		{words: []string{"createAlert", "--config", config, "--type", "team", "--responder", ""}, want: "sre web"},
+		{words: []string{"getTeam", "--config", config, "--name", ""}, want: "sre web"},
+		{words: []string{"createTeam", "--name", ""}, want: ""},
+		{words: []string{"unknown", ""}, want: ""},
+	}
+	for _, test := range tests {
+		if got := strings.Join(complete(t, newCompletionApp(), test.words...), " "); got != test.want {
+			t.Errorf("completion of %q is %q, want %q", test.words, got, test.want)
+		}
+	}
+
+	// the global flags given before the command are skipped with their values
+	app := newCompletionApp()
+	app.Flags = []gcli.Flag{gcli.StringFlag{Name: "config"}, gcli.BoolFlag{Name: "verbose"}}
+	globalTests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{"-"}, "--config --verbose"},
+		{[]string{"--config", ""}, ""},
+		{[]string{"--config=" + config, "--verbose", "c"}, "createAlert getTeam createTeam"},
+		{[]string{"--verbose", "createAlert", "-m"}, "--config --message -m --teams --responder --type --wait"},
+		{[]string{"--config", config, "getTeam", "--name", ""}, "sre web"},
+	}
+	for _, test := range globalTests {
+		if got := strings.Join(complete(t, app, test.words...), " "); got != test.want {
+			t.Errorf("completion of %q is %q, want %q", test.words, got, test.want)
+		}
+	}
+}
+
+func TestCompletionNamesRefreshTheCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-completion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer unloadConfigFile(dir)
+	os.Setenv("XDG_CACHE_HOME", dir)
+	defer os.Unsetenv("XDG_CACHE_HOME")
+
+	server := httptest.NewServer(mockserver.New(mockserver.Options{}))
+	defer server.Close()
+	config := filepath.Join(dir, "lamp.conf")
+	ioutil.WriteFile(config, []byte("apiKey=key\napiUrl="+strings.TrimPrefix(server.URL, "http://")+"\ncompletionCacheTTL=0\n"), 0600)
+
+	teams, err := team.NewClient(&client.Config{ApiKey: "key", OpsGenieAPIURL: client.ApiUrl(strings.TrimPrefix(server.URL, "http://"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := teams.Create(context.Background(), &team.CreateTeamRequest{Name: "database"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a cache older than completionCacheTTL is refreshed
+	cache := writeResourceCache(t, strings.TrimPrefix(server.URL, "http://"), teamNames, namedResource{ID: "t1", Name: "sre"})
+	if got := complete(t, newCompletionApp(), "createAlert", "--config", config, "--teams", ""); len(got) != 1 || got[0] != "database" {
+		t.Errorf("completed %q, want the teams listed from Opsgenie", got)
+	}
+	if content, _ := ioutil.ReadFile(cache); !strings.Contains(string(content), `"name":"database"`) || strings.Contains(string(content), "sre") {
+		t.Errorf("cache is %s after the refresh", content)
+	}
+}
@@ -0,0 +1,229 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	gcli "github.com/urfave/cli"
+)
+
+// The root context of the running command, canceled by SIGINT and SIGTERM or when the timeout passes.
+var (
+	rootMu      sync.Mutex
+	rootCtx     context.Context
+	rootCancel  context.CancelFunc
+	rootSignal  os.Signal
+	rootTimeout time.Duration
+	rootStart   = time.Now()
+)
+
+func init() {
+	rootCtx, rootCancel = context.WithCancel(context.Background())
+}
+
+/*
+HandleSignals cancels the running command on SIGINT and SIGTERM: the requests in flight are canceled, partial
+output files are removed and lamp exits with ExitCodeInterrupted. A second signal exits immediately.
+*/
+func HandleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			rootMu.Lock()
+			again := rootSignal != nil
+			rootSignal = sig
+			cancel := rootCancel
+			rootMu.Unlock()
+			if again {
+				fmt.Fprintln(os.Stderr)
+				os.Exit(ExitCodeInterrupted)
+			}
+			printMessage(WARN, "Interrupted, canceling the command. Interrupt again to exit immediately")
+			cancel()
+		}
+	}()
+}
+
+// rootContext is the context of the requests of the running command.
+func rootContext() context.Context {
+	rootMu.Lock()
+	defer rootMu.Unlock()
+	return rootCtx
+}
+
+// configureTimeout sets the deadline of the command from the timeout setting, counted from the start of lamp or of the shell line.
+func configureTimeout(c *gcli.Context) {
+	timeout, set := grabDuration("timeout", c)
+	if !set || timeout <= 0 {
+		return
+	}
+	rootMu.Lock()
+	defer rootMu.Unlock()
+	rootTimeout = timeout
+	rootCtx, rootCancel = context.WithDeadline(rootCtx, rootStart.Add(timeout))
+}
+
+// resetRootContext starts a new root context for the next line of the shell, the timeout applies to each line.
+func resetRootContext() {
+	rootMu.Lock()
+	defer rootMu.Unlock()
+	rootCancel()
+	rootSignal = nil
+	rootStart = time.Now()
+	if rootTimeout > 0 {
+		rootCtx, rootCancel = context.WithDeadline(context.Background(), rootStart.Add(rootTimeout))
+	} else {
+		rootCtx, rootCancel = context.WithCancel(context.Background())
+	}
+}
+
+// interruption returns the error ending the command when it is interrupted or its timeout passes, nil otherwise.
+func interruption() error {
+	rootMu.Lock()
+	defer rootMu.Unlock()
+	if rootSignal != nil {
+		name := "SIGINT"
+		if rootSignal == syscall.SIGTERM {
+			name = "SIGTERM"
+		}
+		return newError(ExitCodeInterrupted, "Command is interrupted by "+name)
+	}
+	if rootCtx.Err() == context.DeadlineExceeded {
+		return newError(ExitCodeTimeout, "Command did not complete in the timeout of "+rootTimeout.String())
+	}
+	return nil
+}
+
+// contextTransport binds the requests to a context, the SDK does not pass the context given to its calls to the requests.
+type contextTransport struct {
+	next http.RoundTripper
+	ctx  func() context.Context
+}
+
+// boundContextKey marks the context of a request bound by a contextTransport, the transports it wraps keep it.
+type boundContextKey struct{}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Context().Value(boundContextKey{}) != nil {
+		return t.next.RoundTrip(req)
+	}
+	ctx := context.WithValue(t.ctx(), boundContextKey{}, true)
+	// the request has the deadline of the timeout of the http client
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return t.next.RoundTrip(req.WithContext(ctx))
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &cancelingBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelingBody releases the context of a request when its response is read.
+type cancelingBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// bindRootContext cancels the requests of the client and stops retrying them when the root context is done.
+func bindRootContext(config *client.Config) {
+	bindContext(config, rootContext)
+}
+
+// bindContext cancels the requests of the client and stops retrying them when the context returned by ctx is done.
+func bindContext(config *client.Config, ctx func() context.Context) {
+	transport := config.HttpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	config.HttpClient.Transport = &contextTransport{next: transport, ctx: ctx}
+
+	policy := config.RetryPolicy
+	if policy == nil {
+		policy = defaultRetryPolicy
+	}
+	config.RetryPolicy = func(retryCtx context.Context, resp *http.Response, err error) (bool, error) {
+		if ctxErr := ctx().Err(); ctxErr != nil {
+			return false, ctxErr
+		}
+		return policy(retryCtx, resp, err)
+	}
+
+	backoff := config.Backoff
+	if backoff == nil {
+		backoff = retryablehttp.DefaultBackoff
+	}
+	// the wait between retries is done here, as the SDK waits for retries without a context
+	config.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		timer := time.NewTimer(backoff(min, max, attemptNum, resp))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx().Done():
+		}
+		return 0
+	}
+}
+
+/*
+writeFileSafely writes a file through a .part file which is renamed to the file when write succeeds, so that
+interrupted or failed commands do not leave partial files.
+*/
+func writeFileSafely(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path + ".part")
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// downloadFileSafely downloads the url to the file with the HTTP client until ctx is done, see writeFileSafely.
+func downloadFileSafely(ctx context.Context, httpClient *http.Client, path string, url string) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("downloading %s failed with %s", filepath.Base(path), resp.Status)
+	}
+	return writeFileSafely(path, func(w io.Writer) error {
+		_, err := io.Copy(w, resp.Body)
+		return err
+	})
+}
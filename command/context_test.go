@@ -0,0 +1,223 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/team"
+	"github.com/sirupsen/logrus"
+	gcli "github.com/urfave/cli"
+)
+
+// resetRoot ends the timeout and the interruption of a test.
+func resetRoot() {
+	rootMu.Lock()
+	rootTimeout = 0
+	rootMu.Unlock()
+	resetRootContext()
+}
+
+func TestWriteFileSafely(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logs.json")
+
+	failed := writeFileSafely(path, func(w io.Writer) error {
+		fmt.Fprint(w, "partial")
+		return errors.New("connection reset")
+	})
+	if failed == nil || failed.Error() != "connection reset" {
+		t.Errorf("failed write returned %v", failed)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("failed write left %d files", len(files))
+	}
+
+	if err := writeFileSafely(path, func(w io.Writer) error { _, err := fmt.Fprint(w, "complete"); return err }); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadFile(path); string(content) != "complete" {
+		t.Errorf("file has %q", content)
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Errorf("part file is left: %v", err)
+	}
+}
+
+func TestDownloadFileSafely(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/logs/1" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "log lines")
+	}))
+	defer server.Close()
+	// the files are downloaded with the TLS settings of the configuration, without the transports of the Opsgenie requests
+	tlsTransport := server.Client().Transport
+	config := &client.Config{HttpClient: &http.Client{Transport: tlsTransport}}
+	bindRootContext(config)
+	httpClient := downloadClient(config)
+	if httpClient.Transport != tlsTransport {
+		t.Fatalf("download client has the transport %T", httpClient.Transport)
+	}
+
+	path := filepath.Join(dir, "1.json")
+	if err := downloadFileSafely(context.Background(), http.DefaultClient, path, server.URL+"/logs/1"); err == nil {
+		t.Error("download with a client not trusting the server succeeded")
+	}
+	if err := downloadFileSafely(context.Background(), httpClient, path, server.URL+"/logs/1"); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadFile(path); string(content) != "log lines" {
+		t.Errorf("downloaded %q", content)
+	}
+	missing := filepath.Join(dir, "2.json")
+	if err := downloadFileSafely(context.Background(), httpClient, missing, server.URL+"/logs/2"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("download of a missing file returned %v", err)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("failed download created %s", missing)
+	}
+}
+
+func TestTimeoutCancelsRequests(t *testing.T) {
+	defer resetRoot()
+	resetRootContext()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	config := &client.Config{
+		ApiKey:         "key",
+		OpsGenieAPIURL: client.ApiUrl(strings.TrimPrefix(server.URL, "http://")),
+		HttpClient:     &http.Client{},
+		Logger:         logger,
+		RetryCount:     3,
+	}
+	configureTimeout(newSettingsContext(t, map[string]string{"timeout": "300ms"}))
+	bindRootContext(config)
+	cli, err := team.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Now()
+	_, err = cli.List(context.Background(), &team.ListTeamRequest{})
+	if err == nil {
+		t.Fatal("request succeeded after the timeout")
+	}
+	if took := time.Since(started); took > 2*time.Second {
+		t.Errorf("request ended %s after the timeout of 300ms, want it canceled without retries", took)
+	}
+	stop := interruption()
+	if exitCode(stop) != ExitCodeTimeout || !strings.Contains(stop.Error(), "300ms") {
+		t.Errorf("interruption is %v, want the timeout", stop)
+	}
+
+	// the shell starts each line with a new deadline
+	resetRootContext()
+	if err := interruption(); err != nil || rootContext().Err() != nil {
+		t.Errorf("new line starts with %v, %v", err, rootContext().Err())
+	}
+}
+
+func TestInterruptedCommandExits(t *testing.T) {
+	defer resetRoot()
+	batchMode = true
+	defer func() { batchMode = false }()
+
+	// the request fails because the signal canceled it, the command exits as interrupted
+	rootMu.Lock()
+	rootSignal = syscall.SIGTERM
+	rootCancel()
+	rootMu.Unlock()
+	err := catchExit(func() { exitOnErr(context.Canceled) })
+	if exitCode(err) != ExitCodeInterrupted || err.Error() != "Command is interrupted by SIGTERM" {
+		t.Errorf("interrupted command exited with %v", err)
+	}
+	if err := catchExit(func() { exitOnErr(errDryRun) }); err != errDryRun {
+		t.Errorf("interrupted dry run exited with %v", err)
+	}
+
+	// the lines of a batch which are not started yet are skipped
+	var ran []string
+	app := gcli.NewApp()
+	app.Writer = &bytes.Buffer{}
+	app.Commands = []gcli.Command{{Name: "ok", Action: func(c *gcli.Context) { ran = append(ran, "ok") }}}
+	tasks, _ := readBatchTasks(strings.NewReader("{\"command\": \"ok\"}\n{\"command\": \"ok\"}\n"))
+	results := runBatch(gcli.NewContext(app, flag.NewFlagSet("batch", flag.ContinueOnError), nil), tasks, 2, false)
+	if len(ran) != 0 || !results[0].Skipped || !results[1].Skipped {
+		t.Errorf("interrupted batch ran %v with the results %+v, want the lines skipped", ran, results)
+	}
+}
+
+func TestRootContextCancelsRequestsOfClientsWithATimeout(t *testing.T) {
+	defer resetRoot()
+	resetRootContext()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+		fmt.Fprint(w, "done")
+	}))
+	defer server.Close()
+	config := &client.Config{HttpClient: &http.Client{Timeout: 10 * time.Second}}
+	bindRootContext(config)
+
+	// the response is read after the transport returns it
+	resp, err := config.HttpClient.Get(server.URL + "/fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(body) != "done" {
+		t.Errorf("read %q, %v", body, err)
+	}
+
+	time.AfterFunc(100*time.Millisecond, func() {
+		rootMu.Lock()
+		rootCancel()
+		rootMu.Unlock()
+	})
+	started := time.Now()
+	if _, err := config.HttpClient.Get(server.URL + "/slow"); err == nil {
+		t.Error("request succeeded after the root context is canceled")
+	}
+	if took := time.Since(started); took > 2*time.Second {
+		t.Errorf("request ended %s after it started, want it canceled with the root context", took)
+	}
+}
@@ -0,0 +1,387 @@
+package command
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/logs"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/team"
+	"github.com/opsgenie/opsgenie-lamp/cfg"
+	gcli "github.com/urfave/cli"
+)
+
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip"
+
+	doctorTimeout   = 10 * time.Second
+	maxClockSkew    = time.Minute
+	textCheckFormat = "text"
+)
+
+// doctorKeys are the configuration keys whose sources are reported.
+var doctorKeys = []string{
+	"apiKey", "apiUrl", "user", "logPath", "requestTimeout", "connectionTimeout", "retryCount",
+	"proxyHost", "proxyPort", "proxyProtocol", "proxyUsername", "proxyPassword",
+	"caBundle", "clientCert", "clientKey", "insecureSkipVerify",
+}
+
+// doctorCheck is the outcome of one of the checks of the doctor command.
+type doctorCheck struct {
+	Check  string `json:"check"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+type doctor struct {
+	c      *gcli.Context
+	checks []doctorCheck
+	apiKey string
+	apiURL string
+	// reachable is set when the API answered, keyValid when it accepted the API key
+	reachable bool
+	keyValid  bool
+}
+
+func (d *doctor) report(check string, status string, detail string) {
+	d.checks = append(d.checks, doctorCheck{Check: check, Status: status, Detail: detail})
+}
+
+/*
+DoctorAction runs a checklist diagnosing why lamp can not reach Opsgenie: the configuration file
+and keys found, the proxy, DNS and TLS of the API URL, the validity of the API key, the API
+families the key can access, the clock skew with Opsgenie and the log path. The command fails if
+any check fails.
+*/
+func DoctorAction(c *gcli.Context) {
+	d := &doctor{c: c}
+	if d.checkConfiguration() {
+		d.checkKeys()
+		d.checkProxy()
+		d.checkDNS()
+		d.checkTLS()
+		d.checkAPIKey()
+		d.checkAPIFamilies()
+		d.checkLogPath()
+	}
+
+	if strings.ToLower(c.String("output-format")) == textCheckFormat {
+		for _, check := range d.checks {
+			fmt.Fprintf(c.App.Writer, "[%s] %s: %s\n", strings.ToUpper(check.Status), check.Check, check.Detail)
+		}
+	} else {
+		renderResult(c, d.checks)
+	}
+	for _, check := range d.checks {
+		if check.Status == checkFail {
+			exitOnErr(newError(ExitCodeError, "Some checks failed, see the details above."))
+		}
+	}
+}
+
+// checkConfiguration reports the configuration file read and the selected profile, it returns false if the profile can not be selected.
+func (d *doctor) checkConfiguration() bool {
+	cfg.Verbose = false
+	confPath, reason := "", "given with the config flag"
+	if val, success := getVal("config", d.c); success {
+		confPath = val
+		cfg.LoadConfigFromGivenPath(confPath)
+	} else {
+		confPath, reason = cfg.ResolveConfigPath()
+		cfg.LoadConfiguration()
+	}
+	if info, err := os.Stat(confPath); err != nil {
+		d.report("Configuration file", checkWarn, confPath+" can not be read ("+reason+"): "+err.Error()+
+			". Only environment variables and flags are used, run lamp configure to create it")
+	} else {
+		d.report("Configuration file", checkPass, confPath+" ("+reason+")")
+		if info.Mode().Perm()&0077 != 0 {
+			d.report("Configuration file permissions", checkWarn, fmt.Sprintf("%s is accessible by other users (%v), "+
+				"restrict it with chmod 600 since it may hold the API key", confPath, info.Mode().Perm()))
+		} else {
+			d.report("Configuration file permissions", checkPass, fmt.Sprintf("%v", info.Mode().Perm()))
+		}
+	}
+
+	profile, _ := getVal("profile", d.c)
+	if err := cfg.SelectProfile(profile); err != nil {
+		d.report("Profile", checkFail, err.Error()+", profiles in the file: "+strings.Join(cfg.Profiles(), ", "))
+		return false
+	}
+	if cfg.Profile() == "" {
+		d.report("Profile", checkPass, "No profile is selected, the top of the configuration file is used")
+	} else {
+		d.report("Profile", checkPass, cfg.Profile())
+	}
+	return true
+}
+
+// checkKeys reports where the configuration keys are read from, and the API key and URL used.
+func (d *doctor) checkKeys() {
+	var found []string
+	for _, key := range doctorKeys {
+		if d.c.IsSet(key) {
+			found = append(found, key+" ("+key+" flag)")
+		} else if source := cfg.Source(key); source != "" {
+			found = append(found, key+" ("+source+")")
+		}
+	}
+	if len(found) == 0 {
+		d.report("Configuration keys", checkWarn, "No configuration key is set")
+	} else {
+		d.report("Configuration keys", checkPass, strings.Join(found, ", "))
+	}
+
+	apiKey, err := lookupAPIKey(d.c)
+	d.apiKey = apiKey
+	if err != nil {
+		d.report("API key", checkFail, err.Error())
+	} else if d.apiKey == "" {
+		d.report("API key", checkFail, "API key is not set, give --apiKey, set apiKey in the configuration file or run lamp configure")
+	}
+	d.apiURL = cfg.Get("apiUrl")
+	if d.apiURL == "" {
+		d.apiURL = string(client.API_URL)
+	}
+	region := "custom"
+	for name, regionURL := range regionURLs {
+		if strings.TrimPrefix(d.apiURL, "https://") == regionURL {
+			region = name
+		}
+	}
+	d.report("API URL", checkPass, d.apiURL+" (region "+region+")")
+}
+
+// apiBaseURL is the URL of the API with its scheme, the SDK uses plain http for hosts without "api" in their name.
+func (d *doctor) apiBaseURL() string {
+	if strings.HasPrefix(d.apiURL, "http://") || strings.HasPrefix(d.apiURL, "https://") {
+		return d.apiURL
+	}
+	if !strings.Contains(d.apiURL, "api") {
+		return "http://" + d.apiURL
+	}
+	return "https://" + d.apiURL
+}
+
+func (d *doctor) apiHost() string {
+	host := strings.TrimPrefix(strings.TrimPrefix(d.apiURL, "https://"), "http://")
+	return strings.SplitN(host, "/", 2)[0]
+}
+
+func (d *doctor) checkProxy() {
+	if proxyURL := grabProxyURL(); proxyURL != nil {
+		d.report("Proxy", checkPass, "proxyHost is configured, requests are sent through "+redactProxy(proxyURL.String()))
+		return
+	}
+	req, _ := http.NewRequest(http.MethodGet, d.apiBaseURL(), nil)
+	if proxyURL, err := http.ProxyFromEnvironment(req); err != nil {
+		d.report("Proxy", checkFail, "Invalid proxy environment variable: "+err.Error())
+	} else if proxyURL != nil {
+		d.report("Proxy", checkPass, "Requests are sent through "+redactProxy(proxyURL.String())+" given with proxy environment variables")
+	} else {
+		d.report("Proxy", checkPass, "No proxy is used")
+	}
+}
+
+func (d *doctor) usesProxy() bool {
+	if grabProxyURL() != nil {
+		return true
+	}
+	req, _ := http.NewRequest(http.MethodGet, d.apiBaseURL(), nil)
+	proxyURL, _ := http.ProxyFromEnvironment(req)
+	return proxyURL != nil
+}
+
+func (d *doctor) checkDNS() {
+	host, _, err := net.SplitHostPort(d.apiHost())
+	if err != nil {
+		host = d.apiHost()
+	}
+	addresses, err := net.LookupHost(host)
+	switch {
+	case err == nil:
+		d.report("DNS", checkPass, host+" resolves to "+strings.Join(addresses, ", "))
+	case d.usesProxy():
+		d.report("DNS", checkWarn, host+" can not be resolved, which is fine if the proxy resolves it: "+err.Error())
+	default:
+		d.report("DNS", checkFail, host+" can not be resolved: "+err.Error())
+	}
+}
+
+func (d *doctor) checkTLS() {
+	if strings.HasPrefix(d.apiBaseURL(), "http://") {
+		d.report("TLS", checkSkip, d.apiURL+" is used with plain http")
+		return
+	}
+	if d.usesProxy() {
+		d.report("TLS", checkSkip, "Connections go through the proxy, TLS is checked with the API request")
+		return
+	}
+	address := d.apiHost()
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address += ":443"
+	}
+	tlsConfig := grabTLSConfig()
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: doctorTimeout}, "tcp", address, tlsConfig)
+	if err != nil {
+		d.report("TLS", checkFail, "TLS connection to "+address+" failed: "+err.Error()+
+			". Behind a TLS intercepting proxy, set caBundle to the proxy's CA certificate")
+		return
+	}
+	defer conn.Close()
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		d.report("TLS", checkPass, "Connected to "+address)
+		return
+	}
+	cert := state.PeerCertificates[0]
+	d.report("TLS", checkPass, fmt.Sprintf("Connected to %s, certificate of %s issued by %s expires at %s",
+		address, cert.Subject.CommonName, cert.Issuer.CommonName, cert.NotAfter.Format(time.RFC3339)))
+}
+
+// checkAPIKey gets the account with the HTTP client of lamp, which checks the reachability of the API, the API key and the clock skew.
+func (d *doctor) checkAPIKey() {
+	if d.apiKey == "" {
+		return
+	}
+	httpClient := newHTTPClient(d.c)
+	httpClient.Timeout = doctorTimeout
+	req, err := http.NewRequest(http.MethodGet, d.apiBaseURL()+"/v2/account", nil)
+	if err != nil {
+		d.report("API request", checkFail, err.Error())
+		return
+	}
+	req.Header.Set("Authorization", "GenieKey "+d.apiKey)
+	sent := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		d.report("API request", checkFail, "Could not reach "+d.apiBaseURL()+": "+err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+	took := time.Since(sent)
+	d.reachable = true
+	d.report("API request", checkPass, fmt.Sprintf("%s answered in %s", d.apiBaseURL(), took.Round(time.Millisecond)))
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		d.keyValid = true
+		d.report("API key", checkPass, "API key is valid")
+	case http.StatusForbidden:
+		d.keyValid = true
+		d.report("API key", checkWarn, "API key is valid but can not read the account, e.g. the key of an integration")
+	case http.StatusUnauthorized:
+		d.report("API key", checkFail, "API key is not valid for "+d.apiURL+
+			". A key is only valid in the region of its account, check apiUrl and the profile")
+	default:
+		d.report("API key", checkFail, "Unexpected response "+resp.Status)
+	}
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		d.report("Clock skew", checkSkip, "The API did not return its time")
+		return
+	}
+	// the Date header has a precision of a second and is set while the request is processed
+	skew := sent.Add(took / 2).Sub(date)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxClockSkew {
+		d.report("Clock skew", checkWarn, "Local clock differs from Opsgenie by "+skew.Round(time.Second).String()+
+			", dates given to commands may be misinterpreted")
+	} else {
+		d.report("Clock skew", checkPass, "Local clock differs from Opsgenie by less than "+maxClockSkew.String())
+	}
+}
+
+// checkAPIFamilies makes a cheap request to the alert, configuration and logs APIs, which an API key may be restricted from.
+func (d *doctor) checkAPIFamilies() {
+	families := []string{"Alert API", "Configuration API", "Logs API"}
+	if !d.reachable || !d.keyValid {
+		for _, family := range families {
+			d.report(family, checkSkip, "API key is not valid or the API is not reachable")
+		}
+		return
+	}
+	config := &client.Config{
+		ApiKey:         d.apiKey,
+		OpsGenieAPIURL: client.ApiUrl(strings.TrimPrefix(strings.TrimPrefix(d.apiURL, "https://"), "http://")),
+		HttpClient:     newHTTPClient(d.c),
+		RequestTimeout: doctorTimeout,
+		RetryPolicy: func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+			return false, err
+		},
+	}
+	config.ConfigureLogLevel("fatal")
+	bindRootContext(config)
+	ctx := rootContext()
+
+	requests := []func() error{
+		func() error {
+			cli, err := alert.NewClient(config)
+			if err == nil {
+				_, err = cli.List(ctx, &alert.ListAlertRequest{Limit: 1})
+			}
+			return err
+		},
+		func() error {
+			cli, err := team.NewClient(config)
+			if err == nil {
+				_, err = cli.List(ctx, &team.ListTeamRequest{})
+			}
+			return err
+		},
+		func() error {
+			cli, err := logs.NewClient(config)
+			if err == nil {
+				// the files are listed after the marker, a marker of now lists none
+				_, err = cli.ListLogFiles(ctx, &logs.ListLogFilesRequest{Marker: time.Now().UTC().Format("2006-01-02-15-04-05"), Limit: 1})
+			}
+			return err
+		},
+	}
+	for i, request := range requests {
+		err := request()
+		apiErr, isAPIErr := err.(*client.ApiError)
+		switch {
+		case err == nil:
+			d.report(families[i], checkPass, "API key can access the "+families[i])
+		case isAPIErr && (apiErr.StatusCode == http.StatusForbidden || apiErr.StatusCode == http.StatusUnauthorized):
+			d.report(families[i], checkWarn, "API key can not access the "+families[i]+": "+apiErr.Message)
+		default:
+			d.report(families[i], checkFail, err.Error())
+		}
+	}
+}
+
+func (d *doctor) checkLogPath() {
+	logPath := cfg.Get("logPath")
+	if logPath == "" {
+		d.report("Log path", checkSkip, "logPath is not set, logging to file is disabled")
+		return
+	}
+	file, err := ioutil.TempFile(logPath, ".lamp-doctor")
+	if err != nil {
+		d.report("Log path", checkFail, logPath+" is not writable: "+err.Error())
+		return
+	}
+	file.Close()
+	os.Remove(file.Name())
+	d.report("Log path", checkPass, logPath+" is writable")
+}
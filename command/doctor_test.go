@@ -0,0 +1,90 @@
+package command
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opsgenie/opsgenie-lamp/mockserver"
+)
+
+// runDoctor runs the doctor command with the configuration file and returns the status of each check.
+func runDoctor(t *testing.T, confPath string, profile string) (map[string]string, error) {
+	t.Helper()
+	c := newSettingsContext(t, map[string]string{"config": confPath, "profile": profile, "output-format": "text"})
+	var output bytes.Buffer
+	c.App.Writer = &output
+
+	batchMode = true
+	defer func() { batchMode = false }()
+	err := catchExit(func() { DoctorAction(c) })
+
+	statuses := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		// e.g. [PASS] API key: API key is valid
+		status := strings.ToLower(strings.Trim(strings.SplitN(line, " ", 2)[0], "[]"))
+		check := strings.SplitN(strings.SplitN(line, "] ", 2)[1], ": ", 2)[0]
+		statuses[check] = status
+	}
+	return statuses, err
+}
+
+func TestDoctorAction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-doctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer unloadConfigFile(dir)
+
+	server := httptest.NewServer(mockserver.New(mockserver.Options{APIKey: "valid"}))
+	defer server.Close()
+	confPath := filepath.Join(dir, "lamp.conf")
+	ioutil.WriteFile(confPath, []byte("apiKey=valid\napiUrl="+strings.TrimPrefix(server.URL, "http://")+"\nlogPath="+dir+
+		"\n\n[profile wrong]\napiKey=wrong\nlogPath="+filepath.Join(dir, "missing")+"\n"), 0644)
+
+	statuses, err := runDoctor(t, confPath, "")
+	if err != nil {
+		t.Fatalf("doctor exited with %v, checks are %v", err, statuses)
+	}
+	want := map[string]string{
+		"Configuration file":             checkPass,
+		"Configuration file permissions": checkWarn,
+		"Profile":                        checkPass,
+		"Configuration keys":             checkPass,
+		"API URL":                        checkPass,
+		"Proxy":                          checkPass,
+		"DNS":                            checkPass,
+		"TLS":                            checkSkip,
+		"API request":                    checkPass,
+		"API key":                        checkPass,
+		"Clock skew":                     checkPass,
+		"Alert API":                      checkPass,
+		"Configuration API":              checkPass,
+		"Logs API":                       checkPass,
+		"Log path":                       checkPass,
+	}
+	for check, status := range want {
+		if statuses[check] != status {
+			t.Errorf("%s check is %q, want %q", check, statuses[check], status)
+		}
+	}
+
+	// a wrong key fails the command and skips the checks needing a valid key
+	statuses, err = runDoctor(t, confPath, "wrong")
+	if exitCode(err) != ExitCodeError {
+		t.Errorf("doctor with a wrong key exited with %v", err)
+	}
+	if statuses["API key"] != checkFail || statuses["Alert API"] != checkSkip || statuses["Log path"] != checkFail {
+		t.Errorf("checks with a wrong key are %v", statuses)
+	}
+
+	statuses, err = runDoctor(t, confPath, "missing")
+	if statuses["Profile"] != checkFail || len(statuses) != 3 || err == nil {
+		t.Errorf("checks with an unknown profile are %v, want the checks to stop at the profile", statuses)
+	}
+}
@@ -0,0 +1,96 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	gcli "github.com/urfave/cli"
+)
+
+// errDryRun is returned by the dry run transport instead of sending the request to Opsgenie.
+var errDryRun = errors.New("request is not sent to Opsgenie in dry run mode")
+
+// dryRunMode is set while the configuration of a command enables the dry run.
+var dryRunMode bool
+
+// dryRunTransport prints the requests built by the SDK instead of sending them.
+type dryRunTransport struct {
+	writer io.Writer
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fmt.Fprintln(t.writer, req.Method+" "+req.URL.String())
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(body) != 0 {
+			fmt.Fprintln(t.writer, formatRequestBody(req.Header.Get("Content-Type"), body))
+		}
+	}
+	return nil, errDryRun
+}
+
+func formatRequestBody(contentType string, body []byte) string {
+	if strings.HasPrefix(contentType, "multipart/") {
+		return "<" + contentType + " body of " + strconv.Itoa(len(body)) + " bytes>"
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, bytes.TrimSpace(body), "", "    "); err != nil {
+		return string(body)
+	}
+	return indented.String()
+}
+
+func isDryRun(c *gcli.Context) bool {
+	return c.IsSet("dry-run")
+}
+
+/*
+configureDryRun makes the SDK print the method, URL and body of the requests instead of sending
+them. The SDK validates the request before building it, so an invalid request still fails with
+its validation error, while a valid one ends with errDryRun and the command exits successfully.
+*/
+func configureDryRun(c *gcli.Context, config *client.Config) {
+	printMessage(DEBUG, "Dry run mode is enabled, requests will be printed and not sent to Opsgenie.")
+	writer := c.App.Writer
+	if batchMode {
+		// the standard output of a batch is kept for the results of its lines
+		writer = os.Stderr
+	}
+	config.HttpClient = &http.Client{Transport: &dryRunTransport{writer: writer}}
+	config.RetryPolicy = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		return false, err
+	}
+}
+
+func isDryRunErr(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	return err == errDryRun
+}
+
+/*
+dryRunValidationError maps the unexpected errors of a dry run to ExitCodeUsage. No request is sent in dry run
+mode, so such an error is the validation error of the SDK rejecting the values given to the command.
+*/
+func dryRunValidationError(err error) error {
+	if !dryRunMode || isDryRunErr(err) || exitCode(err) != ExitCodeError {
+		return err
+	}
+	return newError(ExitCodeUsage, err.Error())
+}
@@ -0,0 +1,97 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/sirupsen/logrus"
+	gcli "github.com/urfave/cli"
+)
+
+// newDryRunAlertClient returns an alert client configured by configureDryRun, printing the requests to output.
+func newDryRunAlertClient(t *testing.T, output *bytes.Buffer) *alert.Client {
+	t.Helper()
+	app := gcli.NewApp()
+	app.Writer = output
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	config := &client.Config{ApiKey: "key", Logger: logger}
+	configureDryRun(gcli.NewContext(app, flag.NewFlagSet("test", flag.ContinueOnError), nil), config)
+	alertClient, err := alert.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return alertClient
+}
+
+func TestDryRunPrintsTheRequest(t *testing.T) {
+	var output bytes.Buffer
+	alertClient := newDryRunAlertClient(t, &output)
+
+	_, err := alertClient.Create(context.Background(), &alert.CreateAlertRequest{Message: "Disk is full", Priority: alert.P2})
+	if !isDryRunErr(err) {
+		t.Fatalf("Create returned %v, want the dry run error", err)
+	}
+	want := "POST https://api.opsgenie.com/v2/alerts\n{\n    \"message\": \"Disk is full\",\n    \"priority\": \"P2\"\n}\n"
+	if output.String() != want {
+		t.Errorf("printed\n%s\nwant\n%s", output.String(), want)
+	}
+
+	output.Reset()
+	_, err = alertClient.Close(context.Background(), &alert.CloseAlertRequest{IdentifierType: alert.ALIAS, IdentifierValue: "disk-full"})
+	if !isDryRunErr(err) {
+		t.Fatalf("Close returned %v, want the dry run error", err)
+	}
+	if want := "POST https://api.opsgenie.com/v2/alerts/disk-full/close?identifierType=alias\n"; !strings.HasPrefix(output.String(), want) {
+		t.Errorf("printed %q, want the request line %q", output.String(), want)
+	}
+}
+
+func TestDryRunValidatesTheRequest(t *testing.T) {
+	var output bytes.Buffer
+	alertClient := newDryRunAlertClient(t, &output)
+
+	_, err := alertClient.Create(context.Background(), &alert.CreateAlertRequest{})
+	if err == nil || isDryRunErr(err) {
+		t.Fatalf("Create of an alert without a message returned %v, want the validation error", err)
+	}
+	if output.Len() != 0 {
+		t.Errorf("printed %q for an invalid request, want nothing", output.String())
+	}
+
+	batchMode, dryRunMode = true, true
+	defer func() { batchMode, dryRunMode = false, false }()
+	if exited := catchExit(func() { exitOnErr(err) }); exitCode(exited) != ExitCodeUsage || exited.Error() != err.Error() {
+		t.Errorf("invalid dry run exited with %v (code %d), want %q with ExitCodeUsage", exited, exitCode(exited), err)
+	}
+	if exited := catchExit(func() { exitOnErr(errDryRun) }); exited != errDryRun {
+		t.Errorf("valid dry run exited with %v, want the dry run error", exited)
+	}
+	dryRunMode = false
+	if exited := catchExit(func() { exitOnErr(err) }); exitCode(exited) != ExitCodeError {
+		t.Errorf("invalid request exited with code %d out of a dry run, want ExitCodeError", exitCode(exited))
+	}
+}
+
+func TestFormatRequestBody(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{contentType: "application/json", body: `{"note":"Fixed"}`, want: "{\n    \"note\": \"Fixed\"\n}"},
+		{contentType: "application/json", body: "not json", want: "not json"},
+		{contentType: "multipart/form-data; boundary=x", body: "--x\r\n\r\n--x--", want: "<multipart/form-data; boundary=x body of 12 bytes>"},
+	}
+	for _, test := range tests {
+		if got := formatRequestBody(test.contentType, []byte(test.body)); got != test.want {
+			t.Errorf("formatRequestBody(%q, %q) = %q, want %q", test.contentType, test.body, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	gcli "github.com/urfave/cli"
+)
+
+// Exit codes of lamp commands, so that scripts can tell the reason of a failure.
+const (
+	ExitCodeOK            = 0  // the command succeeded
+	ExitCodeError         = 1  // unexpected errors and requests rejected by the SDK before being sent
+	ExitCodeUsage         = 2  // invalid or missing flag values, and requests failing validation in a dry run
+	ExitCodeConfiguration = 3  // invalid configuration, e.g. missing API key or unreadable CA bundle
+	ExitCodeUnauthorized  = 4  // Opsgenie responded 401 or 403, e.g. invalid API key or missing permission
+	ExitCodeNotFound      = 5  // Opsgenie responded 404
+	ExitCodeBadRequest    = 6  // Opsgenie responded with another 4xx status, e.g. 400, 409 or 422
+	ExitCodeRateLimited   = 7  // Opsgenie responded 429 after all retries
+	ExitCodeServerError   = 8  // Opsgenie responded with a 5xx status after all retries
+	ExitCodeNetwork       = 9  // the request could not be sent, e.g. connection refused, DNS or TLS failure
+	ExitCodeTimeout       = 10 // the request or the command timed out
+	ExitCodeInterrupted   = 11 // the command was canceled by SIGINT (Ctrl-C) or SIGTERM
+)
+
+const jsonErrorFormat = "json"
+
+var errorFormat = ""
+
+// lampError is an error detected by lamp itself, carrying the exit code to use.
+type lampError struct {
+	code    int
+	message string
+}
+
+func (e *lampError) Error() string {
+	return e.message
+}
+
+func newError(code int, message string) error {
+	return &lampError{code: code, message: message}
+}
+
+// errorOutput is printed to the standard error when error-format is json.
+type errorOutput struct {
+	Code      int     `json:"code"`
+	Status    int     `json:"status,omitempty"`
+	Message   string  `json:"message"`
+	RequestID string  `json:"requestId,omitempty"`
+	Took      float32 `json:"took,omitempty"`
+}
+
+func grabErrorFormat(c *gcli.Context) {
+	if c.IsSet("error-format") {
+		errorFormat = strings.ToLower(c.String("error-format"))
+	}
+}
+
+// exitCode maps an error returned by the SDK or lamp to one of the exit codes.
+func exitCode(err error) int {
+	switch e := err.(type) {
+	case *lampError:
+		return e.code
+	case *client.ApiError:
+		return statusExitCode(e.StatusCode)
+	}
+	if err == context.DeadlineExceeded {
+		return ExitCodeTimeout
+	}
+	if err == context.Canceled {
+		return ExitCodeInterrupted
+	}
+	if netErr, ok := err.(net.Error); ok {
+		if netErr.Timeout() {
+			return ExitCodeTimeout
+		}
+		return ExitCodeNetwork
+	}
+	return ExitCodeError
+}
+
+func statusExitCode(statusCode int) int {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ExitCodeUnauthorized
+	case statusCode == http.StatusNotFound:
+		return ExitCodeNotFound
+	case statusCode == http.StatusTooManyRequests:
+		return ExitCodeRateLimited
+	case statusCode >= 500:
+		return ExitCodeServerError
+	case statusCode >= 400:
+		return ExitCodeBadRequest
+	default:
+		return ExitCodeError
+	}
+}
+
+// printError prints the error in the format given with the error-format flag.
+func printError(err error) {
+	fields := logFields{"duration": commandDuration()}
+	if apiErr, ok := err.(*client.ApiError); ok {
+		fields["requestId"] = apiErr.RequestId
+		fields["status"] = apiErr.StatusCode
+	}
+	if errorFormat != jsonErrorFormat {
+		lampLog.log(ERROR, err.Error(), fields)
+		return
+	}
+	b, _ := json.Marshal(newErrorOutput(err))
+	fmt.Fprintln(os.Stderr, string(b))
+	lampLog.logFile(ERROR, err.Error(), fields)
+}
+
+func newErrorOutput(err error) *errorOutput {
+	output := &errorOutput{
+		Code:    exitCode(err),
+		Message: err.Error(),
+	}
+	if apiErr, ok := err.(*client.ApiError); ok {
+		output.Status = apiErr.StatusCode
+		output.RequestID = apiErr.RequestId
+		output.Took = apiErr.Took
+		output.Message = apiErr.Message
+		if output.Message == "" {
+			output.Message = http.StatusText(apiErr.StatusCode)
+		}
+	}
+	return output
+}
+
+// exitOnErr prints the error and exits with the exit code of the error, if there is an error.
+// The errors of an interrupted or timed out command exit with ExitCodeInterrupted or ExitCodeTimeout.
+// The error ending a dry run is not a failure, the command exits successfully, while a request failing the validation
+// of a dry run exits with ExitCodeUsage.
+// In batch mode only the line of the batch running the command ends.
+func exitOnErr(err error) {
+	if err != nil && !isDryRunErr(err) {
+		// the failures of the requests canceled by an interruption or the timeout are reported as such
+		if stop := interruption(); stop != nil {
+			err = stop
+		}
+		err = dryRunValidationError(err)
+	}
+	if err != nil && batchMode {
+		panic(&batchExit{err: err})
+	}
+	if isDryRunErr(err) {
+		printSummary("Request is valid, it is not sent to Opsgenie in dry run mode.")
+		os.Exit(ExitCodeOK)
+	}
+	if err != nil {
+		printError(err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitOnUsageErr exits with ExitCodeUsage when a flag has an invalid value.
+func exitOnUsageErr(message string) {
+	exitOnErr(newError(ExitCodeUsage, message))
+}
@@ -0,0 +1,116 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/sirupsen/logrus"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestExitCode(t *testing.T) {
+	refused := &url.Error{Op: "Get", URL: "https://api.opsgenie.com/v2/alerts", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "lamp error", err: newError(ExitCodeConfiguration, "apiKey is not configured"), want: ExitCodeConfiguration},
+		{name: "unauthorized", err: &client.ApiError{StatusCode: http.StatusUnauthorized}, want: ExitCodeUnauthorized},
+		{name: "forbidden", err: &client.ApiError{StatusCode: http.StatusForbidden}, want: ExitCodeUnauthorized},
+		{name: "not found", err: &client.ApiError{StatusCode: http.StatusNotFound}, want: ExitCodeNotFound},
+		{name: "bad request", err: &client.ApiError{StatusCode: http.StatusBadRequest}, want: ExitCodeBadRequest},
+		{name: "conflict", err: &client.ApiError{StatusCode: http.StatusConflict}, want: ExitCodeBadRequest},
+		{name: "unprocessable entity", err: &client.ApiError{StatusCode: http.StatusUnprocessableEntity}, want: ExitCodeBadRequest},
+		{name: "rate limited", err: &client.ApiError{StatusCode: http.StatusTooManyRequests}, want: ExitCodeRateLimited},
+		{name: "server error", err: &client.ApiError{StatusCode: http.StatusBadGateway}, want: ExitCodeServerError},
+		{name: "deadline", err: context.DeadlineExceeded, want: ExitCodeTimeout},
+		{name: "network timeout", err: &url.Error{Op: "Get", URL: "https://api.opsgenie.com", Err: timeoutError{}}, want: ExitCodeTimeout},
+		{name: "connection refused", err: refused, want: ExitCodeNetwork},
+		{name: "other error", err: errors.New("message should be given"), want: ExitCodeError},
+	}
+	for _, test := range tests {
+		if got := exitCode(test.err); got != test.want {
+			t.Errorf("%s: exitCode(%v) = %d, want %d", test.name, test.err, got, test.want)
+		}
+	}
+}
+
+// captureStderr returns what run writes to the standard error.
+func captureStderr(t *testing.T, run func()) string {
+	t.Helper()
+	file, err := ioutil.TempFile("", "lamp-stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	stderr := os.Stderr
+	os.Stderr = file
+	log.SetOutput(file)
+	defer func() {
+		os.Stderr = stderr
+		log.SetOutput(stderr)
+	}()
+	run()
+	content, _ := ioutil.ReadFile(file.Name())
+	return string(content)
+}
+
+func TestPrintJSONError(t *testing.T) {
+	errorFormat = jsonErrorFormat
+	defer func() { errorFormat = "" }()
+
+	output := captureStderr(t, func() {
+		printError(&client.ApiError{StatusCode: http.StatusNotFound, Message: "Alert does not exist", RequestId: "r1", Took: 0.01})
+	})
+	var printed errorOutput
+	if err := json.Unmarshal([]byte(output), &printed); err != nil {
+		t.Fatalf("printed %q, want a JSON object: %v", output, err)
+	}
+	want := errorOutput{Code: ExitCodeNotFound, Status: http.StatusNotFound, Message: "Alert does not exist", RequestID: "r1", Took: 0.01}
+	if printed != want {
+		t.Errorf("printed %+v, want %+v", printed, want)
+	}
+
+	output = captureStderr(t, func() {
+		printError(&client.ApiError{StatusCode: http.StatusServiceUnavailable})
+	})
+	if err := json.Unmarshal([]byte(output), &printed); err != nil || printed.Message != "Service Unavailable" || printed.Code != ExitCodeServerError {
+		t.Errorf("printed %q, want the status text as the message", output)
+	}
+}
+
+func TestJSONErrorFormatKeepsTheStandardErrorParsable(t *testing.T) {
+	errorFormat = jsonErrorFormat
+	defer func() { errorFormat = "" }()
+
+	output := captureStderr(t, func() {
+		printMessage(INFO, "Alert is created")
+		newSDKLogger(logrus.InfoLevel).Errorf("Error occurred with Status code: 404")
+		printError(newError(ExitCodeNotFound, "Alert does not exist"))
+	})
+	var printed errorOutput
+	if err := json.Unmarshal([]byte(output), &printed); err != nil || printed.Message != "Alert does not exist" {
+		t.Errorf("printed %q, want only the JSON error", output)
+	}
+
+	errorFormat = ""
+	if output := captureStderr(t, func() { printMessage(INFO, "Alert is created") }); output == "" {
+		t.Error("info message is not printed with the text error format")
+	}
+}
@@ -0,0 +1,156 @@
+package command
+
+import (
+	"context"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/escalation"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/og"
+)
+
+// EscalationIdentifier identifies an escalation by its name or its ID, IdentifierType is name or id.
+type EscalationIdentifier struct {
+	Identifier     string
+	IdentifierType string
+}
+
+// EscalationRule is a rule of an escalation.
+type EscalationRule struct {
+	// Condition is if-not-acked or if-not-closed.
+	Condition string
+	// NotifyType is next, previous, default, users, admins, random or all.
+	NotifyType string
+	// ParticipantType is user, team, escalation, schedule or none.
+	ParticipantType string
+	ParticipantName string
+	// Delay is the delay of the rule in minutes.
+	Delay uint32
+}
+
+type EscalationRepeat struct {
+	WaitInterval         uint32
+	Count                uint32
+	ResetRecipientStates bool
+	CloseAlertAfterAll   bool
+}
+
+type CreateEscalationOptions struct {
+	Name        string
+	Description string
+	Rules       []EscalationRule
+	// TeamName is the name of the owner team.
+	TeamName string
+	Repeat   EscalationRepeat
+}
+
+func (o CreateEscalationOptions) rules() []escalation.RuleRequest {
+	var rules []escalation.RuleRequest
+	for _, rule := range o.Rules {
+		condition := og.IfNotClosed
+		if rule.Condition == "if-not-acked" {
+			condition = og.IfNotAcked
+		}
+		notifyType := og.All
+		switch rule.NotifyType {
+		case "next":
+			notifyType = og.Next
+		case "previous":
+			notifyType = og.Previous
+		case "default":
+			notifyType = og.Default
+		case "users":
+			notifyType = og.Users
+		case "admins":
+			notifyType = og.Admins
+		case "random":
+			notifyType = og.Random
+		}
+		rules = append(rules, escalation.RuleRequest{
+			Condition:  condition,
+			NotifyType: notifyType,
+			Recipient: og.Participant{
+				Type: og.ParticipantType(rule.ParticipantType),
+				Name: rule.ParticipantName,
+			},
+			Delay: escalation.EscalationDelayRequest{
+				TimeAmount: rule.Delay,
+			},
+		})
+	}
+	return rules
+}
+
+func (o CreateEscalationOptions) repeat() *escalation.RepeatRequest {
+	resetRecipientStates, closeAlertAfterAll := o.Repeat.ResetRecipientStates, o.Repeat.CloseAlertAfterAll
+	return &escalation.RepeatRequest{
+		WaitInterval:         o.Repeat.WaitInterval,
+		Count:                o.Repeat.Count,
+		ResetRecipientStates: &resetRecipientStates,
+		CloseAlertAfterAll:   &closeAlertAfterAll,
+	}
+}
+
+func (o CreateEscalationOptions) ownerTeam() *og.OwnerTeam {
+	if o.TeamName == "" {
+		return nil
+	}
+	return &og.OwnerTeam{Name: o.TeamName}
+}
+
+// CreateEscalation creates an escalation at Opsgenie.
+func (a *API) CreateEscalation(ctx context.Context, opts CreateEscalationOptions) (*escalation.CreateResult, error) {
+	cli, release, err := a.escalationClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Create(ctx, &escalation.CreateRequest{
+		Name:        opts.Name,
+		Description: opts.Description,
+		Rules:       opts.rules(),
+		OwnerTeam:   opts.ownerTeam(),
+		Repeat:      opts.repeat(),
+	})
+}
+
+// GetEscalation retrieves an escalation from Opsgenie.
+func (a *API) GetEscalation(ctx context.Context, opts EscalationIdentifier) (*escalation.GetResult, error) {
+	cli, release, err := a.escalationClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Get(ctx, &escalation.GetRequest{IdentifierType: escalation.Identifier(opts.IdentifierType), Identifier: opts.Identifier})
+}
+
+type UpdateEscalationOptions struct {
+	EscalationIdentifier
+	CreateEscalationOptions
+}
+
+// UpdateEscalation updates an escalation at Opsgenie.
+func (a *API) UpdateEscalation(ctx context.Context, opts UpdateEscalationOptions) (*escalation.UpdateResult, error) {
+	cli, release, err := a.escalationClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Update(ctx, &escalation.UpdateRequest{
+		IdentifierType: escalation.Identifier(opts.IdentifierType),
+		Identifier:     opts.Identifier,
+		Name:           opts.Name,
+		Description:    opts.Description,
+		Rules:          opts.rules(),
+		OwnerTeam:      opts.ownerTeam(),
+		Repeat:         opts.repeat(),
+	})
+}
+
+// DeleteEscalation deletes an escalation at Opsgenie.
+func (a *API) DeleteEscalation(ctx context.Context, opts EscalationIdentifier) (*escalation.DeleteResult, error) {
+	cli, release, err := a.escalationClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Delete(ctx, &escalation.DeleteRequest{IdentifierType: escalation.Identifier(opts.IdentifierType), Identifier: opts.Identifier})
+}
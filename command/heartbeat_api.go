@@ -0,0 +1,97 @@
+package command
+
+import (
+	"context"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/heartbeat"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/og"
+)
+
+// HeartbeatIdentifier identifies a heartbeat by its name.
+type HeartbeatIdentifier struct {
+	Name string
+}
+
+// PingHeartbeat sends a heartbeat signal to Opsgenie.
+func (a *API) PingHeartbeat(ctx context.Context, opts HeartbeatIdentifier) (*heartbeat.PingResult, error) {
+	cli, release, err := a.heartbeatClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Ping(ctx, opts.Name)
+}
+
+type CreateHeartbeatOptions struct {
+	Name          string
+	Description   string
+	AlertMessage  string
+	AlertTags     []string
+	AlertPriority string
+	OwnerTeam     string
+	Interval      int
+	// IntervalUnit is minutes, hours or days.
+	IntervalUnit string
+	Enabled      bool
+}
+
+// CreateHeartbeat creates a heartbeat at Opsgenie.
+func (a *API) CreateHeartbeat(ctx context.Context, opts CreateHeartbeatOptions) (*heartbeat.AddResult, error) {
+	cli, release, err := a.heartbeatClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	enabled := opts.Enabled
+	return cli.Add(ctx, &heartbeat.AddRequest{
+		Name:          opts.Name,
+		Description:   opts.Description,
+		Interval:      opts.Interval,
+		IntervalUnit:  heartbeat.Unit(opts.IntervalUnit),
+		Enabled:       &enabled,
+		OwnerTeam:     og.OwnerTeam{Name: opts.OwnerTeam},
+		AlertMessage:  opts.AlertMessage,
+		AlertTag:      opts.AlertTags,
+		AlertPriority: opts.AlertPriority,
+	})
+}
+
+// DeleteHeartbeat deletes a heartbeat at Opsgenie.
+func (a *API) DeleteHeartbeat(ctx context.Context, opts HeartbeatIdentifier) (*heartbeat.DeleteResult, error) {
+	cli, release, err := a.heartbeatClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Delete(ctx, opts.Name)
+}
+
+// DisableHeartbeat disables a heartbeat at Opsgenie.
+func (a *API) DisableHeartbeat(ctx context.Context, opts HeartbeatIdentifier) (*heartbeat.HeartbeatInfo, error) {
+	cli, release, err := a.heartbeatClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Disable(ctx, opts.Name)
+}
+
+// EnableHeartbeat enables a heartbeat at Opsgenie.
+func (a *API) EnableHeartbeat(ctx context.Context, opts HeartbeatIdentifier) (*heartbeat.HeartbeatInfo, error) {
+	cli, release, err := a.heartbeatClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Enable(ctx, opts.Name)
+}
+
+// ListHeartbeat retrieves the heartbeats from Opsgenie.
+func (a *API) ListHeartbeat(ctx context.Context) (*heartbeat.ListResult, error) {
+	cli, release, err := a.heartbeatClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.List(ctx)
+}
@@ -1,210 +1,103 @@
 package command
 
 import (
-	"errors"
+	"strconv"
+
 	"github.com/opsgenie/opsgenie-go-sdk-v2/heartbeat"
-	"github.com/opsgenie/opsgenie-go-sdk-v2/og"
 	gcli "github.com/urfave/cli"
-	"os"
-	"strconv"
-	"strings"
 )
 
 func NewHeartbeatClient(c *gcli.Context) (*heartbeat.Client, error) {
-	heartbeatCli, cliErr := heartbeat.NewClient(getConfigurations(c))
-	if cliErr != nil {
-		message := "Can not create the heartbeat client. " + cliErr.Error()
-		printMessage(INFO, message)
-		return nil, errors.New(message)
+	cli, _, err := cliAPI(c).heartbeatClient(rootContext())
+	if err != nil {
+		return nil, err
 	}
-	printMessage(DEBUG,"Heartbeat Client created.")
-	return heartbeatCli, nil
+	printMessage(DEBUG, "Heartbeat Client created.")
+	return cli, nil
+}
+
+func grabHeartbeatIdentifier(c *gcli.Context) HeartbeatIdentifier {
+	opts := HeartbeatIdentifier{}
+	opts.Name, _ = getVal("name", c)
+	return opts
 }
 
 // HeartbeatAction sends an Heartbeat signal to Opsgenie.
 func PingHeartbeatAction(c *gcli.Context) {
-	cli, err := NewHeartbeatClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
+	printMessage(DEBUG, "Heartbeat request prepared from flags, sending request to Opsgenie..")
 
-	var name string
-	if val, success := getVal("name", c); success {
-		name = val
-	}
-
-	printMessage(DEBUG,"Heartbeat request prepared from flags, sending request to Opsgenie..")
-
-	response, err := cli.Ping(nil, name)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Ping request has received. RequestID: " + response.RequestId)
+	response, err := cliAPI(c).PingHeartbeat(rootContext(), grabHeartbeatIdentifier(c))
+	exitOnErr(err)
+	printMessage(DEBUG, "Ping request has received. RequestID: "+response.RequestId)
 }
 
 func CreateHeartbeatAction(c *gcli.Context) {
-	cli, err := NewHeartbeatClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	addRequest := heartbeat.AddRequest{}
-
-	if val, success := getVal("name", c); success {
-		addRequest.Name = val
-	}
-
-	if val, success := getVal("description", c); success {
-		addRequest.Description = val
-	}
-
-	if val, success := getVal("alertMessage", c); success {
-		addRequest.AlertMessage = val
-	}
-
-	if val, success := getVal("alertTag", c); success {
-		addRequest.AlertTag = strings.Split(val, ",")
-	}
-
-	if val, success := getVal("alertPriority", c); success {
-		addRequest.AlertPriority = val
-	}
-
-	if val, success := getVal("ownerTeam", c); success {
-		addRequest.OwnerTeam = og.OwnerTeam{
-			Name: val,
-		}
-	}
+	opts := CreateHeartbeatOptions{AlertTags: grabList("alertTag", c), Enabled: c.IsSet("enabled")}
+	opts.Name, _ = getVal("name", c)
+	opts.Description, _ = getVal("description", c)
+	opts.AlertMessage, _ = getVal("alertMessage", c)
+	opts.AlertPriority, _ = getVal("alertPriority", c)
+	opts.OwnerTeam, _ = getVal("ownerTeam", c)
 
 	if val, success := getVal("interval", c); success {
-		addRequest.Interval, err = strconv.Atoi(val)
-
+		interval, err := strconv.Atoi(val)
 		if err != nil {
-			printMessage(ERROR, "Please provide a valid integer for interval.")
-			os.Exit(1)
+			exitOnUsageErr("Please provide a valid integer for interval.")
 		}
+		opts.Interval = interval
 	}
 
 	if val, success := getVal("intervalType", c); success {
-		if val == "m" {
-			addRequest.IntervalUnit = heartbeat.Minutes
-		} else if val == "h" {
-			addRequest.IntervalUnit = heartbeat.Hours
-		} else if val == "d" {
-			addRequest.IntervalUnit = heartbeat.Days
-		} else {
-			printMessage(ERROR, "Please provide a valid interval unit.")
-			os.Exit(1)
+		switch val {
+		case "m":
+			opts.IntervalUnit = string(heartbeat.Minutes)
+		case "h":
+			opts.IntervalUnit = string(heartbeat.Hours)
+		case "d":
+			opts.IntervalUnit = string(heartbeat.Days)
+		default:
+			exitOnUsageErr("Please provide a valid interval unit.")
 		}
 	}
 
-	enabled := c.IsSet("enabled")
-	addRequest.Enabled = &enabled
-
 	printMessage(DEBUG, "Heartbeat create request created from flags. Sedning to Opsgenie...")
 
-	response, err := cli.Add(nil, &addRequest)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Heartbeat will be created " + response.RequestId)
+	response, err := cliAPI(c).CreateHeartbeat(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "Heartbeat will be created "+response.RequestId)
 }
 
 func DeleteHeartbeatAction(c *gcli.Context) {
-	cli, err := NewHeartbeatClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	var name string
-	if val, success := getVal("name", c); success {
-		name = val
-	}
-
 	printMessage(DEBUG, "Heartbeat delete request created from flags. Sending to Opsgenie...")
 
-	response, err := cli.Delete(nil, name)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Heartbeat will be deleted.")
-	printMessage(INFO, response.RequestId)
+	response, err := cliAPI(c).DeleteHeartbeat(rootContext(), grabHeartbeatIdentifier(c))
+	exitOnErr(err)
+	printMessage(DEBUG, "Heartbeat will be deleted.")
+	printResultMessage(c, response.RequestId)
 }
 
 func DisableHeartbeatAction(c *gcli.Context) {
-	cli, err := NewHeartbeatClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	var name string
-	if val, success := getVal("name", c); success {
-		name = val
-	}
-
 	printMessage(DEBUG, "Heartbeat disable request created from flags. Sending to Opsgenie...")
 
-	response, err := cli.Disable(nil, name)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Heartbeat will be disabled.")
-	printMessage(INFO, response.RequestId)
+	response, err := cliAPI(c).DisableHeartbeat(rootContext(), grabHeartbeatIdentifier(c))
+	exitOnErr(err)
+	printMessage(DEBUG, "Heartbeat will be disabled.")
+	printResultMessage(c, response.RequestId)
 }
 
 func EnableHeartbeatAction(c *gcli.Context) {
-	cli, err := NewHeartbeatClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	var name string
-	if val, success := getVal("name", c); success {
-		name = val
-	}
-
 	printMessage(DEBUG, "Heartbeat enable request created from flags. Sending to Opsgenie...")
 
-	response, err := cli.Enable(nil, name)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-	}
+	response, err := cliAPI(c).EnableHeartbeat(rootContext(), grabHeartbeatIdentifier(c))
+	exitOnErr(err)
 	printMessage(DEBUG, "Heartbeat will be enabled")
-	printMessage(INFO, response.RequestId)
+	printResultMessage(c, response.RequestId)
 }
 
 func ListHeartbeatAction(c *gcli.Context) {
-	cli, err := NewHeartbeatClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
+	response, err := cliAPI(c).ListHeartbeat(rootContext())
+	exitOnErr(err)
 
-	response, err := cli.List(nil)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-	}
-
-	outputFormat := strings.ToLower(c.String("output-format"))
-	printMessage(DEBUG,"Heartbeats listed successfully, and will print as " + outputFormat)
-	switch outputFormat {
-	case "yaml":
-		output, err := resultToYAML(response.Heartbeats)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	default:
-		isPretty := c.IsSet("pretty")
-		output, err := resultToJSON(response.Heartbeats, isPretty)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, output)
-	}
+	printMessage(DEBUG, "Heartbeats listed successfully.")
+	renderResult(c, response.Heartbeats)
 }
@@ -0,0 +1,283 @@
+package command
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-lamp/cfg"
+	gcli "github.com/urfave/cli"
+)
+
+const (
+	defaultConnectionTimeout  = 30 * time.Second
+	defaultKeepAlive          = 30 * time.Second
+	defaultMaxIdleConnections = 100
+	defaultIdleConnTimeout    = 90 * time.Second
+	defaultRetryWaitMin       = time.Second
+	defaultRetryWaitMax       = 30 * time.Second
+)
+
+// grabSetting returns the value of the flag with the given name if it is set, otherwise the value
+// of the configuration key with the same name.
+func grabSetting(name string, c *gcli.Context) string {
+	if val, success := getVal(name, c); success {
+		return val
+	}
+	return cfg.Get(name)
+}
+
+/*
+grabDuration reads a duration setting. Plain numbers are seconds like requestTimeout,
+Go durations such as 500ms or 1m30s are accepted as well.
+*/
+func grabDuration(name string, c *gcli.Context) (time.Duration, bool) {
+	val := grabSetting(name, c)
+	if val == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(val); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	duration, err := time.ParseDuration(val)
+	if err != nil || duration < 0 {
+		exitOnErr(newError(ExitCodeConfiguration, "Invalid "+name+" value "+val+". Give the number of seconds or a duration such as 500ms."))
+	}
+	return duration, true
+}
+
+// grabInt reads a setting that is 0 or a positive number, what 0 means depends on the setting, e.g. no retries for retryCount.
+func grabInt(name string, c *gcli.Context) (int, bool) {
+	val := grabSetting(name, c)
+	if val == "" {
+		return 0, false
+	}
+	number, err := strconv.Atoi(val)
+	if err != nil || number < 0 {
+		exitOnErr(newError(ExitCodeConfiguration, "Invalid "+name+" value "+val+". It should be 0 or a positive number."))
+	}
+	return number, true
+}
+
+// configureRetries sets the retry count, backoff and retry policy of the SDK from the retry settings.
+func configureRetries(c *gcli.Context, config *client.Config) {
+	retryCount, retryCountSet := grabInt("retryCount", c)
+	if retryCountSet {
+		config.RetryCount = retryCount
+		printMessage(DEBUG, "Will retry failed requests "+strconv.Itoa(retryCount)+" times.")
+	}
+
+	waitMin, waitMinSet := grabDuration("retryWaitMin", c)
+	waitMax, waitMaxSet := grabDuration("retryWaitMax", c)
+	if waitMinSet || waitMaxSet {
+		// a bound given alone moves the default of the other one
+		switch {
+		case !waitMinSet:
+			waitMin = defaultRetryWaitMin
+			if waitMax < waitMin {
+				waitMin = waitMax
+			}
+		case !waitMaxSet:
+			waitMax = defaultRetryWaitMax
+			if waitMax < waitMin {
+				waitMax = waitMin
+			}
+		case waitMax < waitMin:
+			exitOnErr(newError(ExitCodeConfiguration, "retryWaitMax "+waitMax.String()+" should not be less than retryWaitMin "+waitMin.String()))
+		}
+		printMessage(DEBUG, "Will wait between "+waitMin.String()+" and "+waitMax.String()+" before retrying failed requests.")
+		config.Backoff = func(_, _ time.Duration, attemptNum int, resp *http.Response) time.Duration {
+			return retryablehttp.DefaultBackoff(waitMin, waitMax, attemptNum, resp)
+		}
+	}
+
+	retryOnStatus := grabSetting("retryOnStatus", c)
+	if retryCountSet && retryCount == 0 {
+		// the SDK falls back to its default retry count when RetryCount is 0, so retries are disabled with the policy
+		config.RetryPolicy = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+			return false, err
+		}
+	} else if retryOnStatus != "" {
+		statuses := strings.Split(retryOnStatus, ",")
+		printMessage(DEBUG, "Will retry requests failing with status "+retryOnStatus)
+		config.RetryPolicy = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			if err != nil {
+				return true, err
+			}
+			return matchesStatus(resp.StatusCode, statuses), nil
+		}
+	}
+}
+
+// defaultRetryPolicy is the retry policy of the SDK, retrying errors, 429 and 5xx responses except 501.
+func defaultRetryPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err != nil {
+		return true, err
+	}
+	if resp.StatusCode == 0 || resp.StatusCode == http.StatusTooManyRequests {
+		return true, nil
+	}
+	return resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented, nil
+}
+
+// matchesStatus checks the status code against a list of codes such as 429 or status classes such as 5xx.
+func matchesStatus(statusCode int, statuses []string) bool {
+	code := strconv.Itoa(statusCode)
+	for _, status := range statuses {
+		status = strings.ToLower(strings.TrimSpace(status))
+		if status == code || (len(status) == 3 && strings.HasSuffix(status, "xx") && status[0] == code[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// newHTTPClient creates the HTTP client used by the SDK, with the connection and proxy settings of lamp.
+func newHTTPClient(c *gcli.Context) *http.Client {
+	connectionTimeout, ok := grabDuration("connectionTimeout", c)
+	if !ok {
+		connectionTimeout = defaultConnectionTimeout
+	}
+	keepAlive, keepAliveSet := grabDuration("keepAlive", c)
+	if !keepAliveSet {
+		keepAlive = defaultKeepAlive
+	}
+	maxIdleConnections, ok := grabInt("maxIdleConnections", c)
+	if !ok {
+		maxIdleConnections = defaultMaxIdleConnections
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   connectionTimeout,
+		KeepAlive: keepAlive,
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          maxIdleConnections,
+		MaxIdleConnsPerHost:   maxIdleConnections,
+		IdleConnTimeout:       defaultIdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if keepAliveSet && keepAlive == 0 {
+		dialer.KeepAlive = -1
+		transport.DisableKeepAlives = true
+		printMessage(DEBUG, "Keep-alive is disabled.")
+	}
+	if proxyURL := grabProxyURL(); proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	} else {
+		printMessage(DEBUG, "proxyHost is not configured, will use HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables if set.")
+	}
+	transport.TLSClientConfig = grabTLSConfig()
+	printMessage(DEBUG, "HTTP client is configured with connectionTimeout: "+connectionTimeout.String()+
+		", keepAlive: "+keepAlive.String()+", maxIdleConnections: "+strconv.Itoa(maxIdleConnections))
+
+	return &http.Client{Transport: transport}
+}
+
+// downloadClient returns the HTTP client of the configuration without the transports lamp adds for the Opsgenie requests,
+// files are downloaded with the connection, proxy and TLS settings but are not journaled or rate limited.
+func downloadClient(config *client.Config) *http.Client {
+	httpClient := *config.HttpClient
+	for {
+		switch transport := httpClient.Transport.(type) {
+		case *contextTransport:
+			httpClient.Transport = transport.next
+		case *journalTransport:
+			httpClient.Transport = transport.next
+		case *rateLimitTransport:
+			httpClient.Transport = transport.next
+		default:
+			return &httpClient
+		}
+	}
+}
+
+// grabProxyURL builds the proxy URL from the proxy configuration keys, it returns nil if proxyHost is not set.
+func grabProxyURL() *url.URL {
+	proxyHost := cfg.Get("proxyHost")
+	if proxyHost == "" {
+		return nil
+	}
+	printMessage(DEBUG, "Configuring proxy settings with host "+proxyHost)
+	if proxyPort := cfg.Get("proxyPort"); proxyPort != "" {
+		if _, err := strconv.Atoi(proxyPort); err != nil {
+			printMessage(DEBUG, "Invalid proxy port.")
+		} else {
+			proxyHost = proxyHost + ":" + proxyPort
+		}
+	}
+	proxyURL := &url.URL{
+		Host:   proxyHost,
+		Scheme: string(proxyProtocol(cfg.Get("proxyProtocol"))),
+	}
+	if username := cfg.Get("proxyUsername"); username != "" {
+		proxyURL.User = url.UserPassword(username, cfg.Get("proxyPassword"))
+	}
+	return proxyURL
+}
+
+// grabTLSConfig builds the TLS configuration from the caBundle, clientCert, clientKey and insecureSkipVerify keys.
+func grabTLSConfig() *tls.Config {
+	tlsConfig := &tls.Config{}
+
+	if caBundle := cfg.Get("caBundle"); caBundle != "" {
+		pem, err := ioutil.ReadFile(caBundle)
+		if err != nil {
+			exitOnErr(newError(ExitCodeConfiguration, "Can not read the CA bundle. "+err.Error()))
+		}
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			exitOnErr(newError(ExitCodeConfiguration, "Could not find any PEM encoded certificate in the CA bundle "+caBundle))
+		}
+		printMessage(DEBUG, "Added the certificates in "+caBundle+" to the trusted certificates.")
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	clientCert := cfg.Get("clientCert")
+	clientKey := cfg.Get("clientKey")
+	if clientCert != "" || clientKey != "" {
+		if clientCert == "" || clientKey == "" {
+			exitOnErr(newError(ExitCodeConfiguration, "Both clientCert and clientKey should be configured to use a client certificate."))
+		}
+		certificate, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			exitOnErr(newError(ExitCodeConfiguration, "Can not load the client certificate. "+err.Error()))
+		}
+		printMessage(DEBUG, "Will authenticate with the client certificate "+clientCert)
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if val := cfg.Get("insecureSkipVerify"); val != "" {
+		insecure, err := strconv.ParseBool(val)
+		if err != nil {
+			exitOnErr(newError(ExitCodeConfiguration, "Invalid insecureSkipVerify value "+val+". It should be true or false."))
+		}
+		if insecure {
+			printMessage(WARN, "!!! insecureSkipVerify is enabled, TLS certificates of Opsgenie and the proxy are NOT verified."+
+				" Connections can be intercepted, use caBundle to trust your proxy certificate instead !!!")
+			tlsConfig.InsecureSkipVerify = true
+		}
+	}
+	return tlsConfig
+}
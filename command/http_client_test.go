@@ -0,0 +1,292 @@
+package command
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-lamp/cfg"
+	gcli "github.com/urfave/cli"
+)
+
+// newSettingsContext returns a context with the given settings set as flags.
+func newSettingsContext(t *testing.T, settings map[string]string) *gcli.Context {
+	t.Helper()
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	var args []string
+	for name, value := range settings {
+		set.String(name, "", "")
+		args = append(args, "--"+name, value)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return gcli.NewContext(gcli.NewApp(), set, nil)
+}
+
+func TestConfigureRetriesBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]string
+		// want are the waits before the first retries, nil when the SDK backoff is kept
+		want []time.Duration
+	}{
+		{name: "sdk backoff", settings: map[string]string{}},
+		{
+			name:     "min and max",
+			settings: map[string]string{"retryWaitMin": "500ms", "retryWaitMax": "3s"},
+			want:     []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:     "seconds",
+			settings: map[string]string{"retryWaitMin": "2", "retryWaitMax": "5"},
+			want:     []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second},
+		},
+		{
+			name:     "default max",
+			settings: map[string]string{"retryWaitMin": "10s"},
+			want:     []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second},
+		},
+		{
+			name:     "max below the default min",
+			settings: map[string]string{"retryWaitMax": "200ms"},
+			want:     []time.Duration{200 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			name:     "min above the default max",
+			settings: map[string]string{"retryWaitMin": "1m"},
+			want:     []time.Duration{time.Minute, time.Minute},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &client.Config{}
+			configureRetries(newSettingsContext(t, test.settings), config)
+			if test.want == nil {
+				if config.Backoff != nil {
+					t.Error("Backoff is set, want the backoff of the SDK")
+				}
+				return
+			}
+			for attempt, want := range test.want {
+				if wait := config.Backoff(0, 0, attempt, nil); wait != want {
+					t.Errorf("wait before retry %d is %s, want %s", attempt+1, wait, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigureRetriesRejectsInconsistentWaits(t *testing.T) {
+	batchMode = true
+	defer func() { batchMode = false }()
+	err := catchExit(func() {
+		configureRetries(newSettingsContext(t, map[string]string{"retryWaitMin": "5s", "retryWaitMax": "1s"}), &client.Config{})
+	})
+	if exitCode(err) != ExitCodeConfiguration {
+		t.Errorf("configureRetries exited with %v, want a configuration error", err)
+	}
+}
+
+func TestConfigureRetriesPolicy(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	networkErr := errors.New("connection refused")
+
+	type attempt struct {
+		ctx    context.Context
+		status int
+		err    error
+		retry  bool
+	}
+	tests := []struct {
+		name     string
+		settings map[string]string
+		attempts []attempt
+	}{
+		{
+			name:     "retry on the given statuses",
+			settings: map[string]string{"retryOnStatus": "429, 5xx"},
+			attempts: []attempt{
+				{status: http.StatusTooManyRequests, retry: true},
+				{status: http.StatusServiceUnavailable, retry: true},
+				{status: http.StatusInternalServerError, retry: true},
+				{status: http.StatusNotFound, retry: false},
+				{status: http.StatusOK, retry: false},
+				{err: networkErr, retry: true},
+				{ctx: canceled, status: http.StatusServiceUnavailable, retry: false},
+			},
+		},
+		{
+			name:     "no retries",
+			settings: map[string]string{"retryCount": "0", "retryOnStatus": "5xx"},
+			attempts: []attempt{
+				{status: http.StatusServiceUnavailable, retry: false},
+				{err: networkErr, retry: false},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &client.Config{}
+			configureRetries(newSettingsContext(t, test.settings), config)
+			if config.RetryPolicy == nil {
+				t.Fatal("RetryPolicy is not set")
+			}
+			for _, a := range test.attempts {
+				ctx := a.ctx
+				if ctx == nil {
+					ctx = context.Background()
+				}
+				var resp *http.Response
+				if a.err == nil {
+					resp = &http.Response{StatusCode: a.status}
+				}
+				if retry, _ := config.RetryPolicy(ctx, resp, a.err); retry != a.retry {
+					t.Errorf("retry of status %d, error %v is %v, want %v", a.status, a.err, retry, a.retry)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchesStatus(t *testing.T) {
+	statuses := []string{"429", " 5XX", "40"}
+	for code, want := range map[int]bool{429: true, 500: true, 503: true, 599: true, 400: false, 404: false, 200: false} {
+		if got := matchesStatus(code, statuses); got != want {
+			t.Errorf("matchesStatus(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	transport := newHTTPClient(newSettingsContext(t, map[string]string{"maxIdleConnections": "7"})).Transport.(*http.Transport)
+	if transport.MaxIdleConns != 7 || transport.MaxIdleConnsPerHost != 7 || transport.DisableKeepAlives {
+		t.Errorf("transport keeps %d idle connections, %d per host, keep-alive disabled %v, want 7, 7 and keep-alive",
+			transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.DisableKeepAlives)
+	}
+
+	transport = newHTTPClient(newSettingsContext(t, map[string]string{"keepAlive": "0"})).Transport.(*http.Transport)
+	if !transport.DisableKeepAlives {
+		t.Error("keep-alive is enabled with keepAlive 0")
+	}
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key as PEM files into dir.
+func writeClientCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "lamp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath, keyPath := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	return certPath, keyPath
+}
+
+// withSettings sets the configuration settings as environment variables while run runs.
+func withSettings(settings map[string]string, run func()) {
+	for name, value := range settings {
+		os.Setenv(cfg.EnvName(name), value)
+		defer os.Unsetenv(cfg.EnvName(name))
+	}
+	cfg.SelectProfile("")
+	defer cfg.SelectProfile("")
+	run()
+}
+
+func TestTLSSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the server stands for a TLS intercepting proxy with its own certificate, it asks for a client certificate without requiring one
+	var clientCertificates int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientCertificates = len(r.TLS.PeerCertificates)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	caBundle := filepath.Join(dir, "ca.pem")
+	ioutil.WriteFile(caBundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+	clientCert, clientKey := writeClientCertificate(t, dir)
+
+	tests := []struct {
+		name             string
+		settings         map[string]string
+		wantErr          bool
+		wantCertificates int
+	}{
+		{name: "unknown certificate authority", settings: map[string]string{}, wantErr: true},
+		{name: "ca bundle", settings: map[string]string{"caBundle": caBundle}},
+		{name: "insecure skip verify", settings: map[string]string{"insecureSkipVerify": "true"}},
+		{name: "verified when insecure skip verify is false", settings: map[string]string{"insecureSkipVerify": "false"}, wantErr: true},
+		{
+			name:             "client certificate",
+			settings:         map[string]string{"caBundle": caBundle, "clientCert": clientCert, "clientKey": clientKey},
+			wantCertificates: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clientCertificates = 0
+			withSettings(test.settings, func() {
+				httpClient := newHTTPClient(newSettingsContext(t, nil))
+				resp, err := httpClient.Get(server.URL)
+				if test.wantErr {
+					if err == nil {
+						resp.Body.Close()
+						t.Fatal("request succeeded, want a certificate verification error")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				if clientCertificates != test.wantCertificates {
+					t.Errorf("server got %d client certificates, want %d", clientCertificates, test.wantCertificates)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package command
+
+import (
+	"context"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/incident"
+)
+
+// IncidentIdentifier identifies an incident by its ID, or by its tiny id when IdentifierType is tiny.
+type IncidentIdentifier struct {
+	ID             string
+	IdentifierType string
+}
+
+func (i IncidentIdentifier) identifierType() incident.IdentifierType {
+	if i.IdentifierType == "tiny" {
+		return incident.Tiny
+	}
+	return incident.Id
+}
+
+// IncidentResponder is a responder of an incident, Identifier is the ID of a user or the name of a team.
+type IncidentResponder struct {
+	// Type is user or team.
+	Type       string
+	Identifier string
+}
+
+func incidentResponders(responders []IncidentResponder) []incident.Responder {
+	var result []incident.Responder
+	for _, responder := range responders {
+		if responder.Type == "user" {
+			result = append(result, incident.Responder{Type: incident.User, Id: responder.Identifier})
+		} else {
+			result = append(result, incident.Responder{Type: incident.Team, Name: responder.Identifier})
+		}
+	}
+	return result
+}
+
+type CreateIncidentOptions struct {
+	Message                     string
+	Description                 string
+	Responders                  []IncidentResponder
+	Tags                        []string
+	Details                     map[string]string
+	Priority                    string
+	Note                        string
+	ServiceID                   string
+	NotifyStakeHolders          bool
+	StatusPageEntityTitle       string
+	StatusPageEntityDescription string
+}
+
+// CreateIncident creates an incident at Opsgenie.
+func (a *API) CreateIncident(ctx context.Context, opts CreateIncidentOptions) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	notifyStakeHolders := opts.NotifyStakeHolders
+	return cli.Create(ctx, &incident.CreateRequest{
+		Message:            opts.Message,
+		Description:        opts.Description,
+		Responders:         incidentResponders(opts.Responders),
+		Tags:               opts.Tags,
+		Details:            opts.Details,
+		Priority:           incident.Priority(opts.Priority),
+		Note:               opts.Note,
+		ServiceId:          opts.ServiceID,
+		NotifyStakeholders: &notifyStakeHolders,
+		StatusPageEntity: &incident.StatusPageEntity{
+			Title:       opts.StatusPageEntityTitle,
+			Description: opts.StatusPageEntityDescription,
+		},
+	})
+}
+
+// DeleteIncident deletes an incident at Opsgenie.
+func (a *API) DeleteIncident(ctx context.Context, opts IncidentIdentifier) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Delete(ctx, &incident.DeleteRequest{Id: opts.ID, Identifier: opts.identifierType()})
+}
+
+// GetIncident retrieves the details of an incident from Opsgenie.
+func (a *API) GetIncident(ctx context.Context, opts IncidentIdentifier) (*incident.GetResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Get(ctx, &incident.GetRequest{Id: opts.ID, Identifier: opts.identifierType()})
+}
+
+type ListIncidentOptions struct {
+	Limit  int
+	Offset int
+	// SortField is createdAt by default, or one of tinyId, message, status, isSeen and owner.
+	SortField string
+	// Order is desc by default, or asc.
+	Order string
+	Query string
+}
+
+// ListIncident retrieves a page of incidents from Opsgenie.
+func (a *API) ListIncident(ctx context.Context, opts ListIncidentOptions) (*incident.ListResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	req := incident.ListRequest{
+		Limit:  opts.Limit,
+		Offset: opts.Offset,
+		Sort:   incidentSortField(opts.SortField),
+		Order:  incident.Desc,
+		Query:  opts.Query,
+	}
+	if opts.Order == "asc" {
+		req.Order = incident.Asc
+	}
+	return cli.List(ctx, &req)
+}
+
+func incidentSortField(field string) incident.SortField {
+	switch field {
+	case "", "createdAt":
+		return incident.CreatedAt
+	case "tinyId":
+		return incident.TinyId
+	case "message":
+		return incident.Message
+	case "status":
+		return incident.Status
+	case "isSeen":
+		return incident.IsSeen
+	}
+	return incident.Owner
+}
+
+type IncidentNoteOptions struct {
+	IncidentIdentifier
+	Note string
+}
+
+// CloseIncident closes an incident at Opsgenie.
+func (a *API) CloseIncident(ctx context.Context, opts IncidentNoteOptions) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Close(ctx, &incident.CloseRequest{Id: opts.ID, Identifier: opts.identifierType(), Note: opts.Note})
+}
+
+// AddNoteIncident adds a note to an incident at Opsgenie.
+func (a *API) AddNoteIncident(ctx context.Context, opts IncidentNoteOptions) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.AddNote(ctx, &incident.AddNoteRequest{Id: opts.ID, Identifier: opts.identifierType(), Note: opts.Note})
+}
+
+type AddResponderIncidentOptions struct {
+	IncidentNoteOptions
+	Responders []IncidentResponder
+}
+
+// AddResponderIncident adds responders to an incident at Opsgenie.
+func (a *API) AddResponderIncident(ctx context.Context, opts AddResponderIncidentOptions) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.AddResponder(ctx, &incident.AddResponderRequest{
+		Id:         opts.ID,
+		Identifier: opts.identifierType(),
+		Note:       opts.Note,
+		Responders: incidentResponders(opts.Responders),
+	})
+}
+
+type IncidentTagsOptions struct {
+	IncidentNoteOptions
+	Tags []string
+}
+
+// AddTagsIncident adds tags to an incident at Opsgenie.
+func (a *API) AddTagsIncident(ctx context.Context, opts IncidentTagsOptions) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.AddTags(ctx, &incident.AddTagsRequest{Id: opts.ID, Identifier: opts.identifierType(), Note: opts.Note, Tags: opts.Tags})
+}
+
+// RemoveTagsIncident removes tags from an incident at Opsgenie.
+func (a *API) RemoveTagsIncident(ctx context.Context, opts IncidentTagsOptions) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.RemoveTags(ctx, &incident.RemoveTagsRequest{Id: opts.ID, Identifier: opts.identifierType(), Note: opts.Note, Tags: opts.Tags})
+}
+
+type AddDetailsIncidentOptions struct {
+	IncidentNoteOptions
+	Details map[string]string
+}
+
+// AddDetailsIncident adds details to an incident at Opsgenie.
+func (a *API) AddDetailsIncident(ctx context.Context, opts AddDetailsIncidentOptions) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.AddDetails(ctx, &incident.AddDetailsRequest{Id: opts.ID, Identifier: opts.identifierType(), Note: opts.Note, Details: opts.Details})
+}
+
+type RemoveDetailsIncidentOptions struct {
+	IncidentNoteOptions
+	Keys []string
+}
+
+// RemoveDetailsIncident removes details from an incident at Opsgenie.
+func (a *API) RemoveDetailsIncident(ctx context.Context, opts RemoveDetailsIncidentOptions) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.RemoveDetails(ctx, &incident.RemoveDetailsRequest{Id: opts.ID, Identifier: opts.identifierType(), Note: opts.Note, Keys: opts.Keys})
+}
+
+type UpdatePriorityIncidentOptions struct {
+	IncidentIdentifier
+	// Priority is one of P1 to P5.
+	Priority string
+}
+
+// UpdatePriorityIncident updates the priority of an incident at Opsgenie.
+func (a *API) UpdatePriorityIncident(ctx context.Context, opts UpdatePriorityIncidentOptions) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.UpdatePriority(ctx, &incident.UpdatePriorityRequest{Id: opts.ID, Identifier: opts.identifierType(), Priority: incident.Priority(opts.Priority)})
+}
+
+type UpdateMessageIncidentOptions struct {
+	IncidentIdentifier
+	Message string
+}
+
+// UpdateMessageIncident updates the message of an incident at Opsgenie.
+func (a *API) UpdateMessageIncident(ctx context.Context, opts UpdateMessageIncidentOptions) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.UpdateMessage(ctx, &incident.UpdateMessageRequest{Id: opts.ID, Identifier: opts.identifierType(), Message: opts.Message})
+}
+
+type UpdateDescriptionIncidentOptions struct {
+	IncidentIdentifier
+	Description string
+}
+
+// UpdateDescriptionIncident updates the description of an incident at Opsgenie.
+func (a *API) UpdateDescriptionIncident(ctx context.Context, opts UpdateDescriptionIncidentOptions) (*incident.AsyncResult, error) {
+	cli, release, err := a.incidentClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.UpdateDescription(ctx, &incident.UpdateDescriptionRequest{Id: opts.ID, Identifier: opts.identifierType(), Description: opts.Description})
+}
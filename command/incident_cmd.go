@@ -1,643 +1,274 @@
 package command
 
 import (
-	"errors"
 	"github.com/opsgenie/opsgenie-go-sdk-v2/incident"
 	gcli "github.com/urfave/cli"
-	"os"
-	"strconv"
-	"strings"
 )
 
 func NewIncidentClient(c *gcli.Context) (*incident.Client, error) {
-	incidentcli, cliErr := incident.NewClient(getConfigurations(c))
-	if cliErr != nil {
-		message := "Can not create the incident client. " + cliErr.Error()
-		printMessage(INFO, message)
-		return nil, errors.New(message)
-	}
-	printMessage(DEBUG,"Incident Client created.")
-	return incidentcli, nil
-}
-
-func CreateIncidentAction(c *gcli.Context) {
-	cli, err := NewIncidentClient(c)
+	cli, _, err := cliAPI(c).incidentClient(rootContext())
 	if err != nil {
-		os.Exit(1)
-	}
-
-	req :=incident.CreateRequest{}
-	if val, success := getVal("message", c); success {
-		req.Message =  val
-	}
-
-	if val, success := getVal("description", c); success {
-		req.Description = val
-	}
-
-	req.Responders = grabIncidentResponders(c)
-
-	if val, success := getVal("tags", c); success {
-		req.Tags = strings.Split(val, ",")
-	}
-
-	req.Details = grabIncidentDetails(c)
-
-	req.Priority = grabIncidentPriority(c)
-
-	if val, success := getVal("note", c); success {
-		req.Note =  val
-	}
-
-	if val, success := getVal("serviceId", c); success {
-		req.ServiceId =  val
+		return nil, err
 	}
+	printMessage(DEBUG, "Incident Client created.")
+	return cli, nil
+}
 
-	notifyStakeHolders := c.IsSet("notifyStakeHolders")
-
-	req.StatusPageEntity = grabStatusPageEntity(c)
-
-	req.NotifyStakeholders = &notifyStakeHolders
-
-	printMessage(DEBUG,"Create Incident Request Created. Sending to Opsgenie...")
+func grabIncidentIdentifier(c *gcli.Context) IncidentIdentifier {
+	opts := IncidentIdentifier{}
+	opts.ID, _ = getVal("identifier", c)
+	opts.IdentifierType, _ = getVal("identifierType", c)
+	return opts
+}
 
-	resp, err := cli.Create(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+func grabIncidentNoteOptions(c *gcli.Context) IncidentNoteOptions {
+	opts := IncidentNoteOptions{IncidentIdentifier: grabIncidentIdentifier(c)}
+	opts.Note, _ = getVal("note", c)
+	return opts
+}
 
-	printMessage(DEBUG,"Creating Incident. RequestID: " + resp.RequestId)
-	printMessage(INFO, "RequestID: " + resp.RequestId)
+func CreateIncidentAction(c *gcli.Context) {
+	opts := CreateIncidentOptions{
+		Responders:         grabIncidentResponders(c),
+		Tags:               grabList("tags", c),
+		Details:            grabIncidentDetails(c),
+		Priority:           grabIncidentPriority(c),
+		NotifyStakeHolders: c.IsSet("notifyStakeHolders"),
+	}
+	opts.Message, _ = getVal("message", c)
+	opts.Description, _ = getVal("description", c)
+	opts.Note, _ = getVal("note", c)
+	opts.ServiceID, _ = getVal("serviceId", c)
+	opts.StatusPageEntityTitle, _ = getVal("statusPageEntityTitle", c)
+	opts.StatusPageEntityDescription, _ = getVal("statusPageEntityDescription", c)
+
+	printMessage(DEBUG, "Create Incident Request Created. Sending to Opsgenie...")
+
+	api := cliAPI(c)
+	resp, err := api.CreateIncident(rootContext(), opts)
+	exitOnErr(err)
+
+	printMessage(DEBUG, "Creating Incident. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 func DeleteIncidentAction(c *gcli.Context) {
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
+	printMessage(DEBUG, "Delete Incident Request Created. Sending to Opsgenie...")
 
-	req := incident.DeleteRequest{}
+	api := cliAPI(c)
+	resp, err := api.DeleteIncident(rootContext(), grabIncidentIdentifier(c))
+	exitOnErr(err)
 
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
-
-	req.Identifier = grabIncidentIdentifierType(c)
-
-	printMessage(DEBUG,"Delete Incident Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.Delete(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-
-	printMessage(DEBUG,"Deleting Incident. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Deleting Incident. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 func GetIncidentAction(c *gcli.Context) {
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.GetRequest{}
-
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
-
-	req.Identifier = grabIncidentIdentifierType(c)
-
-	printMessage(DEBUG,"Get Incident Request Created. Sending to Opsgenie...")
+	printMessage(DEBUG, "Get Incident Request Created. Sending to Opsgenie...")
 
-	resp, err := cli.Get(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	resp, err := cliAPI(c).GetIncident(rootContext(), grabIncidentIdentifier(c))
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Fetching Incident. RequestID: " + resp.RequestId)
-	output, err := resultToJSON(resp, true)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(INFO,"Incident : " + output)
+	printMessage(DEBUG, "Fetching Incident. RequestID: "+resp.RequestId)
+	renderResult(c, resp)
 }
 
 func ListIncidentAction(c *gcli.Context) {
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.ListRequest{}
-
-	if val, success := getVal("limit", c); success {
-		req.Limit, _ =  strconv.Atoi(val)
-	}
-
-	req.Sort = grabSortField(c)
-
-	if val, success := getVal("offset", c); success {
-		req.Offset, _ =  strconv.Atoi(val)
-	}
-
-	req.Order = getOrderIncident(c)
-
-	if val, success := getVal("query", c); success {
-		req.Query =  val
-	}
-
-	printMessage(DEBUG,"List Incident Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.List(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
+	api := cliAPI(c)
+	opts := ListIncidentOptions{Limit: grabIntFlag("limit", c), Offset: grabIntFlag("offset", c)}
+	opts.SortField, _ = getVal("sortField", c)
+	opts.Order, _ = getVal("order", c)
+	opts.Query, _ = getVal("query", c)
+
+	printMessage(DEBUG, "List Incident Request Created. Sending to Opsgenie...")
+
+	if isAllPagesRequested(c) {
+		renderAllPages(c, func() (interface{}, bool, error) {
+			resp, err := api.ListIncident(rootContext(), opts)
+			if err != nil {
+				return nil, false, err
+			}
+			opts.Offset += len(resp.Incidents)
+			return resp.Incidents, resp.Paging.Next != "", nil
+		})
+		return
 	}
 
-	printMessage(DEBUG,"Fetching List of Incident. RequestID: " + resp.RequestId)
-	output, err := resultToJSON(resp, true)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(INFO,"Incident List : " + output)
+	resp, err := api.ListIncident(rootContext(), opts)
+	exitOnErr(err)
 
+	printMessage(DEBUG, "Fetching List of Incident. RequestID: "+resp.RequestId)
+	renderResult(c, resp.Incidents)
 }
 
 func CloseIncidentAction(c *gcli.Context) {
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.CloseRequest{}
-
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
-
-	req.Identifier = grabIncidentIdentifierType(c)
-
-	if val, success := getVal("note", c); success {
-		req.Note =  val
-	}
-
-	printMessage(DEBUG,"Close Incident Request Created. Sending to Opsgenie...")
+	printMessage(DEBUG, "Close Incident Request Created. Sending to Opsgenie...")
 
-	resp, err := cli.Close(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	api := cliAPI(c)
+	resp, err := api.CloseIncident(rootContext(), grabIncidentNoteOptions(c))
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Cosing Incident. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Cosing Incident. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 func AddNoteIncidentAction(c *gcli.Context) {
+	printMessage(DEBUG, "Add Note to Incident Request Created. Sending to Opsgenie...")
 
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.AddNoteRequest{}
-
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
-
-	req.Identifier = grabIncidentIdentifierType(c)
-
-	if val, success := getVal("note", c); success {
-		req.Note =  val
-	}
-
-	printMessage(DEBUG,"Add Note to Incident Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.AddNote(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	api := cliAPI(c)
+	resp, err := api.AddNoteIncident(rootContext(), grabIncidentNoteOptions(c))
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Adding Note to Incident. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Adding Note to Incident. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 func AddResponderIncidentAction(c *gcli.Context) {
+	opts := AddResponderIncidentOptions{IncidentNoteOptions: grabIncidentNoteOptions(c), Responders: grabIncidentResponders(c)}
 
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.AddResponderRequest{}
-
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
-
-	req.Identifier = grabIncidentIdentifierType(c)
-
-	if val, success := getVal("note", c); success {
-		req.Note =  val
-	}
-
-	req.Responders = grabIncidentResponders(c)
-
-	printMessage(DEBUG,"Add Responder to Incident Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.AddResponder(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	printMessage(DEBUG, "Add Responder to Incident Request Created. Sending to Opsgenie...")
 
-	printMessage(DEBUG,"Adding Responder to Incident. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	api := cliAPI(c)
+	resp, err := api.AddResponderIncident(rootContext(), opts)
+	exitOnErr(err)
 
+	printMessage(DEBUG, "Adding Responder to Incident. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 func AddTagsIncidentAction(c *gcli.Context) {
+	opts := IncidentTagsOptions{IncidentNoteOptions: grabIncidentNoteOptions(c), Tags: grabList("tags", c)}
 
+	printMessage(DEBUG, "Add Tags to Incident Request Created. Sending to Opsgenie...")
 
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.AddTagsRequest{}
-
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
-
-	req.Identifier = grabIncidentIdentifierType(c)
-
-	if val, success := getVal("note", c); success {
-		req.Note =  val
-	}
-
-	if val, success := getVal("tags", c); success {
-		req.Tags =  strings.Split(val, ",")
-	}
+	api := cliAPI(c)
+	resp, err := api.AddTagsIncident(rootContext(), opts)
+	exitOnErr(err)
 
-
-	printMessage(DEBUG,"Add Tags to Incident Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.AddTags(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-
-	printMessage(DEBUG,"Adding Tags to Incident. RequestID: " + resp.RequestId)
-	printMessage(INFO, "RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Adding Tags to Incident. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 func RemoveTagsIncidentAction(c *gcli.Context) {
+	opts := IncidentTagsOptions{IncidentNoteOptions: grabIncidentNoteOptions(c), Tags: grabList("tags", c)}
 
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.RemoveTagsRequest{}
-
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
-
-	req.Identifier = grabIncidentIdentifierType(c)
-
-	if val, success := getVal("note", c); success {
-		req.Note =  val
-	}
+	printMessage(DEBUG, "Remove Tags from Incident Request Created. Sending to Opsgenie...")
 
-	if val, success := getVal("tags", c); success {
-		req.Tags =  strings.Split(val, ",")
-	}
-
-	printMessage(DEBUG,"Remove Tags from Incident Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.RemoveTags(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	api := cliAPI(c)
+	resp, err := api.RemoveTagsIncident(rootContext(), opts)
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Removing Tags from Incident. RequestID: " + resp.RequestId)
-	printMessage(INFO, "RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Removing Tags from Incident. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 func AddDetailsIncidentAction(c *gcli.Context) {
+	opts := AddDetailsIncidentOptions{IncidentNoteOptions: grabIncidentNoteOptions(c), Details: grabIncidentDetails(c)}
 
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.AddDetailsRequest{}
-
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
-
-	req.Identifier = grabIncidentIdentifierType(c)
+	printMessage(DEBUG, "Add Details to Incident Request Created. Sending to Opsgenie...")
 
-	if val, success := getVal("note", c); success {
-		req.Note =  val
-	}
-
-	req.Details = grabIncidentDetails(c)
+	api := cliAPI(c)
+	resp, err := api.AddDetailsIncident(rootContext(), opts)
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Add Details to Incident Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.AddDetails(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-
-	printMessage(DEBUG,"Adding details to Incident. RequestID: " + resp.RequestId)
-	printMessage(INFO, "RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Adding details to Incident. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 func RemoveDetailsIncidentAction(c *gcli.Context) {
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.RemoveDetailsRequest{}
-
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
-
-	req.Identifier = grabIncidentIdentifierType(c)
-
-	if val, success := getVal("note", c); success {
-		req.Note =  val
-	}
+	opts := RemoveDetailsIncidentOptions{IncidentNoteOptions: grabIncidentNoteOptions(c), Keys: grabList("keys", c)}
 
-	if val, success := getVal("keys", c); success {
-		req.Keys =  strings.Split(val, ",")
-	}
-
-	printMessage(DEBUG,"Remove Details from Incident Request Created. Sending to Opsgenie...")
+	printMessage(DEBUG, "Remove Details from Incident Request Created. Sending to Opsgenie...")
 
-	resp, err := cli.RemoveDetails(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	api := cliAPI(c)
+	resp, err := api.RemoveDetailsIncident(rootContext(), opts)
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Removing Details from Incident. RequestID: " + resp.RequestId)
-	printMessage(INFO, "RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Removing Details from Incident. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 func UpdatePriorityIncidentAction(c *gcli.Context) {
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.UpdatePriorityRequest{}
+	opts := UpdatePriorityIncidentOptions{IncidentIdentifier: grabIncidentIdentifier(c), Priority: grabIncidentPriority(c)}
 
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
-
-	req.Identifier = grabIncidentIdentifierType(c)
+	printMessage(DEBUG, "Update Incident Priority Request Created. Sending to Opsgenie...")
 
-	req.Priority =  grabIncidentPriority(c)
+	api := cliAPI(c)
+	resp, err := api.UpdatePriorityIncident(rootContext(), opts)
+	exitOnErr(err)
 
-	printMessage(DEBUG,"Update Incident Priority Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.UpdatePriority(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-
-	printMessage(DEBUG,"Incident Priority is being updated. RequestID: " + resp.RequestId)
-	printMessage(INFO, "RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Incident Priority is being updated. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 func UpdateMessageIncidentAction(c *gcli.Context) {
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.UpdateMessageRequest{}
+	opts := UpdateMessageIncidentOptions{IncidentIdentifier: grabIncidentIdentifier(c)}
+	opts.Message, _ = getVal("message", c)
 
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
+	printMessage(DEBUG, "Update Incident Message Request Created. Sending to Opsgenie...")
 
-	req.Identifier = grabIncidentIdentifierType(c)
+	api := cliAPI(c)
+	resp, err := api.UpdateMessageIncident(rootContext(), opts)
+	exitOnErr(err)
 
-	if val, success := getVal("message", c); success {
-		req.Message =  val
-	}
-
-	printMessage(DEBUG,"Update Incident Message Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.UpdateMessage(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-
-	printMessage(DEBUG,"Incident message is being updated. RequestID: " + resp.RequestId)
-	printMessage(INFO, "RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Incident message is being updated. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
 func UpdateDescriptionIncidentAction(c *gcli.Context) {
-	cli, err := NewIncidentClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	req := incident.UpdateDescriptionRequest{}
-
-	if val, success := getVal("identifier", c); success {
-		req.Id =  val
-	}
-
-	req.Identifier = grabIncidentIdentifierType(c)
-
-	if val, success := getVal("description", c); success {
-		req.Description =  val
-	}
-
-	printMessage(DEBUG,"Update Incident Description Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.UpdateDescription(nil, &req)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
+	opts := UpdateDescriptionIncidentOptions{IncidentIdentifier: grabIncidentIdentifier(c)}
+	opts.Description, _ = getVal("description", c)
 
-	printMessage(DEBUG,"Incident Description is being updated. RequestID: " + resp.RequestId)
-	printMessage(INFO, "RequestID: " + resp.RequestId)
-}
+	printMessage(DEBUG, "Update Incident Description Request Created. Sending to Opsgenie...")
 
-func grabIncidentIdentifierType(c *gcli.Context) incident.IdentifierType {
-	if val, success := getVal("identifierType", c); success {
-		if val == "tiny" {
-			return incident.Tiny
-		}
-	}
-	return incident.Id
-}
-
-func grabSortField(c *gcli.Context) incident.SortField {
-	if val, success := getVal("sortField", c); success {
-		if val == "createdAt" {
-			return incident.CreatedAt
-		}else if val == "tinyId" {
-			return incident.TinyId
-		}else if val == "message" {
-			return incident.Message
-		}else if val == "status" {
-			return incident.Status
-		}else if val == "isSeen" {
-			return incident.IsSeen
-		}else {
-			return incident.Owner
-		}
-	}
-	return incident.CreatedAt
-}
+	api := cliAPI(c)
+	resp, err := api.UpdateDescriptionIncident(rootContext(), opts)
+	exitOnErr(err)
 
-func getOrderIncident(c *gcli.Context) incident.Order {
-	if val, success := getVal("order", c); success {
-		if val == "asc" {
-			return incident.Asc
-		}
-	}
-	return incident.Desc
+	printMessage(DEBUG, "Incident Description is being updated. RequestID: "+resp.RequestId)
+	printIncidentRequest(c, api, resp.RequestId, "RequestID: "+resp.RequestId)
 }
 
-func grabIncidentResponders(c *gcli.Context) []incident.Responder {
-
-	var responders []incident.Responder
-
-	responderTypes := grabResponderTypes(c)
-	var responderIdentifiers []string
-
-	if val, success := getVal("responder", c); success {
-		responderIdentifiers =  strings.Split(val, ",")
-	}
-
+// grabIncidentResponders pairs the values of the type and responder flags.
+func grabIncidentResponders(c *gcli.Context) []IncidentResponder {
+	responderTypes := grabList("type", c)
+	responderIdentifiers := grabList("responder", c)
 	if len(responderTypes) != len(responderIdentifiers) {
-		printMessage(ERROR,"type and responders should have equal values")
-		os.Exit(1)
+		exitOnUsageErr("type and responders should have equal values")
 	}
 
-	for index,_ := range responderTypes {
-		if responderTypes[index] == incident.User {
-			responders = append(responders, incident.Responder{
-				Type: responderTypes[index],
-				Id:   responderIdentifiers[index],
-			})
-		} else {
-			responders = append(responders, incident.Responder{
-				Type: responderTypes[index],
-				Name:   responderIdentifiers[index],
-			})
+	var responders []IncidentResponder
+	for index, responderType := range responderTypes {
+		if responderType != "user" {
+			responderType = "team"
 		}
+		responders = append(responders, IncidentResponder{Type: responderType, Identifier: responderIdentifiers[index]})
 	}
-
 	return responders
 }
 
-func grabResponderTypes(c *gcli.Context) []incident.ResponderType {
-	if val, success := getVal("type", c); success {
-
-		var types []incident.ResponderType
-
-		responderType := strings.Split(val, ",")
-		for _,value := range responderType {
-			if value == "user" {
-				types = append(types, incident.User)
-			} else {
-				types = append(types, incident.Team)
-			}
-		}
-		return types
-	}
-	return nil
-}
-
 func grabIncidentDetails(c *gcli.Context) map[string]string {
-
-	var detailKeys []string
-	var detailValues []string
-	var details = make(map[string]string)
-
-	if val, success := getVal("detailKeys", c); success {
-		detailKeys = strings.Split(val, ",")
-	}
-
-	if val, success := getVal("detailValues", c); success {
-		detailValues = strings.Split(val, ",")
-	}
-
+	detailKeys := grabList("detailKeys", c)
+	detailValues := grabList("detailValues", c)
 	if len(detailKeys) != len(detailValues) {
-		printMessage(ERROR,"detailKeys and detailValues should have equal values")
-		os.Exit(1)
+		exitOnUsageErr("detailKeys and detailValues should have equal values")
 	}
 
-	for index,value := range detailKeys {
+	var details = make(map[string]string)
+	for index, value := range detailKeys {
 		details[value] = detailValues[index]
 	}
 	return details
 }
 
-func grabIncidentPriority(c *gcli.Context) incident.Priority {
-
+func grabIncidentPriority(c *gcli.Context) string {
 	if val, success := getVal("priority", c); success {
-		if val == "P1" {
-			return incident.P1
-		} else if val == "P2" {
-			return incident.P2
-		} else if val == "P3" {
-			return incident.P3
-		} else if val == "P4" {
-			return incident.P4
-		} else if val == "P5"{
-			return incident.P5
+		switch incident.Priority(val) {
+		case incident.P1, incident.P2, incident.P3, incident.P4, incident.P5:
+			return val
 		}
 	}
 
-	printMessage(ERROR,"Please add correct Priority")
-	os.Exit(1)
-	return incident.P3
+	exitOnUsageErr("Please add correct Priority")
+	return string(incident.P3)
 }
-
-func grabStatusPageEntity(c *gcli.Context) *incident.StatusPageEntity {
-
-	var statusPageEntity incident.StatusPageEntity
-
-	if val, success := getVal("statusPageEntityTitle", c); success {
-		statusPageEntity.Title =  val
-	}
-
-	if val, success := getVal("statusPageEntityDescription", c); success {
-		statusPageEntity.Description = val
-	}
-
-	return &statusPageEntity
-}
-
@@ -0,0 +1,61 @@
+package command
+
+import (
+	"context"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/integration"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/policy"
+)
+
+// IntegrationIdentifier identifies an integration by its ID.
+type IntegrationIdentifier struct {
+	ID string
+}
+
+// EnableIntegration enables an integration at Opsgenie.
+func (a *API) EnableIntegration(ctx context.Context, opts IntegrationIdentifier) (*integration.EnableResult, error) {
+	cli, release, err := a.integrationClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Enable(ctx, &integration.EnableIntegrationRequest{Id: opts.ID})
+}
+
+// DisableIntegration disables an integration at Opsgenie.
+func (a *API) DisableIntegration(ctx context.Context, opts IntegrationIdentifier) (*integration.DisableResult, error) {
+	cli, release, err := a.integrationClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Disable(ctx, &integration.DisableIntegrationRequest{Id: opts.ID})
+}
+
+// PolicyIdentifier identifies a policy by its ID, TeamID is required for team policies.
+type PolicyIdentifier struct {
+	ID     string
+	TeamID string
+	// PolicyType is alert or notification.
+	PolicyType string
+}
+
+// EnablePolicy enables a policy at Opsgenie.
+func (a *API) EnablePolicy(ctx context.Context, opts PolicyIdentifier) (*policy.PolicyResult, error) {
+	cli, release, err := a.policyClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.EnablePolicy(ctx, &policy.EnablePolicyRequest{Id: opts.ID, TeamId: opts.TeamID, Type: policy.PolicyType(opts.PolicyType)})
+}
+
+// DisablePolicy disables a policy at Opsgenie.
+func (a *API) DisablePolicy(ctx context.Context, opts PolicyIdentifier) (*policy.PolicyResult, error) {
+	cli, release, err := a.policyClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.DisablePolicy(ctx, &policy.DisablePolicyRequest{Id: opts.ID, TeamId: opts.TeamID, Type: policy.PolicyType(opts.PolicyType)})
+}
@@ -1,33 +1,41 @@
 package command
 
 import (
-	"errors"
 	"github.com/opsgenie/opsgenie-go-sdk-v2/integration"
 	"github.com/opsgenie/opsgenie-go-sdk-v2/policy"
 	gcli "github.com/urfave/cli"
-	"os"
 )
 
 func NewIntegrationClient(c *gcli.Context) (*integration.Client, error) {
-	integrationCli, cliErr := integration.NewClient(getConfigurations(c))
-	if cliErr != nil {
-		message := "Can not create the integration client. " + cliErr.Error()
-		printMessage(ERROR, message)
-		return nil, errors.New(message)
+	cli, _, err := cliAPI(c).integrationClient(rootContext())
+	if err != nil {
+		return nil, err
 	}
-	printMessage(DEBUG,"Integration Client created.")
-	return integrationCli, nil
+	printMessage(DEBUG, "Integration Client created.")
+	return cli, nil
 }
 
 func NewPolicyClient(c *gcli.Context) (*policy.Client, error) {
-	policyCli, cliErr := policy.NewClient(getConfigurations(c))
-	if cliErr != nil {
-		message := "Can not create the policy client. " + cliErr.Error()
-		printMessage(ERROR, message)
-		return nil, errors.New(message)
+	cli, _, err := cliAPI(c).policyClient(rootContext())
+	if err != nil {
+		return nil, err
 	}
-	printMessage(DEBUG,"Policy Client created.")
-	return policyCli, nil
+	printMessage(DEBUG, "Policy Client created.")
+	return cli, nil
+}
+
+func grabPolicyIdentifier(c *gcli.Context) PolicyIdentifier {
+	opts := PolicyIdentifier{}
+	opts.ID, _ = getVal("id", c)
+	opts.TeamID, _ = getVal("teamId", c)
+	opts.PolicyType, _ = getVal("policyType", c)
+	return opts
+}
+
+func grabIntegrationIdentifier(c *gcli.Context) IntegrationIdentifier {
+	opts := IntegrationIdentifier{}
+	opts.ID, _ = getVal("id", c)
+	return opts
 }
 
 // EnableAction enables an integration/policy according to the --type parameter at Opsgenie.
@@ -35,50 +43,19 @@ func EnableAction(c *gcli.Context) {
 	val, _ := getVal("type", c)
 	switch val {
 	case "policy":
-		cli, err := NewPolicyClient(c)
-		if err != nil {
-			os.Exit(1)
-		}
-
-		req := policy.EnablePolicyRequest{}
-		if val, success := getVal("id", c); success {
-			req.Id = val
-		}
-		if val, success := getVal("teamId", c); success {
-			req.TeamId = val
-		}
-		if val, success := getVal("policyType", c); success {
-			req.Type = policy.PolicyType(val)
-		}
-		printMessage(DEBUG,"Enable policy request prepared from flags, sending request to Opsgenie..")
-		_, err = cli.EnablePolicy(nil, &req)
-		if err != nil {
-			printMessage(ERROR, err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO,"Policy enabled successfuly")
+		printMessage(DEBUG, "Enable policy request prepared from flags, sending request to Opsgenie..")
+		_, err := cliAPI(c).EnablePolicy(rootContext(), grabPolicyIdentifier(c))
+		exitOnErr(err)
+		printResultMessage(c, "Policy enabled successfuly")
 
 	case "integration":
-		cli, err := NewIntegrationClient(c)
-		if err != nil {
-			os.Exit(1)
-		}
-
-		req := integration.EnableIntegrationRequest{}
-		if val, success := getVal("id", c); success {
-			req.Id = val
-		}
-		printMessage(DEBUG,"Enable integration request prepared from flags, sending request to Opsgenie..")
-		_, err = cli.Enable(nil, &req)
-		if err != nil {
-			printMessage(ERROR, err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO,"Integration enabled successfuly")
+		printMessage(DEBUG, "Enable integration request prepared from flags, sending request to Opsgenie..")
+		_, err := cliAPI(c).EnableIntegration(rootContext(), grabIntegrationIdentifier(c))
+		exitOnErr(err)
+		printResultMessage(c, "Integration enabled successfuly")
 	default:
-		printMessage(INFO,"Invalid type option " + val + ", specify either integration or policy")
 		gcli.ShowCommandHelp(c, "enable")
-		os.Exit(1)
+		exitOnUsageErr("Invalid type option " + val + ", specify either integration or policy")
 	}
 }
 
@@ -87,49 +64,18 @@ func DisableAction(c *gcli.Context) {
 	val, _ := getVal("type", c)
 	switch val {
 	case "policy":
-		cli, err := NewPolicyClient(c)
-		if err != nil {
-			os.Exit(1)
-		}
-
-		req := policy.DisablePolicyRequest{}
-		if val, success := getVal("id", c); success {
-			req.Id = val
-		}
-		if val, success := getVal("teamId", c); success {
-			req.TeamId = val
-		}
-		if val, success := getVal("policyType", c); success {
-			req.Type = policy.PolicyType(val)
-		}
-		printMessage(DEBUG,"Disable policy request prepared from flags, sending request to Opsgenie..")
-		_, err = cli.DisablePolicy(nil, &req)
-		if err != nil {
-			printMessage(ERROR, err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO, "Policy disabled successfuly")
+		printMessage(DEBUG, "Disable policy request prepared from flags, sending request to Opsgenie..")
+		_, err := cliAPI(c).DisablePolicy(rootContext(), grabPolicyIdentifier(c))
+		exitOnErr(err)
+		printResultMessage(c, "Policy disabled successfuly")
 
 	case "integration":
-		cli, err := NewIntegrationClient(c)
-		if err != nil {
-			os.Exit(1)
-		}
-
-		req := integration.DisableIntegrationRequest{}
-		if val, success := getVal("id", c); success {
-			req.Id = val
-		}
-		printMessage(DEBUG,"Disable integration request prepared from flags, sending request to Opsgenie..")
-		_, err = cli.Disable(nil, &req)
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		printMessage(INFO,"Integration disabled successfuly")
+		printMessage(DEBUG, "Disable integration request prepared from flags, sending request to Opsgenie..")
+		_, err := cliAPI(c).DisableIntegration(rootContext(), grabIntegrationIdentifier(c))
+		exitOnErr(err)
+		printResultMessage(c, "Integration disabled successfuly")
 	default:
-		printMessage(ERROR,"Invalid type option " + val + ", specify either integration or policy")
 		gcli.ShowCommandHelp(c, "disable")
-		os.Exit(1)
+		exitOnUsageErr("Invalid type option " + val + ", specify either integration or policy")
 	}
 }
@@ -0,0 +1,564 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/policy"
+	"github.com/opsgenie/opsgenie-lamp/cfg"
+	gcli "github.com/urfave/cli"
+)
+
+const (
+	disabledJournal   = "none"
+	maxJournalBody    = 64 << 10
+	defaultHistoryMax = 20
+
+	outcomeAccepted  = "accepted"
+	outcomeSucceeded = "succeeded"
+	outcomeFailed    = "failed"
+)
+
+/*
+journalEntry is a line of the journal, recording a mutating request sent to Opsgenie. The id of an
+entry is its line number, which is not written since the journal is only appended to.
+*/
+type journalEntry struct {
+	ID           int             `json:"id,omitempty"`
+	Time         string          `json:"time"`
+	User         string          `json:"user"`
+	Host         string          `json:"host,omitempty"`
+	OpsgenieUser string          `json:"opsgenieUser,omitempty"`
+	Profile      string          `json:"profile,omitempty"`
+	Command      string          `json:"command"`
+	Method       string          `json:"method"`
+	Path         string          `json:"path"`
+	Query        string          `json:"query,omitempty"`
+	Body         json.RawMessage `json:"body,omitempty"`
+	Status       int             `json:"status"`
+	RequestID    string          `json:"requestId,omitempty"`
+	Outcome      string          `json:"outcome"`
+	Message      string          `json:"message,omitempty"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	Undo         int             `json:"undo,omitempty"`
+	UndoneBy     int             `json:"undoneBy,omitempty"`
+}
+
+// journalState is what the journal entries of the running command share.
+type journalState struct {
+	mu           sync.Mutex
+	path         string
+	user         string
+	host         string
+	opsgenieUser string
+	profile      string
+	command      string
+	// undo is the id of the entry the running undo command reverses
+	undo int
+	// responses are the last responses to the mutating requests, by method and URL
+	responses map[string]journalResponse
+}
+
+// journalResponse is the body of the response to a mutating request, with the command which sent the request.
+type journalResponse struct {
+	command string
+	body    []byte
+}
+
+// journalCommandKey is the context key of the command sending a request, a line of a batch or the shell runs another command than lamp.
+type journalCommandKey struct{}
+
+var (
+	journal              = &journalState{responses: map[string]journalResponse{}}
+	subscribeJournalOnce sync.Once
+)
+
+// withJournalCommand records the requests sent with the context as requests of the command.
+func withJournalCommand(ctx context.Context, command string) context.Context {
+	return context.WithValue(ctx, journalCommandKey{}, command)
+}
+
+/*
+journalTransport keeps the bodies of the responses to mutating requests for the journal, e.g. the alias of a
+created override, and records the requests failing without a response, which the SDK does not report.
+*/
+type journalTransport struct {
+	next http.RoundTripper
+}
+
+func (t *journalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+	command, _ := req.Context().Value(journalCommandKey{}).(string)
+	var body []byte
+	if req.Body != nil && !strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/") {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req = req.WithContext(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		recordFailedRequest(req, body, command, err)
+		return resp, err
+	}
+	responseBody, readErr := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+	if readErr != nil {
+		return resp, nil
+	}
+	journal.mu.Lock()
+	journal.responses[req.Method+" "+req.URL.String()] = journalResponse{command: command, body: responseBody}
+	journal.mu.Unlock()
+	return resp, nil
+}
+
+func grabJournalPath() string {
+	if path := cfg.Get("journalPath"); path != "" {
+		if path == disabledJournal {
+			return ""
+		}
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lamp", "journal.jsonl")
+}
+
+/*
+enableJournal records the mutating requests of the command in the journal, an append-only file of
+JSON lines at journalPath, by default lamp/journal.jsonl in the user configuration directory. The
+requests are recorded once they are answered, with the answer of the last retry. Requests failing
+without an answer, e.g. on a network error, are recorded as failed for every try.
+*/
+func enableJournal(c *gcli.Context, config *client.Config) {
+	path := grabJournalPath()
+	if path == "" {
+		return
+	}
+	journal.mu.Lock()
+	journal.path = path
+	journal.command = c.Command.Name
+	journal.profile = cfg.Profile()
+	journal.opsgenieUser = grabUsername(c)
+	journal.user = os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		journal.user = current.Username
+	}
+	journal.host, _ = os.Hostname()
+	journal.mu.Unlock()
+
+	next := config.HttpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	config.HttpClient.Transport = &journalTransport{next: next}
+	subscribeJournalOnce.Do(func() {
+		subscriber := client.MetricSubscriber{Process: func(metric client.Metric) interface{} {
+			if httpMetric, ok := metric.(*client.HttpMetric); ok {
+				recordRequest(httpMetric)
+			}
+			return nil
+		}}
+		subscriber.Register(client.HTTP)
+	})
+}
+
+func recordRequest(metric *client.HttpMetric) {
+	if metric.HttpRequest.Request == nil || metric.HttpRequest.Request.Request == nil || metric.HttpRequest.Method == http.MethodGet {
+		return
+	}
+	req := metric.HttpRequest.Request.Request
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	key := req.Method + " " + req.URL.String()
+	response := journal.responses[key]
+	delete(journal.responses, key)
+
+	body, _ := metric.HttpRequest.BodyBytes()
+	entry := newJournalEntry(req, body, response.command)
+	entry.Status = metric.StatusCode
+	var answer struct {
+		RequestID string          `json:"requestId"`
+		Message   string          `json:"message"`
+		Data      json.RawMessage `json:"data"`
+	}
+	json.Unmarshal(response.body, &answer)
+	entry.RequestID = answer.RequestID
+	switch {
+	case metric.StatusCode == http.StatusAccepted:
+		entry.Outcome = outcomeAccepted
+	case metric.StatusCode >= 200 && metric.StatusCode < 300:
+		entry.Outcome = outcomeSucceeded
+		if len(answer.Data) <= maxJournalBody {
+			entry.Result = answer.Data
+		}
+	default:
+		entry.Outcome = outcomeFailed
+		entry.Message = answer.Message
+		if metric.Error != nil && entry.Message == "" {
+			entry.Message = metric.Error.Error()
+		}
+	}
+	writeJournalEntry(entry)
+}
+
+// recordFailedRequest records a request which got no response, e.g. because the connection failed or the command is interrupted.
+func recordFailedRequest(req *http.Request, body []byte, command string, err error) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	entry := newJournalEntry(req, body, command)
+	entry.Outcome = outcomeFailed
+	entry.Message = err.Error()
+	writeJournalEntry(entry)
+}
+
+// newJournalEntry creates the entry of a request sent by the command, the running command if it is empty. journal.mu is held.
+func newJournalEntry(req *http.Request, body []byte, command string) journalEntry {
+	if command == "" {
+		command = journal.command
+	}
+	entry := journalEntry{
+		Time:         time.Now().UTC().Format(time.RFC3339),
+		User:         journal.user,
+		Host:         journal.host,
+		OpsgenieUser: journal.opsgenieUser,
+		Profile:      journal.profile,
+		Command:      command,
+		Method:       req.Method,
+		Path:         req.URL.Path,
+		Query:        req.URL.RawQuery,
+		Undo:         journal.undo,
+	}
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/") {
+		entry.Body, _ = json.Marshal("<" + req.Header.Get("Content-Type") + " body>")
+	} else if len(body) > 0 && len(body) <= maxJournalBody && json.Valid(body) {
+		entry.Body = body
+	}
+	return entry
+}
+
+// writeJournalEntry appends the entry to the journal, a failure is only warned about. journal.mu is held.
+func writeJournalEntry(entry journalEntry) {
+	if err := appendJournalEntry(journal.path, entry); err != nil {
+		printMessage(WARN, "Could not write to the journal "+journal.path+": "+err.Error())
+	}
+}
+
+func appendJournalEntry(path string, entry journalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// readJournal reads the entries of the journal, numbering them by line and linking the undone entries to their undo.
+func readJournal(path string) ([]*journalEntry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var entries []*journalEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), 4*maxJournalBody)
+	for line := 1; scanner.Scan(); line++ {
+		entry := &journalEntry{}
+		if json.Unmarshal(scanner.Bytes(), entry) != nil {
+			continue
+		}
+		entry.ID = line
+		entries = append(entries, entry)
+	}
+	for _, entry := range entries {
+		if entry.Undo > 0 && entry.Outcome != outcomeFailed {
+			if undone := findJournalEntry(entries, entry.Undo); undone != nil {
+				undone.UndoneBy = entry.ID
+			}
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func findJournalEntry(entries []*journalEntry, id int) *journalEntry {
+	index := sort.Search(len(entries), func(i int) bool {
+		return entries[i].ID >= id
+	})
+	if index < len(entries) && entries[index].ID == id {
+		return entries[index]
+	}
+	return nil
+}
+
+func openJournal(c *gcli.Context) []*journalEntry {
+	readConfigFile(c)
+	path := grabJournalPath()
+	if path == "" {
+		exitOnErr(newError(ExitCodeConfiguration, "Journal is disabled with journalPath="+disabledJournal))
+	}
+	entries, err := readJournal(path)
+	if err != nil {
+		exitOnErr(newError(ExitCodeError, "Could not read the journal "+path+": "+err.Error()))
+	}
+	return entries
+}
+
+// HistoryAction lists the latest entries of the journal, filtered by command, user, profile, age and reversibility.
+func HistoryAction(c *gcli.Context) {
+	entries := openJournal(c)
+	var since time.Time
+	if val, success := getVal("since", c); success {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			exitOnUsageErr("Invalid since value " + val + ", give a duration such as 24h")
+		}
+		since = time.Now().Add(-duration)
+	}
+	commandName, _ := getVal("command", c)
+	userName, _ := getVal("user", c)
+	profile, _ := getVal("profile", c)
+
+	matched := []*journalEntry{}
+	for _, entry := range entries {
+		if commandName != "" && entry.Command != commandName ||
+			userName != "" && entry.User != userName && entry.OpsgenieUser != userName ||
+			profile != "" && entry.Profile != profile {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, entry.Time); !since.IsZero() && (err != nil || t.Before(since)) {
+			continue
+		}
+		if c.Bool("undoable") {
+			if _, err := inverseOf(entry); err != nil || entry.UndoneBy != 0 || entry.Outcome == outcomeFailed {
+				continue
+			}
+		}
+		matched = append(matched, entry)
+	}
+	limit := defaultHistoryMax
+	if c.IsSet("limit") {
+		limit = c.Int("limit")
+	}
+	if limit > 0 && len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+	renderResult(c, matched)
+}
+
+// inverseRequest reverses the request of a journal entry with the SDK and returns the request id or the result message.
+type inverseRequest struct {
+	description string
+	send        func(c *gcli.Context) (string, error)
+	// async is set when the inverse request is processed asynchronously, e.g. an alert action
+	async bool
+}
+
+var (
+	alertActionPath = regexp.MustCompile(`^/v2/alerts/([^/]+)/(acknowledge|tags|details)$`)
+	togglePath      = regexp.MustCompile(`^/v2/(heartbeats|integrations|policies)/([^/]+)/(enable|disable)$`)
+	overridePath    = regexp.MustCompile(`^/v2/schedules/([^/]+)/overrides$`)
+
+	toggleKinds = map[string]string{"heartbeats": "heartbeat", "integrations": "integration", "policies": "policy"}
+)
+
+/*
+inverseOf returns the request reversing the request of a journal entry. acknowledge is reversed by
+unacknowledge, adding tags and details by removing them, enabling a heartbeat, integration or
+policy by disabling it and the other way around, and creating a schedule override by deleting it.
+*/
+func inverseOf(entry *journalEntry) (*inverseRequest, error) {
+	if entry.Method != http.MethodPost {
+		return nil, newError(ExitCodeUsage, "Entry "+strconv.Itoa(entry.ID)+" ("+entry.Method+" "+entry.Path+") can not be undone")
+	}
+	values, _ := url.ParseQuery(entry.Query)
+	query := map[string]string{}
+	for key := range values {
+		query[key] = values.Get(key)
+	}
+	var body map[string]interface{}
+	json.Unmarshal(entry.Body, &body)
+	note := "Undo of lamp journal entry " + strconv.Itoa(entry.ID)
+
+	if match := alertActionPath.FindStringSubmatch(entry.Path); match != nil {
+		action := AlertActionOptions{AlertIdentifier: AlertIdentifier{ID: match[1], IdentifierType: query["identifierType"]},
+			Source: "lamp", Note: note}
+		identifier := match[1]
+		switch match[2] {
+		case "acknowledge":
+			return &inverseRequest{description: "unacknowledge alert " + identifier, async: true,
+				send: func(c *gcli.Context) (string, error) {
+					action.User = grabUsername(c)
+					return alertRequestID(cliAPI(c).UnAcknowledge(rootContext(), action))
+				}}, nil
+		case "tags":
+			tags := joinStrings(body["tags"])
+			return &inverseRequest{description: "remove tags " + tags + " from alert " + identifier, async: true,
+				send: func(c *gcli.Context) (string, error) {
+					action.User = grabUsername(c)
+					return alertRequestID(cliAPI(c).RemoveTags(rootContext(), RemoveTagsOptions{AlertActionOptions: action, Tags: []string{tags}}))
+				}}, nil
+		case "details":
+			keys := []string{}
+			if details, ok := body["details"].(map[string]interface{}); ok {
+				for key := range details {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			return &inverseRequest{description: "remove details " + strings.Join(keys, ",") + " from alert " + identifier, async: true,
+				send: func(c *gcli.Context) (string, error) {
+					action.User = grabUsername(c)
+					return alertRequestID(cliAPI(c).RemoveDetails(rootContext(), RemoveDetailsOptions{AlertActionOptions: action, Keys: keys}))
+				}}, nil
+		}
+	}
+
+	if match := togglePath.FindStringSubmatch(entry.Path); match != nil {
+		kind, identifier, enable := match[1], match[2], match[3] == "disable"
+		action := "disable"
+		if enable {
+			action = "enable"
+		}
+		return &inverseRequest{description: action + " " + toggleKinds[kind] + " " + identifier,
+			send: func(c *gcli.Context) (string, error) {
+				return toggle(c, kind, identifier, query["teamId"], enable)
+			}}, nil
+	}
+
+	if match := overridePath.FindStringSubmatch(entry.Path); match != nil {
+		alias, _ := body["alias"].(string)
+		var result map[string]interface{}
+		if json.Unmarshal(entry.Result, &result) == nil && alias == "" {
+			alias, _ = result["alias"].(string)
+		}
+		if alias == "" {
+			return nil, newError(ExitCodeUsage, "Entry "+strconv.Itoa(entry.ID)+" does not have the alias of the created override")
+		}
+		override := ScheduleOverrideOptions{ScheduleIdentifier: ScheduleIdentifier{ID: match[1], IdentifierType: query["scheduleIdentifierType"]},
+			Alias: alias}
+		return &inverseRequest{description: "delete override " + alias + " of schedule " + match[1],
+			send: func(c *gcli.Context) (string, error) {
+				_, err := cliAPI(c).DeleteScheduleOverride(rootContext(), override)
+				return "Override " + alias + " is deleted", err
+			}}, nil
+	}
+	return nil, newError(ExitCodeUsage, "Entry "+strconv.Itoa(entry.ID)+" ("+entry.Method+" "+entry.Path+") can not be undone, "+
+		"only acknowledge, addTags, addDetails, enable, disable and createScheduleOverride can be undone")
+}
+
+func toggle(c *gcli.Context, kind string, identifier string, teamID string, enable bool) (string, error) {
+	api := cliAPI(c)
+	var err error
+	switch kind {
+	case "heartbeats":
+		if enable {
+			_, err = api.EnableHeartbeat(rootContext(), HeartbeatIdentifier{Name: identifier})
+		} else {
+			_, err = api.DisableHeartbeat(rootContext(), HeartbeatIdentifier{Name: identifier})
+		}
+	case "integrations":
+		if enable {
+			_, err = api.EnableIntegration(rootContext(), IntegrationIdentifier{ID: identifier})
+		} else {
+			_, err = api.DisableIntegration(rootContext(), IntegrationIdentifier{ID: identifier})
+		}
+	case "policies":
+		// the type is only validated by the SDK, team policies are sent as notification policies which require the team
+		opts := PolicyIdentifier{ID: identifier, TeamID: teamID, PolicyType: string(policy.AlertPolicy)}
+		if teamID != "" {
+			opts.PolicyType = string(policy.NotificationPolicy)
+		}
+		if enable {
+			_, err = api.EnablePolicy(rootContext(), opts)
+		} else {
+			_, err = api.DisablePolicy(rootContext(), opts)
+		}
+	}
+	if enable {
+		return strings.Title(toggleKinds[kind]) + " " + identifier + " is enabled", err
+	}
+	return strings.Title(toggleKinds[kind]) + " " + identifier + " is disabled", err
+}
+
+func joinStrings(value interface{}) string {
+	items, _ := value.([]interface{})
+	strs := make([]string, 0, len(items))
+	for _, item := range items {
+		strs = append(strs, formatCell(item))
+	}
+	return strings.Join(strs, ",")
+}
+
+// UndoAction reverses the request of a journal entry, the undo is recorded in the journal as well.
+func UndoAction(c *gcli.Context) {
+	idArg := c.Args().First()
+	if val, success := getVal("id", c); success {
+		idArg = val
+	}
+	id, err := strconv.Atoi(idArg)
+	if err != nil {
+		exitOnUsageErr("Id of the journal entry should be given, e.g. lamp undo 12. Run lamp history to list the entries")
+	}
+	entries := openJournal(c)
+	entry := findJournalEntry(entries, id)
+	if entry == nil {
+		exitOnErr(newError(ExitCodeNotFound, "Journal entry "+idArg+" is not found"))
+	}
+	if entry.Outcome == outcomeFailed {
+		exitOnUsageErr("Entry " + idArg + " has failed, there is nothing to undo")
+	}
+	if entry.UndoneBy != 0 {
+		exitOnUsageErr("Entry " + idArg + " is already undone by entry " + strconv.Itoa(entry.UndoneBy))
+	}
+	inverse, err := inverseOf(entry)
+	exitOnErr(err)
+	if entry.Profile != cfg.Profile() {
+		exitOnUsageErr("Entry " + idArg + " is made with the profile " + entry.Profile + ", give --profile " + entry.Profile + " to undo it")
+	}
+
+	journal.mu.Lock()
+	journal.undo = id
+	journal.mu.Unlock()
+	printMessage(DEBUG, "Will "+inverse.description+" to undo entry "+idArg)
+	result, err := inverse.send(c)
+	exitOnErr(err)
+	if inverse.async {
+		printAlertRequest(c, cliAPI(c), result, "Undo of entry "+idArg+" ("+inverse.description+") is accepted, requestId: "+result)
+		return
+	}
+	printResultMessage(c, result+", entry "+idArg+" is undone")
+}
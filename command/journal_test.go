@@ -0,0 +1,208 @@
+package command
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-lamp/mockserver"
+	"github.com/sirupsen/logrus"
+)
+
+func TestInverseOf(t *testing.T) {
+	reversible := []struct {
+		entry       journalEntry
+		description string
+		async       bool
+	}{
+		{journalEntry{Method: http.MethodPost, Path: "/v2/alerts/disk-full/acknowledge", Query: "identifierType=alias"}, "unacknowledge alert disk-full", true},
+		{journalEntry{Method: http.MethodPost, Path: "/v2/alerts/a1/tags", Body: []byte(`{"tags":["disk","prod"]}`)}, "remove tags disk,prod from alert a1", true},
+		{journalEntry{Method: http.MethodPost, Path: "/v2/alerts/a1/details", Body: []byte(`{"details":{"region":"eu","host":"db1"}}`)}, "remove details host,region from alert a1", true},
+		{journalEntry{Method: http.MethodPost, Path: "/v2/heartbeats/nightly/enable"}, "disable heartbeat nightly", false},
+		{journalEntry{Method: http.MethodPost, Path: "/v2/integrations/i1/disable"}, "enable integration i1", false},
+		{journalEntry{Method: http.MethodPost, Path: "/v2/policies/p1/disable", Query: "teamId=t1"}, "enable policy p1", false},
+		// the alias of an override is given in the request or generated and returned in the result
+		{journalEntry{Method: http.MethodPost, Path: "/v2/schedules/primary/overrides", Body: []byte(`{"alias":"vacation"}`)}, "delete override vacation of schedule primary", false},
+		{journalEntry{Method: http.MethodPost, Path: "/v2/schedules/primary/overrides", Result: []byte(`{"alias":"generated"}`)}, "delete override generated of schedule primary", false},
+	}
+	for _, test := range reversible {
+		inverse, err := inverseOf(&test.entry)
+		if err != nil {
+			t.Errorf("inverseOf(%s %s) returned %v", test.entry.Method, test.entry.Path, err)
+			continue
+		}
+		if inverse.description != test.description || inverse.async != test.async {
+			t.Errorf("inverseOf(%s %s) is %q async %v, want %q async %v",
+				test.entry.Method, test.entry.Path, inverse.description, inverse.async, test.description, test.async)
+		}
+	}
+
+	irreversible := []journalEntry{
+		{Method: http.MethodPost, Path: "/v2/schedules/primary/overrides"},
+		{Method: http.MethodDelete, Path: "/v2/alerts/a1"},
+		{Method: http.MethodPost, Path: "/v2/alerts/a1/close"},
+	}
+	for _, entry := range irreversible {
+		if _, err := inverseOf(&entry); exitCode(err) != ExitCodeUsage {
+			t.Errorf("inverseOf(%s %s) returned %v, want a usage error", entry.Method, entry.Path, err)
+		}
+	}
+}
+
+func TestReadJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.jsonl")
+
+	if entries, err := readJournal(path); err != nil || entries != nil {
+		t.Fatalf("reading a missing journal returned %v, %v, want no entries", entries, err)
+	}
+	ioutil.WriteFile(path, []byte(strings.Join([]string{
+		`{"command":"enableHeartbeat","outcome":"succeeded"}`,
+		`not json`,
+		`{"command":"disableHeartbeat","outcome":"failed","undo":1}`,
+		`{"command":"disableHeartbeat","outcome":"succeeded","undo":1}`,
+	}, "\n")+"\n"), 0600)
+
+	entries, err := readJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the ids are the line numbers, a failed undo does not undo the entry
+	if len(entries) != 3 || entries[0].ID != 1 || entries[1].ID != 3 || entries[2].ID != 4 {
+		t.Fatalf("read %d entries %+v", len(entries), entries)
+	}
+	if entries[0].UndoneBy != 4 {
+		t.Errorf("entry 1 is undone by %d, want 4", entries[0].UndoneBy)
+	}
+	if found := findJournalEntry(entries, 3); found != entries[1] || findJournalEntry(entries, 2) != nil {
+		t.Errorf("findJournalEntry found %+v for id 3", found)
+	}
+}
+
+func TestJournalRecordsMutatingRequests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.jsonl")
+	server := httptest.NewServer(mockserver.New(mockserver.Options{}))
+	defer server.Close()
+
+	withSettings(map[string]string{"journalPath": path}, func() {
+		c := newSettingsContext(t, map[string]string{"user": "jane@example.com"})
+		c.Command.Name = "acknowledge"
+		logger := logrus.New()
+		logger.Out = ioutil.Discard
+		config := &client.Config{
+			ApiKey:         "key",
+			OpsGenieAPIURL: client.ApiUrl(strings.TrimPrefix(server.URL, "http://")),
+			HttpClient:     &http.Client{},
+			Logger:         logger,
+		}
+		enableJournal(c, config)
+		defer func() { journal.path = "" }()
+		cli, err := alert.NewClient(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		created, err := cli.Create(context.Background(), &alert.CreateAlertRequest{Message: "Disk is full", Alias: "disk-full"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cli.Get(context.Background(), &alert.GetAlertRequest{IdentifierType: alert.ALIAS, IdentifierValue: "disk-full"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cli.Acknowledge(context.Background(), &alert.AcknowledgeAlertRequest{IdentifierType: alert.ALIAS, IdentifierValue: "disk-full"}); err != nil {
+			t.Fatal(err)
+		}
+
+		entries, err := readJournal(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the get request is not recorded
+		if len(entries) != 2 {
+			t.Fatalf("journal has %d entries, want the create and acknowledge requests", len(entries))
+		}
+		if entries[0].Path != "/v2/alerts" || entries[0].RequestID != created.RequestId || entries[0].Outcome != outcomeAccepted ||
+			entries[0].OpsgenieUser != "jane@example.com" || entries[0].Command != "acknowledge" {
+			t.Errorf("first entry is %+v", entries[0])
+		}
+		inverse, err := inverseOf(entries[1])
+		if err != nil || inverse.description != "unacknowledge alert disk-full" {
+			t.Errorf("second entry %+v is reversed with %v, %v", entries[1], inverse, err)
+		}
+	})
+}
+
+func TestJournalRecordsTheCommandOfEachLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.jsonl")
+	server := httptest.NewServer(mockserver.New(mockserver.Options{}))
+	defer server.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	withSettings(map[string]string{"journalPath": path}, func() {
+		c := newSettingsContext(t, nil)
+		c.Command.Name = "batch"
+		logger := logrus.New()
+		logger.Out = ioutil.Discard
+		newConfig := func(url string) *client.Config {
+			config := &client.Config{
+				ApiKey:         "key",
+				OpsGenieAPIURL: client.ApiUrl(strings.TrimPrefix(url, "http://")),
+				HttpClient:     &http.Client{},
+				Logger:         logger,
+				RetryCount:     1,
+			}
+			enableJournal(c, config)
+			return config
+		}
+		defer func() { journal.path = "" }()
+
+		// the lines of a batch share the configuration and the clients, each records its own command
+		config, clients := newConfig(server.URL), newSDKClients()
+		if _, err := (&API{config: config, command: "createAlert", clients: clients}).CreateAlert(context.Background(), CreateAlertOptions{Message: "Disk is full"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (&API{config: config, clients: clients}).CreateAlert(context.Background(), CreateAlertOptions{Message: "CPU is high"}); err != nil {
+			t.Fatal(err)
+		}
+		// a request without a response is recorded as failed for every try
+		if _, err := (&API{config: newConfig(unreachable.URL), command: "closeAlert", clients: newSDKClients()}).CloseAlert(context.Background(), AlertActionOptions{AlertIdentifier: AlertIdentifier{ID: "a1"}}); err == nil {
+			t.Fatal("closing an alert on a closed server succeeded")
+		}
+
+		entries, err := readJournal(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var recorded []string
+		for _, entry := range entries {
+			recorded = append(recorded, entry.Command+" "+entry.Outcome)
+		}
+		if got := strings.Join(recorded, ", "); got != "createAlert accepted, batch accepted, closeAlert failed, closeAlert failed" {
+			t.Errorf("journal has %s", got)
+		}
+		if last := entries[len(entries)-1]; last.Path != "/v2/alerts/a1/close" || !strings.Contains(last.Message, "connection refused") {
+			t.Errorf("failed request is recorded as %+v", last)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/logs"
+)
+
+type DownloadLogsOptions struct {
+	// Start is the name of the file to start after, e.g. 2019-06-10-12-00-00.
+	Start string
+	// End is the date to stop at, e.g. 2019-06-11-12.
+	End string
+	// Path is the directory of the downloaded files, the working directory if it is empty.
+	Path string
+	// Limit is the number of files listed by a request, 1000 if it is 0.
+	Limit int
+}
+
+// DownloadLogsResult lists the downloaded files and the files which could not be downloaded.
+type DownloadLogsResult struct {
+	// Downloaded are the paths of the downloaded files.
+	Downloaded []string
+	// Failed are the errors of the files whose download link could not be created, by file name. The other files are still downloaded.
+	Failed map[string]error
+}
+
+// DownloadLogs downloads the log files of the account, the result lists the downloaded files when it fails too.
+func (a *API) DownloadLogs(ctx context.Context, opts DownloadLogsOptions) (*DownloadLogsResult, error) {
+	cli, release, err := a.logsClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	path := opts.Path
+	if path == "" {
+		path = "."
+	}
+	limit := opts.Limit
+	if limit == 0 {
+		limit = 1000
+	}
+	req := logs.ListLogFilesRequest{Marker: opts.Start, Limit: limit}
+	result := &DownloadLogsResult{Failed: map[string]error{}}
+	for {
+		response, err := cli.ListLogFiles(ctx, &req)
+		if err != nil {
+			return result, err
+		}
+		if response.Marker == "" {
+			return result, nil
+		}
+		req.Marker, err = downloadLogFiles(ctx, cli, downloadClient(a.config), response.Logs, opts.End, path, result)
+		if err != nil || req.Marker == "" {
+			return result, err
+		}
+	}
+}
+
+// downloadLogFiles downloads the files of a page until the end date, it returns the marker of the next page.
+func downloadLogFiles(ctx context.Context, cli *logs.Client, httpClient *http.Client, receivedLogs []logs.Log, endDate string, path string, result *DownloadLogsResult) (string, error) {
+	currentFileDate := ""
+	for _, log := range receivedLogs {
+		// the files downloaded before an interruption are kept, the file being downloaded is removed
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		downloadResponse, err := cli.GenerateLogFileDownloadLink(ctx, &logs.GenerateLogFileDownloadLinkRequest{
+			FileName: log.FileName,
+		})
+		if err != nil {
+			result.Failed[log.FileName] = err
+			continue
+		}
+		currentFileDate = log.FileName[:len(log.FileName)-5]
+		if endDate != "" && !checkDate(endDate, currentFileDate) {
+			return "", nil
+		}
+		filePath := filepath.Join(path, log.FileName)
+		if err := downloadFileSafely(ctx, httpClient, filePath, downloadResponse.LogFileDownloadLink); err != nil {
+			return "", err
+		}
+		result.Downloaded = append(result.Downloaded, filePath)
+	}
+	return currentFileDate, nil
+}
@@ -0,0 +1,434 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opsgenie/opsgenie-lamp/cfg"
+	"github.com/sirupsen/logrus"
+	gcli "github.com/urfave/cli"
+)
+
+const (
+	textLogFormat = "text"
+	jsonLogFormat = "json"
+
+	logFileName          = "lamp.log"
+	rotatedLogPrefix     = "lamp-"
+	rotatedLogTimeFormat = "2006-01-02T15-04-05.000"
+
+	defaultLogMaxSize    = 10 << 20
+	defaultLogRotateAge  = 24 * time.Hour
+	defaultLogMaxBackups = 7
+)
+
+// logLevelOrder orders the levels, messages below the configured level are not logged.
+var logLevelOrder = map[LogLevel]int{DEBUG: 0, INFO: 1, WARN: 2, ERROR: 3}
+
+// logFields are the fields of a log entry besides its time, level and message, e.g. requestId.
+type logFields map[string]interface{}
+
+/*
+lampLogger writes the messages of lamp to the standard error and, when logPath is configured, to
+logPath/lamp.log in text or json format. The log file is kept open for the life of the command
+and is rotated to lamp-<time>.log when it grows over logMaxSize or gets older than logRotateAge;
+logMaxBackups rotated files are kept and those older than logMaxAge are removed. Command output
+is written to the standard output and never to the log.
+*/
+type lampLogger struct {
+	mu     sync.Mutex
+	level  LogLevel
+	format string
+	// fields are added to every entry of the log file, e.g. the command and the profile
+	fields logFields
+	start  time.Time
+
+	dir        string
+	file       *os.File
+	size       int64
+	opened     time.Time
+	maxSize    int64
+	rotateAge  time.Duration
+	maxBackups int
+	maxAge     time.Duration
+}
+
+var lampLog = &lampLogger{level: INFO, format: textLogFormat, fields: logFields{}, start: time.Now()}
+
+// configureLogging reads the level, format, log path and rotation settings of the log from the configuration.
+func configureLogging(c *gcli.Context) {
+	level := INFO
+	switch strings.ToLower(cfg.Get("lamp.log.level")) {
+	case "trace", "debug":
+		level = DEBUG
+	case "warn", "warning":
+		level = WARN
+	case "error", "fatal", "panic":
+		level = ERROR
+	}
+	if verbose {
+		level = DEBUG
+	}
+	format := strings.ToLower(cfg.Get("logFormat"))
+	switch format {
+	case "":
+		format = textLogFormat
+	case textLogFormat, jsonLogFormat:
+	default:
+		exitOnErr(newError(ExitCodeConfiguration, "Invalid logFormat value "+format+", specify text or json"))
+	}
+	maxSize := int64(defaultLogMaxSize)
+	if val := cfg.Get("logMaxSize"); val != "" {
+		size, err := parseSize(val)
+		if err != nil {
+			exitOnErr(newError(ExitCodeConfiguration, "Invalid logMaxSize value "+val+". Give the number of bytes or a size such as 10MB."))
+		}
+		maxSize = size
+	}
+	rotateAge, set := grabDuration("logRotateAge", c)
+	if !set {
+		rotateAge = defaultLogRotateAge
+	}
+	maxAge, _ := grabDuration("logMaxAge", c)
+	maxBackups, set := grabInt("logMaxBackups", c)
+	if !set {
+		maxBackups = defaultLogMaxBackups
+	}
+
+	lampLog.mu.Lock()
+	defer lampLog.mu.Unlock()
+	lampLog.level = level
+	lampLog.format = format
+	lampLog.fields = logFields{"command": c.Command.Name}
+	if profile := cfg.Profile(); profile != "" {
+		lampLog.fields["profile"] = profile
+	}
+	if dir := cfg.Get("logPath"); dir != lampLog.dir {
+		lampLog.closeFile()
+		lampLog.dir = dir
+	}
+	lampLog.maxSize = maxSize
+	lampLog.rotateAge = rotateAge
+	lampLog.maxBackups = maxBackups
+	lampLog.maxAge = maxAge
+}
+
+// parseSize parses a number of bytes with an optional KB, MB or GB suffix.
+func parseSize(val string) (int64, error) {
+	val = strings.ToUpper(strings.TrimSpace(val))
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if strings.HasSuffix(val, suffix) {
+			val, multiplier = strings.TrimSpace(strings.TrimSuffix(val, suffix)), m
+		}
+	}
+	size, err := strconv.ParseInt(strings.TrimSuffix(val, "B"), 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid size %s", val)
+	}
+	return size * multiplier, nil
+}
+
+func (l *lampLogger) enabled(level LogLevel) bool {
+	return verbose || logLevelOrder[level] >= logLevelOrder[l.level]
+}
+
+// log writes a message to the standard error and to the log file if its level is enabled.
+func (l *lampLogger) log(level LogLevel, message string, fields logFields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.enabled(level) {
+		return
+	}
+	// the standard error only has the JSON errors when error-format is json
+	if level != INFO || errorFormat != jsonErrorFormat {
+		l.writeConsole(level, message, fields)
+	}
+	l.writeFile(level, message, fields)
+}
+
+// logResult writes a message reporting the result of a command, which is printed whatever the level is.
+func (l *lampLogger) logResult(message string, fields logFields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writeConsole(INFO, message, fields)
+	l.writeFile(INFO, message, fields)
+}
+
+// logFile writes a message to the log file only, e.g. an error already printed in JSON.
+func (l *lampLogger) logFile(level LogLevel, message string, fields logFields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.enabled(level) {
+		l.writeFile(level, message, fields)
+	}
+}
+
+func (l *lampLogger) writeConsole(level LogLevel, message string, fields logFields) {
+	if l.format == jsonLogFormat {
+		fmt.Fprintln(os.Stderr, l.formatJSON(level, message, fields, nil))
+		return
+	}
+	// the fields are only written to the log file in text format, the standard error stays readable
+	log.Println(string(level) + " : " + message)
+}
+
+func (l *lampLogger) writeFile(level LogLevel, message string, fields logFields) {
+	if l.dir == "" {
+		return
+	}
+	var line string
+	if l.format == jsonLogFormat {
+		line = l.formatJSON(level, message, l.fields, fields)
+	} else {
+		line = "time=" + time.Now().Format(time.RFC3339Nano) + " level=" + strings.ToLower(string(level)) +
+			" msg=" + logfmtValue(message)
+		if len(l.fields) > 0 {
+			line += " " + formatLogfmt(l.fields)
+		}
+		if len(fields) > 0 {
+			line += " " + formatLogfmt(fields)
+		}
+	}
+	if err := l.write(line + "\n"); err != nil {
+		log.Println(string(ERROR) + " : Could not write to the log file: " + err.Error())
+		l.closeFile()
+		l.dir = ""
+	}
+}
+
+func (l *lampLogger) formatJSON(level LogLevel, message string, fields logFields, extra logFields) string {
+	entry := map[string]interface{}{}
+	for _, f := range []logFields{fields, extra} {
+		for key, value := range f {
+			entry[key] = value
+		}
+	}
+	entry["time"] = time.Now().Format(time.RFC3339Nano)
+	entry["level"] = strings.ToLower(string(level))
+	entry["msg"] = message
+	b, _ := json.Marshal(entry)
+	return string(b)
+}
+
+// formatLogfmt formats fields as key=value pairs sorted by key.
+func formatLogfmt(fields logFields) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + logfmtValue(fmt.Sprint(fields[key]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// write appends a line to the log file, opening or rotating the file when needed.
+func (l *lampLogger) write(line string) error {
+	path := filepath.Join(l.dir, logFileName)
+	if l.file != nil {
+		// another lamp process may have rotated the file
+		current, err := os.Stat(path)
+		opened, openedErr := l.file.Stat()
+		if err != nil || openedErr != nil || !os.SameFile(current, opened) {
+			l.closeFile()
+		}
+	}
+	if l.file == nil {
+		if err := l.openFile(path); err != nil {
+			return err
+		}
+	}
+	if l.size > 0 && (l.size+int64(len(line)) > l.maxSize || l.rotateAge > 0 && time.Since(l.opened) > l.rotateAge) {
+		if err := l.rotate(path); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.WriteString(line)
+	l.size += int64(n)
+	return err
+}
+
+func (l *lampLogger) openFile(path string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file, l.size, l.opened = file, info.Size(), firstEntryTime(path)
+	return nil
+}
+
+// firstEntryTime returns the time of the first entry of a log file, which is when the file was started.
+func firstEntryTime(path string) time.Time {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Now()
+	}
+	defer file.Close()
+	line, _ := bufio.NewReader(file).ReadString('\n')
+	var entry struct {
+		Time time.Time `json:"time"`
+	}
+	if json.Unmarshal([]byte(line), &entry) == nil && !entry.Time.IsZero() {
+		return entry.Time
+	}
+	if strings.HasPrefix(line, "time=") {
+		if t, err := time.Parse(time.RFC3339Nano, strings.Fields(strings.TrimPrefix(line, "time="))[0]); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// rotate renames the log file to lamp-<time of its first entry>.log, starts a new file and removes the old rotated files.
+func (l *lampLogger) rotate(path string) error {
+	l.closeFile()
+	rotated := filepath.Join(l.dir, rotatedLogPrefix+l.opened.UTC().Format(rotatedLogTimeFormat)+".log")
+	if err := os.Rename(path, rotated); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	l.removeOldFiles()
+	return l.openFile(path)
+}
+
+func (l *lampLogger) removeOldFiles() {
+	files, err := ioutil.ReadDir(l.dir)
+	if err != nil {
+		return
+	}
+	var rotated []os.FileInfo
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), rotatedLogPrefix) && strings.HasSuffix(file.Name(), ".log") {
+			rotated = append(rotated, file)
+		}
+	}
+	// the names sort in time order, the newest files are kept
+	sort.Slice(rotated, func(i, j int) bool {
+		return rotated[i].Name() > rotated[j].Name()
+	})
+	for i, file := range rotated {
+		if i >= l.maxBackups || l.maxAge > 0 && time.Since(file.ModTime()) > l.maxAge {
+			os.Remove(filepath.Join(l.dir, file.Name()))
+		}
+	}
+}
+
+func (l *lampLogger) closeFile() {
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// quietLogger discards the logs of the SDK, e.g. the configuration it logs when a client is created.
+var quietLogger = &logrus.Logger{Out: ioutil.Discard, Formatter: new(logrus.TextFormatter), Hooks: logrus.LevelHooks{}, Level: logrus.PanicLevel}
+
+// newSDKLogger creates the logger of the SDK clients, which writes in the format of lamp and to the log file of lamp as well.
+func newSDKLogger(level logrus.Level) *logrus.Logger {
+	logger := logrus.New()
+	if level != logrus.Level(0) {
+		logger.SetLevel(level)
+	}
+	if lampLog.format == jsonLogFormat {
+		logger.SetFormatter(&logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap:        logrus.FieldMap{logrus.FieldKeyMsg: "msg"},
+		})
+	} else {
+		logger.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp:   true,
+			TimestampFormat: time.RFC3339Nano,
+		})
+	}
+	if errorFormat == jsonErrorFormat {
+		// the errors are printed in JSON by lamp, the entries of the SDK are only written to the log file
+		logger.Out = ioutil.Discard
+	}
+	logger.AddHook(sdkLogHook{})
+	return logger
+}
+
+// sdkLogHook copies the entries of the SDK logger to the log file.
+type sdkLogHook struct{}
+
+func (sdkLogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (sdkLogHook) Fire(entry *logrus.Entry) error {
+	fields := logFields{"source": "sdk"}
+	for key, value := range entry.Data {
+		fields[key] = value
+	}
+	lampLog.logFile(lampLevel(entry.Level), entry.Message, fields)
+	return nil
+}
+
+// newCLILogger creates the logger of the API of the commands, its entries are messages of lamp logged like printMessage.
+func newCLILogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	logger.SetLevel(logrus.DebugLevel)
+	logger.AddHook(cliLogHook{})
+	return logger
+}
+
+// cliLogHook writes the entries of the logger of the API to the standard error and the log file of lamp.
+type cliLogHook struct{}
+
+func (cliLogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (cliLogHook) Fire(entry *logrus.Entry) error {
+	var fields logFields
+	if len(entry.Data) != 0 {
+		fields = logFields{}
+		for key, value := range entry.Data {
+			fields[key] = value
+		}
+	}
+	lampLog.log(lampLevel(entry.Level), entry.Message, fields)
+	return nil
+}
+
+func lampLevel(level logrus.Level) LogLevel {
+	switch {
+	case level <= logrus.ErrorLevel:
+		return ERROR
+	case level == logrus.WarnLevel:
+		return WARN
+	case level == logrus.InfoLevel:
+		return INFO
+	}
+	return DEBUG
+}
+
+// commandDuration is the time elapsed since lamp started, logged with errors.
+func commandDuration() string {
+	return time.Since(lampLog.start).Round(time.Millisecond).String()
+}
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	gcli "github.com/urfave/cli"
+)
+
+func newTestLogger(t *testing.T, format string) (*lampLogger, string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "lamp-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &lampLogger{
+		level:      INFO,
+		format:     format,
+		fields:     logFields{"command": "createAlert"},
+		dir:        dir,
+		maxSize:    defaultLogMaxSize,
+		rotateAge:  defaultLogRotateAge,
+		maxBackups: defaultLogMaxBackups,
+	}, dir
+}
+
+func readLogLines(t *testing.T, path string) []string {
+	t.Helper()
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(content)), "\n")
+}
+
+func TestLogFileFormats(t *testing.T) {
+	logger, dir := newTestLogger(t, textLogFormat)
+	defer os.RemoveAll(dir)
+	logger.logFile(INFO, "Alert is created", logFields{"requestId": "r1"})
+	logger.logFile(DEBUG, "Request is prepared", nil)
+	logger.format = jsonLogFormat
+	logger.logFile(ERROR, "Alert does not exist", logFields{"status": 404})
+	logger.closeFile()
+
+	lines := readLogLines(t, filepath.Join(dir, logFileName))
+	if len(lines) != 2 {
+		t.Fatalf("log has the lines %q, want the debug message left out", lines)
+	}
+	if want := ` level=info msg="Alert is created" command=createAlert requestId=r1`; !strings.HasPrefix(lines[0], "time=") || !strings.HasSuffix(lines[0], want) {
+		t.Errorf("text entry is %q, want it to end with %q", lines[0], want)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("json entry %q: %v", lines[1], err)
+	}
+	if entry["level"] != "error" || entry["msg"] != "Alert does not exist" || entry["command"] != "createAlert" || entry["status"] != float64(404) {
+		t.Errorf("json entry is %v", entry)
+	}
+}
+
+func TestPrintResultWritesTheCommandOutput(t *testing.T) {
+	logger, dir := newTestLogger(t, jsonLogFormat)
+	defer os.RemoveAll(dir)
+	defaultLog := lampLog
+	lampLog = logger
+	defer func() { lampLog = defaultLog }()
+
+	var output bytes.Buffer
+	app := gcli.NewApp()
+	app.Writer = &output
+	stderr := captureStderr(t, func() {
+		printResult(gcli.NewContext(app, nil, nil), "RequestID: r1\n", logFields{"requestId": "r1"})
+	})
+	logger.closeFile()
+
+	if output.String() != "RequestID: r1\n" || stderr != "" {
+		t.Errorf("printed %q to the output and %q to the standard error", output.String(), stderr)
+	}
+	if lines := readLogLines(t, filepath.Join(dir, logFileName)); len(lines) != 1 || !strings.Contains(lines[0], `"requestId":"r1"`) {
+		t.Errorf("log has the lines %q, want the result with its request id", lines)
+	}
+}
+
+func TestLogFileRotation(t *testing.T) {
+	logger, dir := newTestLogger(t, jsonLogFormat)
+	defer os.RemoveAll(dir)
+	logger.maxSize = 300
+	logger.maxBackups = 2
+
+	// each entry is about 100 bytes, so the file is rotated every few entries
+	for i := 0; i < 20; i++ {
+		logger.logFile(INFO, "Alert is created", logFields{"requestId": strings.Repeat("r", 10)})
+		// rotated files are named after the time of their first entry, which has a millisecond precision
+		time.Sleep(2 * time.Millisecond)
+	}
+	logger.closeFile()
+
+	files, _ := ioutil.ReadDir(dir)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+		if file.Size() > logger.maxSize {
+			t.Errorf("%s has %d bytes, more than logMaxSize", file.Name(), file.Size())
+		}
+	}
+	sort.Strings(names)
+	if len(names) != 3 || !strings.HasPrefix(names[0], rotatedLogPrefix) || !strings.HasPrefix(names[1], rotatedLogPrefix) || names[2] != logFileName {
+		t.Errorf("log directory has %q, want lamp.log and the 2 newest rotated files", names)
+	}
+}
+
+func TestLogFileRotationByAge(t *testing.T) {
+	logger, dir := newTestLogger(t, textLogFormat)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, logFileName)
+	started := time.Now().Add(-2 * defaultLogRotateAge)
+	ioutil.WriteFile(path, []byte("time="+started.Format(time.RFC3339Nano)+" level=info msg=old\n"), 0644)
+
+	logger.logFile(INFO, "new", nil)
+	logger.closeFile()
+
+	// the file is rotated as it was started longer than logRotateAge ago, its name is the time of its first entry
+	rotated := filepath.Join(dir, rotatedLogPrefix+started.UTC().Format(rotatedLogTimeFormat)+".log")
+	if lines := readLogLines(t, rotated); len(lines) != 1 || !strings.HasSuffix(lines[0], "msg=old") {
+		t.Errorf("rotated file has %q", lines)
+	}
+	if lines := readLogLines(t, path); len(lines) != 1 || !strings.Contains(lines[0], "msg=new") {
+		t.Errorf("new file has %q", lines)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	sizes := map[string]int64{"100": 100, "512B": 512, "10KB": 10 << 10, "10 mb": 10 << 20, "1GB": 1 << 30}
+	for val, want := range sizes {
+		if size, err := parseSize(val); err != nil || size != want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", val, size, err, want)
+		}
+	}
+	for _, val := range []string{"", "0", "-1KB", "ten"} {
+		if _, err := parseSize(val); err == nil {
+			t.Errorf("parseSize(%q) succeeded, want an error", val)
+		}
+	}
+}
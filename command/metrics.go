@@ -0,0 +1,391 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-lamp/cfg"
+)
+
+const (
+	fileLockTimeout = 2 * time.Second
+	// a lock file older than staleFileLock is left by a process which did not end properly
+	staleFileLock = 10 * time.Second
+)
+
+var (
+	subscribeMetricsOnce sync.Once
+	metricsMu            sync.Mutex
+
+	// durationBuckets are the upper bounds of the request duration histogram in seconds.
+	durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+	// resourcePathWords are the static segments of the API paths, other segments are identifiers
+	// and are replaced with {id} in the Prometheus labels to keep the number of series bounded.
+	resourcePathWords = map[string]bool{}
+
+	promHelp = map[string]string{
+		"lamp_http_requests_total":           "counter HTTP requests sent to the Opsgenie API by resource path and status code.",
+		"lamp_http_request_duration_seconds": "histogram Duration of the HTTP requests including retries by resource path.",
+		"lamp_http_retries_total":            "counter Retries of the HTTP requests by resource path.",
+		"lamp_sdk_errors_total":              "counter Requests failed before or after the HTTP request by resource path and error type.",
+	}
+)
+
+func init() {
+	for _, word := range strings.Fields(`v1 v2 account acknowledge actions alerts assign attachments audience-templates
+		cancel change-end-date change-order close contacts copy-to count create deployments description details disable
+		download enable escalate escalations forwarding-rules heartbeats incident-rules incident-templates incidents
+		integrations list logs maintenance members message next-on-calls notes notification-rules on-calls overrides ping
+		policies priority recipients reopen requests resolve responders roles rotations routing-rules saved-searches
+		schedules services snooze steps tags teams timeline unacknowledge users`) {
+		resourcePathWords[word] = true
+	}
+}
+
+// metricRecord is a line of the metricsNdjsonPath file, built from the metrics published by the SDK.
+type metricRecord struct {
+	Time           string  `json:"time"`
+	Type           string  `json:"type"`
+	TransactionID  string  `json:"transactionId"`
+	Method         string  `json:"method,omitempty"`
+	ResourcePath   string  `json:"resourcePath"`
+	StatusCode     int     `json:"statusCode,omitempty"`
+	DurationMillis int64   `json:"durationMs"`
+	RetryCount     int     `json:"retryCount,omitempty"`
+	RequestID      string  `json:"requestId,omitempty"`
+	Took           float32 `json:"took,omitempty"`
+	RateLimitState string  `json:"rateLimitState,omitempty"`
+	ErrorType      string  `json:"errorType,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+/*
+subscribeMetrics subscribes to the HTTP, API and SDK metrics of the SDK. The processed requests
+are logged at debug level with their request id and duration. When metricsNdjsonPath or
+metricsPromPath is configured, every metric is appended to metricsNdjsonPath as a JSON line, and
+the counters and histograms of metricsPromPath, a file for the textfile collector of the
+Prometheus node exporter, are updated. Writing metrics never fails a command.
+*/
+func subscribeMetrics() {
+	subscribeMetricsOnce.Do(func() {
+		ndjsonPath := cfg.Get("metricsNdjsonPath")
+		promPath := cfg.Get("metricsPromPath")
+		for _, metricType := range client.AvailableMetricTypes {
+			subscriber := client.MetricSubscriber{Process: func(metric client.Metric) interface{} {
+				record := newMetricRecord(metric)
+				if record == nil {
+					return nil
+				}
+				if record.Type == string(client.API) {
+					lampLog.log(DEBUG, "Request to "+record.ResourcePath+" is processed", logFields{
+						"requestId":  record.RequestID,
+						"duration":   (time.Duration(record.DurationMillis) * time.Millisecond).String(),
+						"statusCode": record.StatusCode,
+						"retryCount": record.RetryCount,
+					})
+				}
+				if ndjsonPath == "" && promPath == "" {
+					return nil
+				}
+				metricsMu.Lock()
+				defer metricsMu.Unlock()
+				if ndjsonPath != "" {
+					if err := appendMetricRecord(ndjsonPath, record); err != nil {
+						printMessage(DEBUG, "Could not write the metric to "+ndjsonPath+": "+err.Error())
+					}
+				}
+				if promPath != "" {
+					if err := updatePromFile(promPath, record); err != nil {
+						printMessage(DEBUG, "Could not update the metrics of "+promPath+": "+err.Error())
+					}
+				}
+				return nil
+			}}
+			subscriber.Register(metricType)
+		}
+	})
+}
+
+// newMetricRecord copies the fields of a metric, leaving out the request and response which hold the API key and the payloads.
+func newMetricRecord(metric client.Metric) *metricRecord {
+	record := &metricRecord{Time: time.Now().UTC().Format(time.RFC3339Nano), Type: metric.Type()}
+	switch m := metric.(type) {
+	case *client.HttpMetric:
+		record.TransactionID = m.TransactionId
+		record.ResourcePath = m.ResourcePath
+		record.StatusCode = m.StatusCode
+		record.DurationMillis = m.Duration
+		record.RetryCount = m.RetryCount
+		if m.HttpRequest.Request != nil && m.HttpRequest.Request.Request != nil {
+			record.Method = m.HttpRequest.Method
+		}
+		if m.Error != nil {
+			record.Error = m.Error.Error()
+		}
+	case *client.ApiMetric:
+		record.TransactionID = m.TransactionId
+		record.ResourcePath = m.ResourcePath
+		record.StatusCode = m.HttpResponse.StatusCode
+		record.DurationMillis = m.Duration
+		record.RetryCount = m.ResultMetadata.RetryCount
+		record.RequestID = m.ResultMetadata.RequestId
+		record.Took = m.ResultMetadata.ResponseTime
+		record.RateLimitState = m.ResultMetadata.RateLimitState
+	case *client.SdkMetric:
+		record.TransactionID = m.TransactionId
+		record.ResourcePath = m.ResourcePath
+		record.DurationMillis = m.Duration
+		record.ErrorType = m.ErrorType
+		record.Error = m.ErrorMessage
+	default:
+		return nil
+	}
+	return record
+}
+
+func appendMetricRecord(path string, record *metricRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// a single write keeps the lines of concurrent lamp processes apart
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// metricResourcePath strips the query and replaces the identifiers of a resource path with {id}, e.g. /v2/alerts/{id}/notes.
+func metricResourcePath(path string) string {
+	path = strings.SplitN(path, "?", 2)[0]
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment != "" && !resourcePathWords[segment] {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+/*
+updatePromFile adds a metric to the counters and histograms of a Prometheus textfile. The file
+is read, updated and replaced with a rename while holding a lock file, so the counters of
+concurrent lamp processes add up and the collector never reads a partially written file.
+*/
+func updatePromFile(path string, record *metricRecord) error {
+	if record.Type != string(client.HTTP) && (record.Type != string(client.SDK) || record.ErrorType == "") {
+		return nil
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	samples, err := readPromSamples(path)
+	if err != nil {
+		return err
+	}
+	resourcePath := metricResourcePath(record.ResourcePath)
+	switch record.Type {
+	case string(client.HTTP):
+		samples[promSeries("lamp_http_requests_total", "resource_path", resourcePath, "status_code", strconv.Itoa(record.StatusCode))]++
+		samples[promSeries("lamp_http_retries_total", "resource_path", resourcePath)] += float64(record.RetryCount)
+		seconds := float64(record.DurationMillis) / 1000
+		for _, bucket := range durationBuckets {
+			series := promSeries("lamp_http_request_duration_seconds_bucket", "resource_path", resourcePath, "le", formatPromValue(bucket))
+			samples[series] += 0
+			if seconds <= bucket {
+				samples[series]++
+			}
+		}
+		samples[promSeries("lamp_http_request_duration_seconds_bucket", "resource_path", resourcePath, "le", "+Inf")]++
+		samples[promSeries("lamp_http_request_duration_seconds_sum", "resource_path", resourcePath)] += seconds
+		samples[promSeries("lamp_http_request_duration_seconds_count", "resource_path", resourcePath)]++
+	case string(client.SDK):
+		samples[promSeries("lamp_sdk_errors_total", "resource_path", resourcePath, "error_type", record.ErrorType)]++
+	}
+	return writePromSamples(path, samples)
+}
+
+/*
+lockFile creates a lock file exclusively, waiting while another process holds it, and returns the function removing it.
+A stale lock is taken over by renaming it to a name of its own first, so that only one of the processes finding it
+removes it, and the lock renamed is checked again in case it was replaced by a live one in the meantime.
+*/
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(fileLockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			held, statErr := file.Stat()
+			file.Close()
+			return func() {
+				// the lock is only removed while it is still the one created, not one taking it over, which may reuse its inode
+				if info, err := os.Stat(path); err == nil && (statErr != nil || os.SameFile(info, held) && info.ModTime().Equal(held.ModTime())) {
+					os.Remove(path)
+				}
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleFileLock {
+			takeOverStaleLock(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by another process", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// takeOverStaleLock removes the stale lock file, a live lock created since it was found stale is put back.
+func takeOverStaleLock(path string) {
+	moved := fmt.Sprintf("%s.stale-%d-%d", path, os.Getpid(), time.Now().UnixNano())
+	if os.Rename(path, moved) != nil {
+		return
+	}
+	if info, err := os.Stat(moved); err == nil && time.Since(info.ModTime()) <= staleFileLock {
+		// linking fails when another lock is created at the path already
+		os.Link(moved, path)
+	}
+	os.Remove(moved)
+}
+
+func promSeries(name string, labels ...string) string {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[i+1])
+		pairs = append(pairs, labels[i]+`="`+value+`"`)
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatPromValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// readPromSamples reads the samples of a textfile written by updatePromFile, a missing file has no samples.
+func readPromSamples(path string) (map[string]float64, error) {
+	samples := map[string]float64{}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return samples, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		separator := strings.LastIndex(line, " ")
+		if line == "" || strings.HasPrefix(line, "#") || separator < 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(line[separator+1:], 64)
+		if err != nil {
+			continue
+		}
+		samples[line[:separator]] = value
+	}
+	return samples, scanner.Err()
+}
+
+func writePromSamples(path string, samples map[string]float64) error {
+	series := make([]string, 0, len(samples))
+	for s := range samples {
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return promSeriesLess(series[i], series[j])
+	})
+
+	var content strings.Builder
+	family := ""
+	for _, s := range series {
+		name := s[:strings.Index(s, "{")]
+		base := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(name, "_bucket"), "_sum"), "_count")
+		if _, ok := promHelp[base]; !ok {
+			base = name
+		}
+		if base != family {
+			family = base
+			if help, ok := promHelp[base]; ok {
+				parts := strings.SplitN(help, " ", 2)
+				fmt.Fprintf(&content, "# HELP %s %s\n# TYPE %s %s\n", base, parts[1], base, parts[0])
+			}
+		}
+		fmt.Fprintf(&content, "%s %s\n", s, formatPromValue(samples[s]))
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.WriteString(content.String()); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// promSeriesLess orders the series by family, then by labels, with the buckets of a histogram in increasing order of le.
+func promSeriesLess(a string, b string) bool {
+	familyA, familyB := promFamilyOrder(a), promFamilyOrder(b)
+	if familyA != familyB {
+		return familyA < familyB
+	}
+	labelsA, leA := splitBucketLabel(a)
+	labelsB, leB := splitBucketLabel(b)
+	if labelsA != labelsB {
+		return labelsA < labelsB
+	}
+	return leA < leB
+}
+
+// promFamilyOrder keeps the buckets, sum and count of a histogram together and in this order.
+func promFamilyOrder(series string) string {
+	name := series[:strings.Index(series, "{")]
+	for i, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix) + strconv.Itoa(i)
+		}
+	}
+	return name
+}
+
+func splitBucketLabel(series string) (string, float64) {
+	series = series[strings.Index(series, "{"):]
+	index := strings.LastIndex(series, `,le="`)
+	if index < 0 {
+		return series, 0
+	}
+	le, err := strconv.ParseFloat(strings.TrimSuffix(series[index+5:], `"}`), 64)
+	if err != nil {
+		return series[:index], 0
+	}
+	return series[:index], le
+}
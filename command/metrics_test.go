@@ -0,0 +1,238 @@
+package command
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+)
+
+func tempMetricsDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "lamp-metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// checkSamples checks the values of the given series of a Prometheus textfile.
+func checkSamples(t *testing.T, path string, want map[string]float64) {
+	t.Helper()
+	samples, err := readPromSamples(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for series, value := range want {
+		if got, ok := samples[series]; !ok || math.Abs(got-value) > 1e-9 {
+			t.Errorf("%s is %v (found: %v), want %v", series, got, ok, value)
+		}
+	}
+}
+
+func TestUpdatePromFile(t *testing.T) {
+	dir := tempMetricsDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lamp.prom")
+	const notes = `resource_path="/v2/alerts/{id}/notes"`
+
+	update := func(record metricRecord) {
+		if err := updatePromFile(path, &record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// API metrics and SDK metrics without an error are not counted, the file is not even written
+	update(metricRecord{Type: string(client.API), ResourcePath: "/v2/alerts", StatusCode: 202})
+	update(metricRecord{Type: string(client.SDK), ResourcePath: "/v2/alerts"})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("%s is written for metrics which are not counted", path)
+	}
+
+	update(metricRecord{Type: string(client.HTTP), ResourcePath: "/v2/alerts/a1/notes?identifierType=id", StatusCode: 200, DurationMillis: 300})
+	checkSamples(t, path, map[string]float64{
+		`lamp_http_requests_total{` + notes + `,status_code="200"}`:          1,
+		`lamp_http_retries_total{` + notes + `}`:                             0,
+		`lamp_http_request_duration_seconds_bucket{` + notes + `,le="0.25"}`: 0,
+		`lamp_http_request_duration_seconds_bucket{` + notes + `,le="0.5"}`:  1,
+		`lamp_http_request_duration_seconds_bucket{` + notes + `,le="+Inf"}`: 1,
+		`lamp_http_request_duration_seconds_sum{` + notes + `}`:              0.3,
+	})
+
+	// the counters add up with the values already in the file
+	update(metricRecord{Type: string(client.HTTP), ResourcePath: "/v2/alerts/a2/notes", StatusCode: 200, DurationMillis: 40000, RetryCount: 2})
+	update(metricRecord{Type: string(client.HTTP), ResourcePath: "/v2/alerts/a3/notes", StatusCode: 429, DurationMillis: 50, RetryCount: 1})
+	update(metricRecord{Type: string(client.SDK), ResourcePath: "/v2/schedules/primary/on-calls", ErrorType: "request-validation-error"})
+	checkSamples(t, path, map[string]float64{
+		`lamp_http_requests_total{` + notes + `,status_code="200"}`:                                                2,
+		`lamp_http_requests_total{` + notes + `,status_code="429"}`:                                                1,
+		`lamp_http_retries_total{` + notes + `}`:                                                                   3,
+		`lamp_http_request_duration_seconds_bucket{` + notes + `,le="0.05"}`:                                       1,
+		`lamp_http_request_duration_seconds_bucket{` + notes + `,le="30"}`:                                         2,
+		`lamp_http_request_duration_seconds_bucket{` + notes + `,le="+Inf"}`:                                       3,
+		`lamp_http_request_duration_seconds_sum{` + notes + `}`:                                                    40.35,
+		`lamp_http_request_duration_seconds_count{` + notes + `}`:                                                  3,
+		`lamp_sdk_errors_total{resource_path="/v2/schedules/{id}/on-calls",error_type="request-validation-error"}`: 1,
+	})
+
+	content, _ := ioutil.ReadFile(path)
+	for _, family := range []string{"lamp_http_requests_total", "lamp_sdk_errors_total"} {
+		if !strings.Contains(string(content), "# TYPE "+family+" counter") {
+			t.Errorf("%s has no TYPE line for %s:\n%s", path, family, content)
+		}
+	}
+	// only the textfile is left, the lock and the temporary file are removed
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("directory has %d files, want only lamp.prom", len(files))
+	}
+}
+
+func TestUpdatePromFileConcurrently(t *testing.T) {
+	dir := tempMetricsDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lamp.prom")
+
+	// the lock keeps the updates of concurrent processes, here goroutines, from overwriting each other
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if err := updatePromFile(path, &metricRecord{Type: string(client.HTTP), ResourcePath: "/v2/alerts", StatusCode: 202}); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	checkSamples(t, path, map[string]float64{`lamp_http_requests_total{resource_path="/v2/alerts",status_code="202"}`: 40})
+}
+
+func TestLockFileTakesOverAStaleLock(t *testing.T) {
+	dir := tempMetricsDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lamp.prom.lock")
+
+	// a lock left behind by a killed process
+	ioutil.WriteFile(path, nil, 0600)
+	stale := time.Now().Add(-2 * staleFileLock)
+	os.Chtimes(path, stale, stale)
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("lock is not held: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock is not removed by unlock")
+	}
+	if moved, _ := filepath.Glob(path + ".stale-*"); len(moved) != 0 {
+		t.Errorf("stale lock is left as %v", moved)
+	}
+}
+
+func TestLockFileTakeOverRace(t *testing.T) {
+	dir := tempMetricsDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lamp.prom.lock")
+
+	// a live lock replaced the stale lock after it was found stale, it is kept
+	ioutil.WriteFile(path, []byte("live"), 0600)
+	takeOverStaleLock(path)
+	if content, err := ioutil.ReadFile(path); err != nil || string(content) != "live" {
+		t.Fatalf("live lock is not kept: %q, %v", content, err)
+	}
+
+	// the holder of a lock taken over does not remove the lock of the new holder
+	stale := time.Now().Add(-2 * staleFileLock)
+	os.Remove(path)
+	unlockFirst, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, stale, stale)
+	unlockSecond, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlockFirst()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("lock of the new holder is removed: %v", err)
+	}
+	unlockSecond()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock is not removed by its holder")
+	}
+
+	// processes taking over the same stale lock concurrently hold it one at a time
+	ioutil.WriteFile(path, nil, 0600)
+	os.Chtimes(path, stale, stale)
+	var holders int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := lockFile(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if n := atomic.AddInt32(&holders, 1); n != 1 {
+				t.Errorf("%d holders of the lock", n)
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&holders, -1)
+			unlock()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMetricResourcePath(t *testing.T) {
+	paths := map[string]string{
+		"/v2/alerts":                                     "/v2/alerts",
+		"/v2/alerts/a1/notes?identifierType=id":          "/v2/alerts/{id}/notes",
+		"/v2/schedules/primary/on-calls":                 "/v2/schedules/{id}/on-calls",
+		"/v1/incidents/requests/r1":                      "/v1/incidents/requests/{id}",
+		"/v2/teams/sre/routing-rules/rule1/change-order": "/v2/teams/{id}/routing-rules/{id}/change-order",
+	}
+	for path, want := range paths {
+		if got := metricResourcePath(path); got != want {
+			t.Errorf("metricResourcePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestAppendMetricRecord(t *testing.T) {
+	dir := tempMetricsDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lamp.ndjson")
+
+	for _, id := range []string{"t1", "t2"} {
+		if err := appendMetricRecord(path, &metricRecord{Type: string(client.API), TransactionID: id, ResourcePath: "/v2/alerts", StatusCode: 202}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	content, _ := ioutil.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("file has the lines %q, want one per metric", lines)
+	}
+	var record metricRecord
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil || record.TransactionID != "t2" || record.StatusCode != 202 {
+		t.Errorf("second line is %s, %v", lines[1], err)
+	}
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opsgenie/opsgenie-lamp/mockserver"
+	gcli "github.com/urfave/cli"
+)
+
+// MockServerAction serves the in-memory mock of the Opsgenie API until the process is stopped.
+func MockServerAction(c *gcli.Context) {
+	grabErrorFormat(c)
+	options := mockserver.Options{APIKey: c.String("apiKey")}
+	if val, success := getVal("processingDelay", c); success {
+		delay, err := time.ParseDuration(val)
+		if seconds, atoiErr := strconv.Atoi(val); atoiErr == nil {
+			delay, err = time.Duration(seconds)*time.Second, nil
+		}
+		if err != nil || delay < 0 {
+			exitOnUsageErr("Invalid processingDelay value " + val + ". Give the number of seconds or a duration such as 500ms.")
+		}
+		options.ProcessingDelay = delay
+	}
+	if val, success := getVal("rateLimit", c); success {
+		periods := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+		parts := strings.SplitN(val, "/", 2)
+		count, err := strconv.Atoi(parts[0])
+		if len(parts) == 1 {
+			parts = append(parts, "m")
+		}
+		if err != nil || count < 0 || periods[parts[1]] == 0 {
+			exitOnUsageErr("Invalid rateLimit value " + val + ". Give the number of requests per s, m or h, e.g. 10/s.")
+		}
+		options.RateLimit, options.RateLimitPeriod = count, periods[parts[1]]
+	}
+
+	listener, err := net.Listen("tcp", c.String("host")+":"+strconv.Itoa(c.Int("port")))
+	if err != nil {
+		exitOnErr(newError(ExitCodeNetwork, "Could not start the mock server: "+err.Error()))
+	}
+	addr := listener.Addr().String()
+	printSummary(fmt.Sprintf("Mock server is listening on %s, set apiUrl=%s to use it.", addr, addr))
+	go func() {
+		<-rootContext().Done()
+		printSummary("Mock server is stopped.")
+		os.Exit(ExitCodeOK)
+	}()
+	err = mockserver.New(options).Serve(listener)
+	exitOnErr(newError(ExitCodeNetwork, "Mock server stopped: "+err.Error()))
+}
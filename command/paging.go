@@ -0,0 +1,72 @@
+package command
+
+import (
+	"reflect"
+	"strconv"
+
+	gcli "github.com/urfave/cli"
+)
+
+// pageFetcher requests the next page of a list command. It returns the items of the page as a
+// slice and whether there is another page to follow.
+type pageFetcher func() (page interface{}, more bool, err error)
+
+// isAllPagesRequested returns whether the all flag is set, the max flag limiting the fetched results implies it.
+func isAllPagesRequested(c *gcli.Context) bool {
+	return c.IsSet("all") || c.IsSet("max")
+}
+
+func grabMaxResults(c *gcli.Context) int {
+	if val, success := getVal("max", c); success {
+		max, err := strconv.Atoi(val)
+		if err != nil || max < 0 {
+			exitOnUsageErr("max should be a positive number")
+		}
+		return max
+	}
+	return 0
+}
+
+/*
+renderAllPages calls fetchPage until there is no next page or the number of results given
+with the max flag is reached. Formats printing one line per item (ndjson and template) are
+streamed page by page, the other formats are rendered once with the merged results.
+*/
+func renderAllPages(c *gcli.Context, fetchPage pageFetcher) {
+	max := grabMaxResults(c)
+	outputFormat := grabOutputFormat(c)
+	streaming := outputFormat == ndjsonFormat || outputFormat == templateFormat
+
+	var merged reflect.Value
+	fetched := 0
+	for page := 1; ; page++ {
+		items, more, err := fetchPage()
+		exitOnErr(err)
+
+		pageItems := reflect.ValueOf(items)
+		if max > 0 && fetched+pageItems.Len() >= max {
+			pageItems = pageItems.Slice(0, max-fetched)
+			more = false
+		}
+		fetched += pageItems.Len()
+		printMessage(DEBUG, "Fetched page "+strconv.Itoa(page)+", "+strconv.Itoa(fetched)+" results so far.")
+
+		if streaming {
+			if pageItems.Len() != 0 {
+				renderResult(c, pageItems.Interface())
+			}
+		} else if merged.IsValid() {
+			merged = reflect.AppendSlice(merged, pageItems)
+		} else {
+			merged = pageItems
+		}
+
+		if !more || pageItems.Len() == 0 {
+			break
+		}
+	}
+
+	if !streaming {
+		renderResult(c, merged.Interface())
+	}
+}
@@ -0,0 +1,170 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+
+	gcli "github.com/urfave/cli"
+)
+
+type pagedItem struct {
+	ID string `json:"id"`
+}
+
+// offsetPages serves the items in pages of limit items like the list endpoints paging with an offset,
+// recording the offset of every request.
+type offsetPages struct {
+	items   []string
+	limit   int
+	offset  int
+	offsets []int
+}
+
+func (p *offsetPages) fetch() (interface{}, bool, error) {
+	p.offsets = append(p.offsets, p.offset)
+	var page []pagedItem
+	for i := p.offset; i < len(p.items) && len(page) < p.limit; i++ {
+		page = append(page, pagedItem{ID: p.items[i]})
+	}
+	// the actions move the cursor past the items of the page and ask for more while pages are full
+	p.offset += len(page)
+	return page, len(page) == p.limit, nil
+}
+
+func runAllPages(t *testing.T, fetch pageFetcher, args ...string) string {
+	t.Helper()
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.Bool("all", false, "")
+	set.String("max", "", "")
+	set.String("output-format", "", "")
+	set.String("columns", "", "")
+	set.String("template", "", "")
+	if err := set.Parse(append([]string{"--all"}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	app := gcli.NewApp()
+	app.Writer = &output
+	renderAllPages(gcli.NewContext(app, set, nil), fetch)
+	return output.String()
+}
+
+func TestRenderAllPages(t *testing.T) {
+	items := []string{"a1", "a2", "a3", "a4", "a5"}
+	tests := []struct {
+		name        string
+		items       []string
+		limit       int
+		args        []string
+		want        string
+		wantOffsets []int
+	}{
+		{
+			name:        "merged pages",
+			items:       items,
+			limit:       2,
+			args:        []string{"--output-format", "csv"},
+			want:        "id\na1\na2\na3\na4\na5\n",
+			wantOffsets: []int{0, 2, 4},
+		},
+		{
+			name:        "last page is full",
+			items:       items[:4],
+			limit:       2,
+			args:        []string{"--output-format", "csv"},
+			want:        "id\na1\na2\na3\na4\n",
+			wantOffsets: []int{0, 2, 4},
+		},
+		{
+			name:        "max in the middle of a page",
+			items:       items,
+			limit:       2,
+			args:        []string{"--output-format", "csv", "--max", "3"},
+			want:        "id\na1\na2\na3\n",
+			wantOffsets: []int{0, 2},
+		},
+		{
+			name:        "max at the end of a page",
+			items:       items,
+			limit:       2,
+			args:        []string{"--output-format", "csv", "--max", "2"},
+			want:        "id\na1\na2\n",
+			wantOffsets: []int{0},
+		},
+		{
+			name:        "streamed pages",
+			items:       items,
+			limit:       2,
+			args:        []string{"--output-format", "template", "--template", "{{.id}}"},
+			want:        "a1\na2\na3\na4\na5\n",
+			wantOffsets: []int{0, 2, 4},
+		},
+		{
+			name:        "no results",
+			limit:       2,
+			args:        []string{"--output-format", "ndjson"},
+			want:        "",
+			wantOffsets: []int{0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pages := &offsetPages{items: test.items, limit: test.limit}
+			if output := runAllPages(t, pages.fetch, test.args...); output != test.want {
+				t.Errorf("printed %q, want %q", output, test.want)
+			}
+			if !reflect.DeepEqual(pages.offsets, test.wantOffsets) {
+				t.Errorf("requested the offsets %v, want %v", pages.offsets, test.wantOffsets)
+			}
+		})
+	}
+}
+
+func TestRenderAllPagesFollowsTheCursorOfTheLastItem(t *testing.T) {
+	// notes and logs of an alert are paged with the offset of the last item of the previous page
+	notes := map[string][]pagedItem{
+		"":   {{ID: "n1"}, {ID: "n2"}},
+		"n2": {{ID: "n3"}, {ID: "n4"}},
+		"n4": {{ID: "n5"}},
+	}
+	var cursors []string
+	cursor := ""
+	fetch := func() (interface{}, bool, error) {
+		cursors = append(cursors, cursor)
+		page, ok := notes[cursor]
+		if !ok {
+			return nil, false, errors.New("unknown cursor " + cursor)
+		}
+		cursor = page[len(page)-1].ID
+		_, more := notes[cursor]
+		return page, more, nil
+	}
+
+	if output := runAllPages(t, fetch, "--output-format", "ndjson"); output != "{\"id\":\"n1\"}\n{\"id\":\"n2\"}\n{\"id\":\"n3\"}\n{\"id\":\"n4\"}\n{\"id\":\"n5\"}\n" {
+		t.Errorf("printed %q", output)
+	}
+	if !reflect.DeepEqual(cursors, []string{"", "n2", "n4"}) {
+		t.Errorf("requested the cursors %q, want the last item of each page", cursors)
+	}
+}
+
+func TestMaxImpliesAllPages(t *testing.T) {
+	for args, want := range map[string]bool{"": false, "--all": true, "--max=10": true} {
+		set := flag.NewFlagSet("listAlerts", flag.ContinueOnError)
+		set.Bool("all", false, "")
+		set.String("max", "", "")
+		var flags []string
+		if args != "" {
+			flags = append(flags, args)
+		}
+		if err := set.Parse(flags); err != nil {
+			t.Fatal(err)
+		}
+		if got := isAllPagesRequested(gcli.NewContext(nil, set, nil)); got != want {
+			t.Errorf("isAllPagesRequested with %q is %v, want %v", args, got, want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-lamp/cfg"
+	gcli "github.com/urfave/cli"
+)
+
+const (
+	pluginPrefix   = "lamp-"
+	pluginCategory = "Plugins"
+)
+
+// pluginFlags are read by lamp to resolve the configuration passed to plugins, they are not passed to the plugin.
+var pluginFlags = []string{"config", "profile", "apiKey"}
+
+// plugin is an executable named lamp-<name> found in the plugins directory or in PATH.
+type plugin struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+var startupConfigOnce sync.Once
+
+// listingCommands list or run other commands, so the aliases and plugins are found when they run.
+var listingCommands = map[string]bool{"help": true, "h": true, "plugins": true, "shell": true, "batch": true, "__complete": true}
+
+// discoveredPlugins are all plugins found by PluginCommands, including the ones shadowed by other commands.
+var discoveredPlugins []plugin
+
+/*
+PluginCommands finds the lamp-<name> executables in the plugins directory and then in the PATH directories, and returns
+a command running each of them. Commands of lamp take precedence over plugins, and the first plugin found takes
+precedence over the plugins of the same name found later. Plugins are not looked up when lamp runs one of its commands
+which runs no other command.
+*/
+func PluginCommands(builtins []gcli.Command) []gcli.Command {
+	if runsBuiltinCommand(builtins) {
+		return nil
+	}
+	loadStartupConfig()
+	taken := map[string]string{}
+	for _, cmd := range builtins {
+		for _, name := range cmd.Names() {
+			taken[name] = "shadowed by the " + cmd.Name + " command"
+		}
+	}
+	var commands []gcli.Command
+	for _, dir := range pluginDirs() {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			name, ok := pluginName(file)
+			if !ok {
+				continue
+			}
+			p := plugin{Name: name, Path: filepath.Join(dir, file.Name()), Status: "active"}
+			if reason, shadowed := taken[name]; shadowed {
+				p.Status = reason
+			} else {
+				taken[name] = "shadowed by " + p.Path
+				commands = append(commands, pluginCommand(p))
+			}
+			discoveredPlugins = append(discoveredPlugins, p)
+		}
+	}
+	return commands
+}
+
+/*
+runsBuiltinCommand returns whether the command line runs a command of lamp which lists or runs no other command, aliases
+and plugins are not needed then as the commands of lamp take precedence over them.
+*/
+func runsBuiltinCommand(builtins []gcli.Command) bool {
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") || listingCommands[os.Args[1]] {
+		return false
+	}
+	for _, cmd := range builtins {
+		if cmd.HasName(os.Args[1]) {
+			return cmd.Category != aliasCategory
+		}
+	}
+	return false
+}
+
+// loadStartupConfig reads the configuration file given on the command line, or the default one, to find the aliases and plugins.
+func loadStartupConfig() {
+	startupConfigOnce.Do(func() {
+		values, _ := splitPluginFlags(os.Args[1:])
+		if path, ok := values["config"]; ok {
+			if _, err := os.Stat(path); err != nil {
+				printMessage(WARN, "Could not read the configuration file to find the aliases and plugins: "+err.Error())
+			}
+			cfg.LoadConfigFromGivenPath(path)
+		} else {
+			cfg.LoadConfiguration()
+		}
+		if err := cfg.SelectProfile(values["profile"]); err != nil {
+			printMessage(WARN, err.Error()+", the aliases and plugins are found with the default profile")
+		}
+	})
+}
+
+// pluginDirs are the plugins directory, pluginsDir or lamp/plugins in the user configuration directory, and the PATH directories.
+func pluginDirs() []string {
+	dir := cfg.Get("pluginsDir")
+	if dir == "" {
+		if configDir, err := os.UserConfigDir(); err == nil {
+			dir = filepath.Join(configDir, "lamp", "plugins")
+		}
+	}
+	return append([]string{dir}, filepath.SplitList(os.Getenv("PATH"))...)
+}
+
+// pluginName returns the name of the plugin command if the file is an executable named lamp-<name>.
+func pluginName(file os.FileInfo) (string, bool) {
+	name := file.Name()
+	if !strings.HasPrefix(name, pluginPrefix) || file.IsDir() {
+		return "", false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".exe" && ext != ".bat" && ext != ".cmd" {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	} else if file.Mode()&0111 == 0 {
+		return "", false
+	}
+	name = strings.TrimPrefix(name, pluginPrefix)
+	return name, name != ""
+}
+
+func pluginCommand(p plugin) gcli.Command {
+	return gcli.Command{Name: p.Name,
+		Category:        pluginCategory,
+		SkipFlagParsing: true,
+		Usage:           "Runs the plugin " + p.Path,
+		Action: func(c *gcli.Context) error {
+			runPlugin(c, p)
+			return nil
+		},
+	}
+}
+
+// splitPluginFlags separates the values of the flags read by lamp from the arguments passed to a plugin.
+func splitPluginFlags(args []string) (map[string]string, []string) {
+	values := map[string]string{}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			return values, append(rest, args[i+1:]...)
+		}
+		name := strings.TrimLeft(args[i], "-")
+		value, hasValue := "", false
+		if eq := strings.Index(name, "="); eq >= 0 {
+			name, value, hasValue = name[:eq], name[eq+1:], true
+		}
+		if !strings.HasPrefix(args[i], "-") || !isPluginFlag(name) {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		values[name] = value
+	}
+	return values, rest
+}
+
+func isPluginFlag(name string) bool {
+	for _, flagName := range pluginFlags {
+		if name == flagName {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+runPlugin runs the plugin with the arguments of the command, except the flags read by lamp, and exits with the exit code
+of the plugin. The resolved configuration is passed as environment variables: LAMP_PROFILE, LAMP_API_URL, LAMP_API_KEY,
+LAMP_USER and LAMP_CONF_PATH, which are also read by lamp commands run by the plugin, and LAMP_BIN, the path of lamp.
+*/
+func runPlugin(c *gcli.Context, p plugin) {
+	values, args := splitPluginFlags(c.Args())
+	set := flag.NewFlagSet(p.Name, flag.ContinueOnError)
+	for _, name := range pluginFlags {
+		set.String(name, "", "")
+		if value, ok := values[name]; ok {
+			set.Set(name, value)
+		}
+	}
+	ctx := gcli.NewContext(c.App, set, nil)
+	readConfigFile(ctx)
+
+	apiURL := cfg.Get("apiUrl")
+	if apiURL == "" {
+		apiURL = string(client.API_URL)
+	}
+	confPath := values["config"]
+	if confPath == "" {
+		confPath = cfg.ConfigPath()
+	}
+	env := append(os.Environ(),
+		"LAMP_PROFILE="+cfg.Profile(),
+		cfg.EnvName("apiUrl")+"="+apiURL,
+		cfg.EnvName("apiKey")+"="+grabAPIKey(ctx),
+		cfg.EnvName("user")+"="+grabUsername(ctx),
+		"LAMP_CONF_PATH="+confPath,
+	)
+	if executable, err := os.Executable(); err == nil {
+		env = append(env, "LAMP_BIN="+executable)
+	}
+
+	cmd := exec.Command(p.Path, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = c.App.Writer
+	cmd.Stderr = c.App.ErrWriter
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	printMessage(DEBUG, "Running the plugin "+p.Path+" "+strings.Join(args, " "))
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && !batchMode {
+			os.Exit(exitErr.ExitCode())
+		} else if ok {
+			exitOnErr(newError(exitErr.ExitCode(), "The plugin "+p.Path+" exited with "+strconv.Itoa(exitErr.ExitCode())))
+		}
+		exitOnErr(newError(ExitCodeError, "Could not run the plugin "+p.Path+": "+err.Error()))
+	}
+}
+
+// PluginsAction lists the plugins found in the plugins directory and in PATH.
+func PluginsAction(c *gcli.Context) {
+	if arg := c.Args().First(); arg != "" && arg != "list" {
+		gcli.ShowCommandHelp(c, c.Command.Name)
+		exitOnUsageErr("Unknown argument " + arg + ", specify list")
+	}
+	plugins := discoveredPlugins
+	if plugins == nil {
+		plugins = []plugin{}
+	}
+	renderResult(c, plugins)
+}
+
+// UnknownCommandAction fails for the commands which are neither lamp commands nor plugins.
+func UnknownCommandAction(c *gcli.Context) {
+	name := c.Args().First()
+	exitOnUsageErr("'" + name + "' is not a lamp command and no " + pluginPrefix + name + " plugin is found in " + pluginDirs()[0] + " or PATH. Run 'lamp help' for the options")
+}
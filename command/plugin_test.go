@@ -0,0 +1,140 @@
+package command
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	gcli "github.com/urfave/cli"
+)
+
+func TestSplitPluginFlags(t *testing.T) {
+	tests := []struct {
+		args   []string
+		values map[string]string
+		rest   []string
+	}{
+		{args: []string{"report", "--since", "1d"}, values: map[string]string{}, rest: []string{"report", "--since", "1d"}},
+		{args: []string{"--profile", "eu", "report"}, values: map[string]string{"profile": "eu"}, rest: []string{"report"}},
+		{args: []string{"--config=/tmp/lamp.conf", "-apiKey", "k", "-v"}, values: map[string]string{"config": "/tmp/lamp.conf", "apiKey": "k"}, rest: []string{"-v"}},
+		{args: []string{"report", "--", "--profile", "eu"}, values: map[string]string{}, rest: []string{"report", "--profile", "eu"}},
+		{args: []string{"--profile"}, values: map[string]string{"profile": ""}, rest: nil},
+	}
+	for _, test := range tests {
+		values, rest := splitPluginFlags(test.args)
+		if !reflect.DeepEqual(values, test.values) || !reflect.DeepEqual(rest, test.rest) {
+			t.Errorf("splitPluginFlags(%q) = %v, %q, want %v, %q", test.args, values, rest, test.values, test.rest)
+		}
+	}
+}
+
+// writePlugin writes an executable shell script, and returns its path.
+func writePlugin(t *testing.T, dir string, name string, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir, err := ioutil.TempDir("", "lamp-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer unloadConfigFile(dir)
+	pluginsDir, pathDir := filepath.Join(dir, "plugins"), filepath.Join(dir, "bin")
+	os.Mkdir(pluginsDir, 0700)
+	os.Mkdir(pathDir, 0700)
+	config := filepath.Join(dir, "lamp.conf")
+	ioutil.WriteFile(config, []byte("apiKey=key\nuser=jane\npluginsDir="+pluginsDir+"\n[profile eu]\napiKey=eu-key\napiUrl=api.eu.opsgenie.com\n"), 0600)
+
+	report := writePlugin(t, pluginsDir, "lamp-report", `echo "$LAMP_PROFILE $LAMP_API_KEY $LAMP_API_URL $LAMP_USER $*"; exit 42`)
+	writePlugin(t, pathDir, "lamp-report", "echo shadowed")
+	writePlugin(t, pathDir, "lamp-getAlert", "echo shadowed")
+	writePlugin(t, pathDir, "lamp-sync", "echo synced")
+	ioutil.WriteFile(filepath.Join(pathDir, "lamp-notes"), []byte("not executable"), 0644)
+
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", pathDir)
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"lamp", "--config", config, "report"}
+	startupConfigOnce, discoveredPlugins = sync.Once{}, nil
+	defer func() { startupConfigOnce, discoveredPlugins = sync.Once{}, nil }()
+
+	commands := PluginCommands([]gcli.Command{{Name: "getAlert"}})
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.Name)
+	}
+	if strings.Join(names, " ") != "report sync" {
+		t.Errorf("plugin commands are %q, want report and sync", names)
+	}
+	statuses := map[string]string{}
+	for _, p := range discoveredPlugins {
+		statuses[p.Path] = p.Status
+	}
+	want := map[string]string{
+		report:                                  "active",
+		filepath.Join(pathDir, "lamp-report"):   "shadowed by " + report,
+		filepath.Join(pathDir, "lamp-getAlert"): "shadowed by the getAlert command",
+		filepath.Join(pathDir, "lamp-sync"):     "active",
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("discovered plugins %v, want %v", statuses, want)
+	}
+
+	// the plugin gets the resolved configuration, not the flags read by lamp, and its exit code
+	batchMode = true
+	defer func() { batchMode = false }()
+	var output bytes.Buffer
+	app := gcli.NewApp()
+	app.Writer, app.ErrWriter = &output, ioutil.Discard
+	app.Commands = commands
+	err = catchExit(func() {
+		app.Run([]string{"lamp", "report", "--config", config, "--profile", "eu", "--since", "1d"})
+	})
+	if exitCode(err) != 42 {
+		t.Errorf("plugin exited with %v, want 42", err)
+
+	}
+	if got := strings.TrimSpace(output.String()); got != "eu eu-key api.eu.opsgenie.com jane --since 1d" {
+		t.Errorf("plugin printed %q", got)
+	}
+}
+
+func TestRunsBuiltinCommand(t *testing.T) {
+	builtins := []gcli.Command{{Name: "getAlert"}, {Name: "configure", Aliases: []string{"config"}}, {Name: "shell"}, {Name: "ack", Category: aliasCategory}}
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	builtin := map[string]bool{
+		"lamp getAlert --id a1":         true,
+		"lamp config":                   true,
+		"lamp shell":                    false,
+		"lamp help":                     false,
+		"lamp ack a1":                   false,
+		"lamp report":                   false,
+		"lamp --config x.conf getAlert": false,
+		"lamp":                          false,
+	}
+	for line, want := range builtin {
+		os.Args = strings.Fields(line)
+		if got := runsBuiltinCommand(builtins); got != want {
+			t.Errorf("runsBuiltinCommand for %q = %v, want %v", line, got, want)
+		}
+	}
+}
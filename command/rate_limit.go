@@ -0,0 +1,331 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	gcli "github.com/urfave/cli"
+)
+
+// API families sharing a rate limit quota of Opsgenie.
+const (
+	alertFamily         = "alert"
+	configurationFamily = "configuration"
+	logsFamily          = "logs"
+)
+
+const (
+	// defaultLogsRateLimit keeps the log file downloads below the quota of the logs API.
+	defaultLogsRateLimit = "120/m"
+	defaultThrottleWait  = time.Second
+	maxThrottleWait      = time.Minute
+	// minRateFactor is the smallest share of the configured rate used after throttled responses,
+	// the share recovers to the full rate in rateRecovery.
+	minRateFactor = 0.1
+	rateRecovery  = time.Minute
+
+	rateLimitStateHeader  = "X-RateLimit-State"
+	rateLimitPeriodHeader = "X-RateLimit-Period-In-Sec"
+)
+
+var rateLimitFamilies = []string{alertFamily, configurationFamily, logsFamily}
+
+var (
+	rateLimitersMu sync.Mutex
+	rateLimiters   = map[string]*rateLimiter{}
+)
+
+/*
+rateLimiter is the token bucket of an API family. The bucket is kept in a state file guarded by a lock file, so
+the concurrent workers of a process and the lamp processes using the same account share it. A rate of 0 does not
+limit the requests, but the requests still wait while Opsgenie throttles the family.
+*/
+type rateLimiter struct {
+	mu     sync.Mutex
+	family string
+	rate   float64
+	burst  float64
+	path   string
+	// memory is the state when there is no state file
+	memory *rateLimitState
+}
+
+// rateLimitState is the content of the state file of a rate limiter.
+type rateLimitState struct {
+	Tokens         float64   `json:"tokens"`
+	Updated        time.Time `json:"updated"`
+	Factor         float64   `json:"factor"`
+	ThrottledUntil time.Time `json:"throttledUntil"`
+}
+
+// rateLimitTransport waits for the rate limiter of the API family of each request and throttles it from the responses.
+type rateLimitTransport struct {
+	next http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter := rateLimiterOf(apiFamily(req.URL.Path))
+	if err := limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		limiter.observe(resp)
+	}
+	return resp, err
+}
+
+/*
+configureRateLimits limits the requests with the alertRateLimit, configurationRateLimit and logsRateLimit settings,
+e.g. 600/m, 10/s or 0 for no limit. Only the logs API is limited by default.
+*/
+func configureRateLimits(c *gcli.Context, config *client.Config) {
+	dir := rateLimitDir(config)
+	for _, family := range rateLimitFamilies {
+		name := family + "RateLimit"
+		value := grabSetting(name, c)
+		if value == "" && family == logsFamily {
+			value = defaultLogsRateLimit
+		}
+		rate, err := parseRateLimit(value)
+		if err != nil {
+			exitOnErr(newError(ExitCodeConfiguration, "Invalid "+name+" value "+value+". "+err.Error()))
+		}
+		limiter := rateLimiterOf(family)
+		limiter.mu.Lock()
+		limiter.rate, limiter.burst = rate, rate
+		if limiter.burst < 1 {
+			limiter.burst = 1
+		}
+		limiter.path = ""
+		if dir != "" {
+			limiter.path = filepath.Join(dir, family+".json")
+		}
+		limiter.mu.Unlock()
+	}
+	transport := config.HttpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	config.HttpClient.Transport = &rateLimitTransport{next: transport}
+}
+
+// parseRateLimit returns the requests per second of a rate limit such as 600/m, 10/s or 36000/h. Plain numbers are per minute.
+func parseRateLimit(value string) (float64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "0" || value == "none" || value == "off" {
+		return 0, nil
+	}
+	count, unit := value, "m"
+	if slash := strings.Index(value, "/"); slash >= 0 {
+		count, unit = strings.TrimSpace(value[:slash]), strings.TrimSpace(value[slash+1:])
+	}
+	number, err := strconv.ParseFloat(count, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("It should be a number of requests per s, m or h, e.g. 600/m.")
+	}
+	switch unit {
+	case "s":
+		return number, nil
+	case "m":
+		return number / 60, nil
+	case "h":
+		return number / 3600, nil
+	}
+	return 0, fmt.Errorf("It should be a number of requests per s, m or h, e.g. 600/m.")
+}
+
+// apiFamily returns the API family of a request path, the families have separate quotas in Opsgenie.
+func apiFamily(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/v2/logs"):
+		return logsFamily
+	case strings.Contains(path, "/alerts"), strings.Contains(path, "/incidents"):
+		return alertFamily
+	}
+	return configurationFamily
+}
+
+func rateLimiterOf(family string) *rateLimiter {
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+	limiter, ok := rateLimiters[family]
+	if !ok {
+		limiter = &rateLimiter{family: family, burst: 1}
+		rateLimiters[family] = limiter
+	}
+	return limiter
+}
+
+// accountHash identifies the account of a configuration in file names by a hash of its API URL and key.
+func accountHash(config *client.Config) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(string(config.OpsGenieAPIURL) + "\n" + config.ApiKey))
+	return strconv.FormatUint(hash.Sum64(), 16)
+}
+
+// rateLimitDir is the directory of the state files of an account, lamp/ratelimit/<hash of the api url and key> in the user cache directory.
+func rateLimitDir(config *client.Config) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	dir := filepath.Join(cacheDir, "lamp", "ratelimit", accountHash(config))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		printMessage(DEBUG, "Rate limits are not shared with other processes, could not create "+dir+": "+err.Error())
+		return ""
+	}
+	return dir
+}
+
+// wait takes a token from the bucket, waiting until one is available or the request is canceled.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		delay := l.take()
+		if delay <= 0 {
+			return nil
+		}
+		printMessage(DEBUG, fmt.Sprintf("Waiting %s for the %s rate limit", delay.Round(time.Millisecond), l.family))
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// take returns 0 when it takes a token from the bucket, or how long to wait before trying again.
+func (l *rateLimiter) take() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if l.rate == 0 {
+		state := l.readState(now)
+		if now.Before(state.ThrottledUntil) {
+			return state.ThrottledUntil.Sub(now)
+		}
+		return 0
+	}
+	defer l.lock()()
+	state := l.readState(now)
+	if now.Before(state.ThrottledUntil) {
+		return state.ThrottledUntil.Sub(now)
+	}
+	elapsed := now.Sub(state.Updated)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	state.Factor += elapsed.Seconds() / rateRecovery.Seconds()
+	if state.Factor > 1 {
+		state.Factor = 1
+	}
+	rate := l.rate * state.Factor
+	state.Tokens += elapsed.Seconds() * rate
+	if state.Tokens > l.burst {
+		state.Tokens = l.burst
+	}
+	state.Updated = now
+	var delay time.Duration
+	if state.Tokens >= 1 {
+		state.Tokens--
+	} else {
+		delay = time.Duration((1 - state.Tokens) / rate * float64(time.Second))
+	}
+	l.writeState(state)
+	return delay
+}
+
+/*
+observe throttles the family when Opsgenie responds with 429 or the THROTTLED rate limit state. The requests wait for
+Retry-After or X-RateLimit-Period-In-Sec seconds, and the rate is halved and then recovers gradually.
+*/
+func (l *rateLimiter) observe(resp *http.Response) {
+	if resp.StatusCode != http.StatusTooManyRequests && !strings.EqualFold(resp.Header.Get(rateLimitStateHeader), "THROTTLED") {
+		return
+	}
+	wait := defaultThrottleWait
+	if seconds, err := strconv.Atoi(resp.Header.Get(rateLimitPeriodHeader)); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait > maxThrottleWait {
+		wait = maxThrottleWait
+	}
+	printMessage(INFO, fmt.Sprintf("Opsgenie throttled the %s requests, waiting %s", l.family, wait))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer l.lock()()
+	now := time.Now()
+	state := l.readState(now)
+	// the requests sent before the family was throttled do not slow it down again
+	if now.After(state.ThrottledUntil) {
+		state.Factor /= 2
+		if state.Factor < minRateFactor {
+			state.Factor = minRateFactor
+		}
+	}
+	if until := now.Add(wait); until.After(state.ThrottledUntil) {
+		state.ThrottledUntil = until
+	}
+	state.Tokens = 0
+	state.Updated = now
+	l.writeState(state)
+}
+
+// lock takes the lock file of the state file, the requests are limited per process only when it can not be taken.
+func (l *rateLimiter) lock() func() {
+	if l.path == "" {
+		return func() {}
+	}
+	unlock, err := lockFile(l.path + ".lock")
+	if err != nil {
+		printMessage(DEBUG, "Rate limit of "+l.family+" is not shared with other processes: "+err.Error())
+		return func() {}
+	}
+	return unlock
+}
+
+func (l *rateLimiter) readState(now time.Time) rateLimitState {
+	state := rateLimitState{Tokens: l.burst, Updated: now, Factor: 1}
+	if l.path == "" {
+		if l.memory != nil {
+			return *l.memory
+		}
+		return state
+	}
+	data, err := ioutil.ReadFile(l.path)
+	if err != nil || json.Unmarshal(data, &state) != nil {
+		return rateLimitState{Tokens: l.burst, Updated: now, Factor: 1}
+	}
+	return state
+}
+
+func (l *rateLimiter) writeState(state rateLimitState) {
+	if l.path == "" {
+		l.memory = &state
+		return
+	}
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = ioutil.WriteFile(l.path, data, 0600)
+	}
+	if err != nil {
+		printMessage(DEBUG, "Could not write the rate limit state "+l.path+": "+err.Error())
+	}
+}
@@ -0,0 +1,203 @@
+package command
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opsgenie/opsgenie-lamp/mockserver"
+)
+
+// delayTolerance absorbs the time passing between setting up the state of a limiter and taking tokens.
+const delayTolerance = 50 * time.Millisecond
+
+func TestParseRateLimit(t *testing.T) {
+	rates := map[string]float64{
+		"":         0,
+		"off":      0,
+		"10/s":     10,
+		"600/m":    10,
+		" 120 / M": 2,
+		"36000/h":  10,
+		"30":       0.5,
+	}
+	for value, want := range rates {
+		if rate, err := parseRateLimit(value); err != nil || rate != want {
+			t.Errorf("parseRateLimit(%q) = %v, %v, want %v", value, rate, err, want)
+		}
+	}
+	for _, value := range []string{"fast", "10/d", "-1/s"} {
+		if _, err := parseRateLimit(value); err == nil {
+			t.Errorf("parseRateLimit(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestAPIFamily(t *testing.T) {
+	families := map[string]string{
+		"/v2/alerts":                  alertFamily,
+		"/v2/alerts/requests/r1":      alertFamily,
+		"/v1/incidents/create":        alertFamily,
+		"/v2/logs/list":               logsFamily,
+		"/v2/teams":                   configurationFamily,
+		"/v2/schedules/s1/on-calls":   configurationFamily,
+		"/v2/integrations/i1/disable": configurationFamily,
+	}
+	for path, want := range families {
+		if family := apiFamily(path); family != want {
+			t.Errorf("family of %s is %s, want %s", path, family, want)
+		}
+	}
+}
+
+func TestRateLimiterTake(t *testing.T) {
+	tests := []struct {
+		name string
+		rate float64
+		// burst is 1 if it is 0
+		burst float64
+		// state is the stored state, updated is how long ago it was updated and throttled how long the family is throttled for
+		state     *rateLimitState
+		updated   time.Duration
+		throttled time.Duration
+		want      []time.Duration
+	}{
+		{name: "no limit", rate: 0, want: []time.Duration{0, 0, 0}},
+		{name: "no limit while throttled", rate: 0, state: &rateLimitState{Factor: 1}, throttled: 2 * time.Second, want: []time.Duration{2 * time.Second}},
+		{name: "full bucket", rate: 1, burst: 2, want: []time.Duration{0, 0, time.Second}},
+		{name: "empty bucket", rate: 2, burst: 2, state: &rateLimitState{Tokens: 0, Factor: 1}, want: []time.Duration{500 * time.Millisecond}},
+		{name: "partly filled bucket", rate: 2, burst: 2, state: &rateLimitState{Tokens: 0.5, Factor: 1}, want: []time.Duration{250 * time.Millisecond}},
+		{name: "refilled bucket", rate: 2, burst: 2, state: &rateLimitState{Tokens: 0, Factor: 1}, updated: time.Second, want: []time.Duration{0, 0, 500 * time.Millisecond}},
+		{name: "refill up to the burst", rate: 2, burst: 1, state: &rateLimitState{Tokens: 0, Factor: 1}, updated: time.Minute, want: []time.Duration{0, 500 * time.Millisecond}},
+		{name: "slowed down after throttling", rate: 2, burst: 2, state: &rateLimitState{Tokens: 0, Factor: 0.5}, want: []time.Duration{time.Second}},
+		{name: "throttled", rate: 2, burst: 2, state: &rateLimitState{Tokens: 2, Factor: 1}, throttled: 3 * time.Second, want: []time.Duration{3 * time.Second}},
+	}
+	for _, test := range tests {
+		l := &rateLimiter{family: alertFamily, rate: test.rate, burst: test.burst}
+		if l.burst == 0 {
+			l.burst = 1
+		}
+		if test.state != nil {
+			state := *test.state
+			state.Updated = time.Now().Add(-test.updated)
+			state.ThrottledUntil = time.Now().Add(test.throttled)
+			l.memory = &state
+		}
+		for i, want := range test.want {
+			delay := l.take()
+			if delay < want-delayTolerance || delay > want+delayTolerance {
+				t.Errorf("%s: take %d waits %s, want %s", test.name, i+1, delay, want)
+			}
+		}
+	}
+}
+
+func TestRateLimiterObserve(t *testing.T) {
+	l := &rateLimiter{family: configurationFamily, rate: 10, burst: 10}
+	respond := func(status int, header map[string]string) {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}}
+		for name, value := range header {
+			resp.Header.Set(name, value)
+		}
+		l.observe(resp)
+	}
+
+	respond(http.StatusOK, map[string]string{rateLimitStateHeader: "OK"})
+	if l.memory != nil {
+		t.Fatalf("accepted request changed the state to %+v", *l.memory)
+	}
+	respond(http.StatusTooManyRequests, map[string]string{rateLimitPeriodHeader: "2"})
+	if wait := time.Until(l.memory.ThrottledUntil); l.memory.Factor != 0.5 || wait < 2*time.Second-delayTolerance || wait > 2*time.Second {
+		t.Errorf("state after a 429 is %+v, want half the rate for 2s", *l.memory)
+	}
+	// a request sent before the throttling started extends the wait without slowing down the rate again
+	respond(http.StatusOK, map[string]string{rateLimitStateHeader: "throttled", "Retry-After": "5"})
+	if wait := time.Until(l.memory.ThrottledUntil); l.memory.Factor != 0.5 || wait < 5*time.Second-delayTolerance || wait > 5*time.Second {
+		t.Errorf("state after a throttled response is %+v, want half the rate for 5s", *l.memory)
+	}
+	l.memory.ThrottledUntil = time.Now().Add(-time.Second)
+	for i := 0; i < 5; i++ {
+		respond(http.StatusTooManyRequests, map[string]string{"Retry-After": "3600"})
+		l.memory.ThrottledUntil = time.Now().Add(-time.Second)
+	}
+	if l.memory.Factor != minRateFactor {
+		t.Errorf("rate factor is %v after repeated throttling, want %v", l.memory.Factor, minRateFactor)
+	}
+}
+
+func TestRateLimiterTakeSharesTheStateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-ratelimit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, alertFamily+".json")
+
+	// limiters of two processes using the same account
+	first := &rateLimiter{family: alertFamily, rate: 1, burst: 2, path: path}
+	second := &rateLimiter{family: alertFamily, rate: 1, burst: 2, path: path}
+	for i, l := range []*rateLimiter{first, second} {
+		if delay := l.take(); delay != 0 {
+			t.Errorf("take %d waits %s, want a token", i+1, delay)
+		}
+	}
+	if delay := first.take(); delay < time.Second-delayTolerance || delay > time.Second {
+		t.Errorf("take of the emptied bucket waits %s, want 1s", delay)
+	}
+	if _, err := os.Stat(path + ".lock"); err == nil {
+		t.Errorf("lock file %s is left after taking tokens", path+".lock")
+	}
+}
+
+func TestRateLimitTransportWaitsWhileThrottled(t *testing.T) {
+	server := httptest.NewServer(mockserver.New(mockserver.Options{RateLimit: 1, RateLimitPeriod: time.Second}))
+	defer server.Close()
+	rateLimitersMu.Lock()
+	previous := rateLimiters
+	rateLimiters = map[string]*rateLimiter{}
+	rateLimitersMu.Unlock()
+	defer func() {
+		rateLimitersMu.Lock()
+		rateLimiters = previous
+		rateLimitersMu.Unlock()
+	}()
+
+	client := &http.Client{Transport: &rateLimitTransport{next: http.DefaultTransport}}
+	get := func() int {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/v2/teams", nil)
+		req.Header.Set("Authorization", "GenieKey key")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := get(); status != http.StatusOK {
+		t.Fatalf("first request answered %d", status)
+	}
+	if status := get(); status != http.StatusTooManyRequests {
+		t.Fatalf("second request answered %d, want it throttled", status)
+	}
+	started := time.Now()
+	if status := get(); status != http.StatusOK {
+		t.Errorf("request after the throttling answered %d", status)
+	}
+	if waited := time.Since(started); waited < time.Second-delayTolerance {
+		t.Errorf("request waited %s, want the 1s rate limit period", waited)
+	}
+
+	// waiting for the throttling ends with the request
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	rateLimiterOf(configurationFamily).observe(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}})
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/v2/teams", nil)
+	if _, err := client.Do(req.WithContext(ctx)); err == nil {
+		t.Error("request was sent while throttled, want the deadline exceeded")
+	}
+}
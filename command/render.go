@@ -0,0 +1,472 @@
+package command
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/escalation"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/heartbeat"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/incident"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/og"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/schedule"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/service"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/team"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/user"
+	gcli "github.com/urfave/cli"
+)
+
+const (
+	jsonFormat     = "json"
+	yamlFormat     = "yaml"
+	tableFormat    = "table"
+	csvFormat      = "csv"
+	ndjsonFormat   = "ndjson"
+	templateFormat = "template"
+)
+
+// defaultColumns holds the fields printed in table and csv formats for each resource type.
+// Fields are JSON paths of the resource, nested fields are separated by dots.
+var defaultColumns = map[reflect.Type][]string{
+	reflect.TypeOf(alert.Alert{}):                   {"tinyId", "id", "status", "priority", "message", "owner", "createdAt"},
+	reflect.TypeOf(alert.AlertNote{}):               {"createdAt", "owner", "note"},
+	reflect.TypeOf(alert.AlertLog{}):                {"createdAt", "type", "owner", "log"},
+	reflect.TypeOf(alert.AlertRecipient{}):          {"user.username", "state", "method", "updatedAt"},
+	reflect.TypeOf(alert.ListedAttachment{}):        {"id", "name"},
+	reflect.TypeOf(incident.Incident{}):             {"tinyId", "id", "status", "priority", "message", "ownerTeam", "createdAt"},
+	reflect.TypeOf(team.ListedTeams{}):              {"id", "name", "description"},
+	reflect.TypeOf(team.LogEntry{}):                 {"createdDate", "owner", "log"},
+	reflect.TypeOf(team.GetRoleInfo{}):              {"id", "name"},
+	reflect.TypeOf(team.RoutingRuleMeta{}):          {"id", "name", "isDefault", "notify.type", "notify.name"},
+	reflect.TypeOf(schedule.Schedule{}):             {"id", "name", "timezone", "enabled", "ownerTeam.name"},
+	reflect.TypeOf(og.Rotation{}):                   {"id", "name", "type", "length", "startDate", "endDate"},
+	reflect.TypeOf(schedule.ScheduleOverride{}):     {"alias", "user.type", "user.name", "user.username", "startDate", "endDate"},
+	reflect.TypeOf(schedule.GetOnCallParticipant{}): {"type", "name", "id"},
+	reflect.TypeOf(escalation.Escalation{}):         {"id", "name", "ownerTeam.name", "description"},
+	reflect.TypeOf(service.Service{}):               {"id", "name", "teamId", "visibility"},
+	reflect.TypeOf(heartbeat.Heartbeat{}):           {"name", "enabled", "expired", "interval", "intervalUnit", "ownerTeam.name"},
+	reflect.TypeOf(user.User{}):                     {"id", "username", "fullName", "role.name"},
+	reflect.TypeOf(bulkResult{}):                    {"tinyId", "alertId", "message", "requestId", "error"},
+	reflect.TypeOf(RequestOutcome{}):                {"requestId", "action", "success", "status", "alertId", "incidentId", "tinyId"},
+	reflect.TypeOf(doctorCheck{}):                   {"check", "status", "detail"},
+	reflect.TypeOf(plugin{}):                        {"name", "status", "path"},
+	reflect.TypeOf(journalEntry{}):                  {"id", "time", "user", "profile", "command", "method", "path", "outcome", "requestId", "undoneBy"},
+}
+
+var (
+	resultMetadataType = reflect.TypeOf(client.ResultMetadata{})
+	timeType           = reflect.TypeOf(time.Time{})
+)
+
+func renderResponse(c *gcli.Context, resp interface{}, err error) {
+	exitOnErr(err)
+	renderResult(c, resp)
+}
+
+// renderResult prints the given result in the format requested with the output-format flag.
+func renderResult(c *gcli.Context, data interface{}) {
+	printMessage(DEBUG, "Got response successfully, rendering in "+grabOutputFormat(c)+" format")
+
+	output, err := renderOutput(c, data)
+	exitOnErr(err)
+	if shellMode {
+		shellRendered, _ = toGeneric(data)
+	}
+	printOutput(c, output)
+}
+
+func grabOutputFormat(c *gcli.Context) string {
+	if outputFormat := strings.ToLower(c.String("output-format")); outputFormat != "" {
+		return outputFormat
+	}
+	return jsonFormat
+}
+
+func renderOutput(c *gcli.Context, data interface{}) (string, error) {
+	switch outputFormat := grabOutputFormat(c); outputFormat {
+	case jsonFormat:
+		return resultToJSON(data, c.IsSet("pretty"))
+	case yamlFormat:
+		return resultToYAML(data)
+	case tableFormat:
+		return resultToTable(data, grabColumns(c))
+	case csvFormat:
+		return resultToCSV(data, grabColumns(c))
+	case ndjsonFormat:
+		return resultToNDJSON(data)
+	case templateFormat:
+		return resultToTemplate(data, c.String("template"))
+	default:
+		return "", newError(ExitCodeUsage, "Unknown output format "+outputFormat+", specify one of json, yaml, table, csv, ndjson or template")
+	}
+}
+
+// printOutput writes rendered command output to the standard output, separately from log messages.
+func printOutput(c *gcli.Context, output string) {
+	fmt.Fprintln(c.App.Writer, strings.TrimRight(output, "\n"))
+}
+
+func grabColumns(c *gcli.Context) []string {
+	if val, success := getVal("columns", c); success {
+		var columns []string
+		for _, column := range strings.Split(val, ",") {
+			columns = append(columns, strings.TrimSpace(column))
+		}
+		return columns
+	}
+	return nil
+}
+
+/*
+The 'listAlerts' command returns a list of alerts.
+The 'ResultToTable' function is called whenever "output-format" parameter is
+set to table. Each item of the result is printed as a row, and the columns are
+taken from the "columns" parameter or the default columns of the resource type.
+*/
+func resultToTable(data interface{}, columns []string) (string, error) {
+	header, rows, err := tabulate(data, columns)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for i := range header {
+		header[i] = strings.ToUpper(header[i])
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return "", errors.New("Can not render the response as a table. " + err.Error())
+	}
+	return buf.String(), nil
+}
+
+func resultToCSV(data interface{}, columns []string) (string, error) {
+	header, rows, err := tabulate(data, columns)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(header)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		return "", errors.New("Can not render the response in CSV format. " + err.Error())
+	}
+	return buf.String(), nil
+}
+
+// resultToNDJSON prints every item of the result as a JSON document on its own line.
+func resultToNDJSON(data interface{}) (string, error) {
+	var lines []string
+	for _, item := range resultItems(data) {
+		line, err := resultToJSON(item, false)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// resultToTemplate executes the given Go template for every item of the result. Fields are
+// referenced by their JSON names, e.g. {{.tinyId}} or {{.ownerTeam.name}}.
+func resultToTemplate(data interface{}, text string) (string, error) {
+	if text == "" {
+		return "", newError(ExitCodeUsage, "template flag should be provided when output-format is template")
+	}
+	tmpl, err := template.New("output").Funcs(template.FuncMap{"orEmpty": orEmpty, "lookupField": lookupField}).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", errors.New("Can not parse the output template. " + err.Error())
+	}
+	emptyMissingValues(tmpl.Tree, tmpl.Tree.Root)
+
+	var buf bytes.Buffer
+	for _, item := range resultItems(data) {
+		value, err := toGeneric(item)
+		if err != nil {
+			return "", err
+		}
+		if err := tmpl.Execute(&buf, value); err != nil {
+			return "", errors.New("Can not execute the output template. " + err.Error())
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String(), nil
+}
+
+/*
+emptyMissingValues rewrites the template so that missing and null fields print nothing instead of <no value>: the value
+printed by every action is piped to orEmpty, and the fields of fields, e.g. .ownerTeam.name, are looked up with
+lookupField, which does not fail when a field on the way is missing.
+*/
+func emptyMissingValues(tree *parse.Tree, node parse.Node) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, child := range node.Nodes {
+			emptyMissingValues(tree, child)
+		}
+	case *parse.ActionNode:
+		lookupFields(tree, node.Pipe)
+		if len(node.Pipe.Decl) == 0 {
+			node.Pipe.Cmds = append(node.Pipe.Cmds, templateCall(tree, "orEmpty"))
+		}
+	case *parse.IfNode:
+		lookupFields(tree, node.Pipe)
+		emptyMissingValues(tree, node.List)
+		emptyMissingValues(tree, node.ElseList)
+	case *parse.RangeNode:
+		lookupFields(tree, node.Pipe)
+		emptyMissingValues(tree, node.List)
+		emptyMissingValues(tree, node.ElseList)
+	case *parse.WithNode:
+		lookupFields(tree, node.Pipe)
+		emptyMissingValues(tree, node.List)
+		emptyMissingValues(tree, node.ElseList)
+	}
+}
+
+// lookupFields replaces the fields of fields in the commands of the pipeline with (lookupField . "field.field").
+func lookupFields(tree *parse.Tree, pipe *parse.PipeNode) {
+	for _, cmd := range pipe.Cmds {
+		for i, arg := range cmd.Args {
+			field, ok := arg.(*parse.FieldNode)
+			if !ok || len(field.Ident) < 2 {
+				continue
+			}
+			path := strings.Join(field.Ident, ".")
+			lookup := templateCall(tree, "lookupField", &parse.DotNode{NodeType: parse.NodeDot},
+				&parse.StringNode{NodeType: parse.NodeString, Quoted: strconv.Quote(path), Text: path})
+			cmd.Args[i] = &parse.PipeNode{NodeType: parse.NodePipe, Cmds: []*parse.CommandNode{lookup}}
+		}
+	}
+}
+
+func templateCall(tree *parse.Tree, function string, args ...parse.Node) *parse.CommandNode {
+	return &parse.CommandNode{NodeType: parse.NodeCommand, Args: append([]parse.Node{parse.NewIdentifier(function).SetTree(tree)}, args...)}
+}
+
+func orEmpty(value interface{}) interface{} {
+	if value == nil {
+		return ""
+	}
+	return value
+}
+
+// tabulate converts the result into a header and string rows for the given columns.
+func tabulate(data interface{}, columns []string) ([]string, [][]string, error) {
+	items := resultItems(data)
+	if len(columns) == 0 {
+		columns = itemColumns(data)
+	}
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column
+		if column == "" {
+			header[i] = "value"
+		}
+	}
+
+	var rows [][]string
+	for _, item := range items {
+		value, err := toGeneric(item)
+		if err != nil {
+			return nil, nil, err
+		}
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = formatCell(lookupField(value, column))
+		}
+		rows = append(rows, row)
+	}
+	return header, rows, nil
+}
+
+// resultItems returns the items of a list result, or the result itself when it is not a list.
+// A result is treated as a list when it is a slice, or a struct carrying a "data" slice, a
+// slice of a resource type that has default columns, or nothing but a single slice.
+func resultItems(data interface{}) []interface{} {
+	list, ok := resultList(data)
+	if !ok {
+		return []interface{}{data}
+	}
+	items := make([]interface{}, list.Len())
+	for i := range items {
+		items[i] = list.Index(i).Interface()
+	}
+	return items
+}
+
+func resultList(data interface{}) (reflect.Value, bool) {
+	v := indirect(reflect.ValueOf(data))
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return v, true
+	case reflect.Struct:
+		var fields []int
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" || field.Type == resultMetadataType {
+				continue
+			}
+			fields = append(fields, i)
+			if field.Type.Kind() != reflect.Slice {
+				continue
+			}
+			if _, ok := defaultColumns[indirectType(field.Type.Elem())]; ok || jsonFieldName(field) == "data" {
+				return v.Field(i), true
+			}
+		}
+		if len(fields) == 1 && v.Field(fields[0]).Kind() == reflect.Slice {
+			return v.Field(fields[0]), true
+		}
+	}
+	return v, false
+}
+
+// itemColumns returns the default columns of the result items. Results without default columns
+// are printed with their top level scalar fields.
+func itemColumns(data interface{}) []string {
+	itemType := indirectType(reflect.TypeOf(data))
+	if list, ok := resultList(data); ok {
+		itemType = indirectType(list.Type().Elem())
+	}
+	if columns, ok := defaultColumns[itemType]; ok {
+		return columns
+	}
+	if itemType != nil && itemType.Kind() == reflect.Struct {
+		if columns := scalarFields(itemType); len(columns) != 0 {
+			return columns
+		}
+	}
+	return []string{""}
+}
+
+func scalarFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type == resultMetadataType {
+			continue
+		}
+		if field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct {
+			fields = append(fields, scalarFields(indirectType(field.Type))...)
+			continue
+		}
+		name := jsonFieldName(field)
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+		switch fieldType := indirectType(field.Type); fieldType.Kind() {
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+			fields = append(fields, name)
+		case reflect.Struct:
+			if fieldType == timeType {
+				fields = append(fields, name)
+			}
+		}
+	}
+	return fields
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// toGeneric converts a result item into maps and slices keyed by the JSON field names.
+func toGeneric(item interface{}) (interface{}, error) {
+	b, err := json.Marshal(item)
+	if err != nil {
+		return nil, errors.New("Can not marshal the response into JSON format. " + err.Error())
+	}
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return nil, errors.New("Can not unmarshal the response from JSON format. " + err.Error())
+	}
+	return value, nil
+}
+
+func lookupField(value interface{}, path string) interface{} {
+	if path == "" {
+		return value
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		var cells []string
+		for _, item := range v {
+			cells = append(cells, formatCell(item))
+		}
+		return strings.Join(cells, ",")
+	case map[string]interface{}:
+		var keys []string
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var cells []string
+		for _, key := range keys {
+			cells = append(cells, key+"="+formatCell(v[key]))
+		}
+		return strings.Join(cells, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,156 @@
+package command
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/team"
+	gcli "github.com/urfave/cli"
+)
+
+// newRenderContext returns a context with the rendering flags set from the given arguments.
+func newRenderContext(t *testing.T, args ...string) *gcli.Context {
+	t.Helper()
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String("output-format", "", "")
+	set.Bool("pretty", false, "")
+	set.String("columns", "", "")
+	set.String("template", "", "")
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return gcli.NewContext(gcli.NewApp(), set, nil)
+}
+
+func TestRenderOutput(t *testing.T) {
+	teams := []team.ListedTeams{
+		{TeamMeta: team.TeamMeta{Id: "t1", Name: "sre"}, Description: "Site reliability, on call"},
+		{TeamMeta: team.TeamMeta{Id: "t2", Name: "web"}},
+	}
+	tests := []struct {
+		name     string
+		args     []string
+		want     string
+		wantCode int
+	}{
+		{
+			name: "json by default",
+			want: `[{"id":"t1","name":"sre","description":"Site reliability, on call"},{"id":"t2","name":"web"}]`,
+		},
+		{
+			name: "pretty json",
+			args: []string{"--output-format", "JSON", "--pretty"},
+			want: "[\n    {\n        \"id\": \"t1\",\n        \"name\": \"sre\",\n        \"description\": \"Site reliability, on call\"\n    },\n    {\n        \"id\": \"t2\",\n        \"name\": \"web\"\n    }\n]",
+		},
+		{
+			name: "yaml",
+			args: []string{"--output-format", "yaml"},
+			want: "- teammeta:\n    id: t1\n    name: sre\n  description: Site reliability, on call\n- teammeta:\n    id: t2\n    name: web\n  description: \"\"\n",
+		},
+		{
+			name: "table with the default columns",
+			args: []string{"--output-format", "table"},
+			want: "ID  NAME  DESCRIPTION\nt1  sre   Site reliability, on call\nt2  web   \n",
+		},
+		{
+			name: "table with the given columns",
+			args: []string{"--output-format", "table", "--columns", "name, id"},
+			want: "NAME  ID\nsre   t1\nweb   t2\n",
+		},
+		{
+			name: "csv",
+			args: []string{"--output-format", "csv"},
+			want: "id,name,description\nt1,sre,\"Site reliability, on call\"\nt2,web,\n",
+		},
+		{
+			name: "ndjson",
+			args: []string{"--output-format", "ndjson"},
+			want: "{\"id\":\"t1\",\"name\":\"sre\",\"description\":\"Site reliability, on call\"}\n{\"id\":\"t2\",\"name\":\"web\"}",
+		},
+		{
+			name: "template",
+			args: []string{"--output-format", "template", "--template", "{{.id}}: {{.name}}"},
+			want: "t1: sre\nt2: web\n",
+		},
+		{
+			name: "template with missing fields",
+			args: []string{"--output-format", "template", "--template", "{{.name}}: {{.description}}{{with .owner}} {{.name}}{{end}}|{{.owner.name}}"},
+			want: "sre: Site reliability, on call|\nweb: |\n",
+		},
+		{
+			name:     "template without the template flag",
+			args:     []string{"--output-format", "template"},
+			wantCode: ExitCodeUsage,
+		},
+		{
+			name:     "invalid template",
+			args:     []string{"--output-format", "template", "--template", "{{.id"},
+			wantCode: ExitCodeError,
+		},
+		{
+			name:     "unknown format",
+			args:     []string{"--output-format", "xml"},
+			wantCode: ExitCodeUsage,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := renderOutput(newRenderContext(t, test.args...), teams)
+			if test.wantCode != ExitCodeOK {
+				if err == nil || exitCode(err) != test.wantCode {
+					t.Fatalf("renderOutput returned %q, %v, want an error with the exit code %d", output, err, test.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.want {
+				t.Errorf("renderOutput returned\n%q\nwant\n%q", output, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderListResult(t *testing.T) {
+	// list results are printed one row per item of their list field, the metadata is left out
+	result := &alert.ListAlertResult{Alerts: []alert.Alert{
+		{TinyID: "1", Id: "a1", Status: "open", Priority: alert.P1, Message: "Disk is full", Tags: []string{"disk", "prod"}},
+		{TinyID: "2", Id: "a2", Status: "closed", Priority: alert.P3, Message: "CPU"},
+	}}
+	c := newRenderContext(t, "--output-format", "csv", "--columns", "tinyId,message,tags,report.ackTime")
+	output, err := renderOutput(c, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "tinyId,message,tags,report.ackTime\n1,Disk is full,\"disk,prod\",\n2,CPU,,\n"; output != want {
+		t.Errorf("renderOutput returned %q, want %q", output, want)
+	}
+
+	// a single resource is a table of one row
+	output, err = renderOutput(newRenderContext(t, "--output-format", "table", "--columns", "id,status"), &result.Alerts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ID  STATUS\na1  open\n"; output != want {
+		t.Errorf("renderOutput returned %q, want %q", output, want)
+	}
+}
+
+func TestFormatCell(t *testing.T) {
+	cells := map[string]interface{}{
+		"":             nil,
+		"text":         "text",
+		"true":         true,
+		"1546300800":   float64(1546300800),
+		"0.5":          0.5,
+		"disk,prod":    []interface{}{"disk", "prod"},
+		"id=t1,name=x": map[string]interface{}{"name": "x", "id": "t1"},
+	}
+	for want, value := range cells {
+		if got := formatCell(value); got != want {
+			t.Errorf("formatCell(%#v) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/policy"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/schedule"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/service"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/team"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/user"
+	gcli "github.com/urfave/cli"
+)
+
+const (
+	defaultCacheTTL  = 10 * time.Minute
+	resourcePageSize = 100
+)
+
+// Kinds of Opsgenie resources that are only resolved, the other kinds are also completed.
+const (
+	serviceNames     = "services"
+	integrationNames = "integrations"
+	policyNames      = "policies"
+)
+
+// cachedKinds are the kinds of resources listed by lamp cache refresh.
+var cachedKinds = []string{teamNames, userNames, scheduleNames, escalationNames, serviceNames, integrationNames, policyNames, heartbeatNames}
+
+// resourceLabels are the singular names of the kinds used in messages.
+var resourceLabels = map[string]string{
+	teamNames:        "team",
+	userNames:        "user",
+	scheduleNames:    "schedule",
+	escalationNames:  "escalation",
+	serviceNames:     "service",
+	integrationNames: "integration",
+	policyNames:      "policy",
+	heartbeatNames:   "heartbeat",
+}
+
+// resolvedFlags maps the flags taking an ID, whatever the command is, to the kind of the resource.
+var resolvedFlags = map[string]string{
+	"teamId":       teamNames,
+	"userId":       userNames,
+	"serviceId":    serviceNames,
+	"escalationId": escalationNames,
+}
+
+// resolvedCommands maps the commands whose id or identifier flag takes the ID of a resource to the kind of the resource.
+var resolvedCommands = map[string]string{
+	"getTeam":                teamNames,
+	"updateTeam":             teamNames,
+	"deleteTeam":             teamNames,
+	"createRole":             teamNames,
+	"listRoles":              teamNames,
+	"listRoutingRules":       teamNames,
+	"deleteRoutingRule":      teamNames,
+	"listTeamLogs":           teamNames,
+	"getEscalation":          escalationNames,
+	"updateEscalation":       escalationNames,
+	"deleteEscalation":       escalationNames,
+	"getSchedule":            scheduleNames,
+	"updateSchedule":         scheduleNames,
+	"deleteSchedule":         scheduleNames,
+	"getScheduleTimeline":    scheduleNames,
+	"createScheduleRotation": scheduleNames,
+	"getScheduleRotation":    scheduleNames,
+	"listScheduleRotations":  scheduleNames,
+	"updateScheduleRotation": scheduleNames,
+	"deleteScheduleRotation": scheduleNames,
+	"createScheduleOverride": scheduleNames,
+	"getScheduleOverride":    scheduleNames,
+	"listScheduleOverrides":  scheduleNames,
+	"updateScheduleOverride": scheduleNames,
+	"deleteScheduleOverride": scheduleNames,
+	"getOncall":              scheduleNames,
+	"getNextOncall":          scheduleNames,
+	"getService":             serviceNames,
+	"updateService":          serviceNames,
+	"deleteService":          serviceNames,
+}
+
+var idPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}(-[0-9a-fA-F]{4}){3}-[0-9a-fA-F]{12}$`)
+
+// namedResource is a cached resource, users are also found by their full name.
+type namedResource struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"fullName,omitempty"`
+}
+
+// resourceCache keeps the resources read from the disk or listed by the running process, e.g. for batch and shell commands, by account and cache key.
+var resourceCache = struct {
+	sync.Mutex
+	resources map[string][]namedResource
+	listed    map[string]time.Time
+}{resources: map[string][]namedResource{}, listed: map[string]time.Time{}}
+
+// resolvedKind is the kind of the resource whose ID the flag of the command takes, empty if the flag takes no resource ID.
+func resolvedKind(argName string, c *gcli.Context) string {
+	if kind, ok := resolvedFlags[argName]; ok {
+		return kind
+	}
+	if argName != "id" && argName != "identifier" {
+		return ""
+	}
+	switch c.Command.Name {
+	case "enable", "disable":
+		if kind := c.String("type"); kind == "integration" || kind == "policy" {
+			return map[string]string{"integration": integrationNames, "policy": policyNames}[kind]
+		}
+		return ""
+	}
+	if identifierType := c.String("identifierType"); identifierType != "" && identifierType != "id" {
+		return ""
+	}
+	return resolvedCommands[c.Command.Name]
+}
+
+/*
+resolveID returns the ID of the resource named by the value. Values looking like an ID are returned as they are, names are
+looked up in the resources cached per account, which are listed again from Opsgenie when they are older than cacheTTL or the
+name is not found. Dry runs only use the cache.
+*/
+func resolveID(c *gcli.Context, kind string, value string) string {
+	if idPattern.MatchString(value) {
+		return value
+	}
+	teamID := ""
+	if kind == policyNames {
+		teamID, _ = getVal("teamId", c)
+	}
+	api := cliAPI(c)
+	key := resourceCacheKey(kind, teamID)
+	resources, fresh := cachedResources(api.config, key, cacheTTL(c))
+	ids := matchResources(resources, value)
+	if len(ids) == 0 && isDryRun(c) {
+		printMessage(WARN, "The "+resourceLabels[kind]+" "+value+" is not in the cache, run lamp cache refresh to resolve it in dry runs.")
+		return value
+	}
+	if !isDryRun(c) && (len(ids) == 0 || !fresh) {
+		listed, err := refreshResources(api, kind, teamID)
+		if err == nil {
+			ids = matchResources(listed, value)
+		} else if len(ids) == 0 {
+			printMessage(WARN, "Could not list the "+kind+" to resolve "+value+": "+err.Error())
+			return value
+		}
+	}
+	switch len(ids) {
+	case 0:
+		exitOnErr(newError(ExitCodeNotFound, "No "+resourceLabels[kind]+" named "+value+" is found"))
+	case 1:
+		if ids[0] != value {
+			printMessage(DEBUG, "Resolved the "+resourceLabels[kind]+" "+value+" to the id "+ids[0])
+		}
+		return ids[0]
+	default:
+		exitOnUsageErr(fmt.Sprintf("The %s name %s is ambiguous, give one of the ids %s", resourceLabels[kind], value, strings.Join(ids, ", ")))
+	}
+	return value
+}
+
+// matchResources returns the IDs of the resources with the value as ID or name, names are compared case insensitively
+// when no name matches exactly.
+func matchResources(resources []namedResource, value string) []string {
+	for _, equal := range []func(string, string) bool{func(a, b string) bool { return a == b }, strings.EqualFold} {
+		var ids []string
+		for _, resource := range resources {
+			if resource.ID == value {
+				return []string{resource.ID}
+			}
+			if equal(resource.Name, value) || (resource.FullName != "" && equal(resource.FullName, value)) {
+				ids = appendUnique(ids, resource.ID)
+			}
+		}
+		if len(ids) != 0 {
+			return ids
+		}
+	}
+	return nil
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// cacheTTL is how long the listed resources are used, completionCacheTTL is still read for older configurations.
+func cacheTTL(c *gcli.Context) time.Duration {
+	if val, set := grabDuration("cacheTTL", c); set {
+		return val
+	}
+	if val, set := grabDuration("completionCacheTTL", c); set {
+		return val
+	}
+	return defaultCacheTTL
+}
+
+// resourceCacheKey identifies the cached resources of a kind, policies are cached per team.
+func resourceCacheKey(kind string, teamID string) string {
+	if teamID != "" {
+		return kind + "-" + teamID
+	}
+	return kind
+}
+
+// resourceCacheDir is the directory caching the resources of an account, lamp/resources/<hash of the api url and key> in the user cache directory.
+func resourceCacheDir(config *client.Config) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "lamp", "resources", accountHash(config))
+}
+
+// cachedResources returns the cached resources and whether they were listed less than ttl ago.
+func cachedResources(config *client.Config, key string, ttl time.Duration) ([]namedResource, bool) {
+	resourceCache.Lock()
+	defer resourceCache.Unlock()
+	memoryKey := accountHash(config) + "/" + key
+	if resources, ok := resourceCache.resources[memoryKey]; ok {
+		return resources, time.Since(resourceCache.listed[memoryKey]) < ttl
+	}
+	cacheFile := filepath.Join(resourceCacheDir(config), key+".json")
+	info, err := os.Stat(cacheFile)
+	if err != nil {
+		return nil, false
+	}
+	content, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		return nil, false
+	}
+	var resources []namedResource
+	if err := json.Unmarshal(content, &resources); err != nil {
+		printMessage(DEBUG, "Ignoring the invalid cache file "+cacheFile+": "+err.Error())
+		return nil, false
+	}
+	resourceCache.resources[memoryKey] = resources
+	resourceCache.listed[memoryKey] = info.ModTime()
+	return resources, time.Since(info.ModTime()) < ttl
+}
+
+// refreshResources lists the resources of a kind from Opsgenie and caches them.
+func refreshResources(api *API, kind string, teamID string) ([]namedResource, error) {
+	resources, err := listResources(api, kind, teamID)
+	if err != nil {
+		return nil, err
+	}
+	config := api.config
+	key := resourceCacheKey(kind, teamID)
+	resourceCache.Lock()
+	resourceCache.resources[accountHash(config)+"/"+key] = resources
+	resourceCache.listed[accountHash(config)+"/"+key] = time.Now()
+	resourceCache.Unlock()
+
+	dir := resourceCacheDir(config)
+	content, _ := json.Marshal(resources)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		printMessage(DEBUG, "Could not create the cache directory: "+err.Error())
+		return resources, nil
+	}
+	tmp, err := ioutil.TempFile(dir, key+".*.tmp")
+	if err != nil {
+		printMessage(DEBUG, "Could not write the "+kind+" cache: "+err.Error())
+		return resources, nil
+	}
+	_, writeErr := tmp.Write(content)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(tmp.Name(), filepath.Join(dir, key+".json")) != nil {
+		os.Remove(tmp.Name())
+		printMessage(DEBUG, "Could not write the "+kind+" cache.")
+	}
+	return resources, nil
+}
+
+// resourceNames are the sorted names of the resources.
+func resourceNames(resources []namedResource) []string {
+	var names []string
+	for _, resource := range resources {
+		names = append(names, resource.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// listResources lists the IDs and names of the resources of a kind, the policies of the team when a team ID is given.
+func listResources(api *API, kind string, teamID string) ([]namedResource, error) {
+	ctx := rootContext()
+	resources := []namedResource{}
+	switch kind {
+	case teamNames:
+		cli, release, err := api.teamClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		resp, err := cli.List(ctx, &team.ListTeamRequest{})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range resp.Teams {
+			resources = append(resources, namedResource{ID: t.Id, Name: t.Name})
+		}
+	case userNames:
+		cli, release, err := api.userClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		for offset := 0; ; offset += resourcePageSize {
+			resp, err := cli.List(ctx, &user.ListRequest{Limit: resourcePageSize, Offset: offset})
+			if err != nil {
+				return nil, err
+			}
+			for _, u := range resp.Users {
+				resources = append(resources, namedResource{ID: u.Id, Name: u.Username, FullName: u.FullName})
+			}
+			if len(resp.Users) < resourcePageSize {
+				break
+			}
+		}
+	case scheduleNames:
+		cli, release, err := api.scheduleClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		expand := false
+		resp, err := cli.List(ctx, &schedule.ListRequest{Expand: &expand})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range resp.Schedule {
+			resources = append(resources, namedResource{ID: s.Id, Name: s.Name})
+		}
+	case escalationNames:
+		cli, release, err := api.escalationClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		resp, err := cli.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range resp.Escalations {
+			resources = append(resources, namedResource{ID: e.Id, Name: e.Name})
+		}
+	case serviceNames:
+		cli, release, err := api.serviceClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		for offset := 0; ; offset += resourcePageSize {
+			resp, err := cli.List(ctx, &service.ListRequest{Limit: resourcePageSize, Offset: offset})
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range resp.Services {
+				resources = append(resources, namedResource{ID: s.Id, Name: s.Name})
+			}
+			if len(resp.Services) < resourcePageSize {
+				break
+			}
+		}
+	case integrationNames:
+		cli, release, err := api.integrationClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		resp, err := cli.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range resp.Integrations {
+			resources = append(resources, namedResource{ID: i.Id, Name: i.Name})
+		}
+	case policyNames:
+		cli, release, err := api.policyClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		resp, err := cli.ListAlertPolicies(ctx, &policy.ListAlertPoliciesRequest{TeamId: teamID})
+		if err != nil {
+			return nil, err
+		}
+		policies := resp.Policies
+		if teamID != "" {
+			resp, err := cli.ListNotificationPolicies(ctx, &policy.ListNotificationPoliciesRequest{TeamId: teamID})
+			if err != nil {
+				return nil, err
+			}
+			policies = append(policies, resp.Policies...)
+		}
+		for _, p := range policies {
+			resources = append(resources, namedResource{ID: p.Id, Name: p.Name})
+		}
+	case heartbeatNames:
+		cli, release, err := api.heartbeatClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		resp, err := cli.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range resp.Heartbeats {
+			resources = append(resources, namedResource{ID: h.Name, Name: h.Name})
+		}
+	}
+	return resources, nil
+}
+
+// CacheAction refreshes or clears the resources cached for the account of the profile to resolve and complete names.
+func CacheAction(c *gcli.Context) {
+	switch c.Args().First() {
+	case "refresh":
+		kinds := cachedKinds
+		if c.NArg() > 1 {
+			kinds = c.Args().Tail()
+			for _, kind := range kinds {
+				if _, ok := resourceLabels[kind]; !ok {
+					exitOnUsageErr("Unknown kind " + kind + ", specify one of " + strings.Join(cachedKinds, ", "))
+				}
+			}
+		}
+		api := cliAPI(c)
+		for _, kind := range kinds {
+			resources, err := refreshResources(api, kind, "")
+			exitOnErr(err)
+			printResultMessage(c, fmt.Sprintf("Cached %d %s", len(resources), kind))
+		}
+	case "clear":
+		dir := resourceCacheDir(cliConfig(c))
+		if err := os.RemoveAll(dir); err != nil {
+			exitOnErr(newError(ExitCodeError, "Could not clear the cache: "+err.Error()))
+		}
+		resourceCache.Lock()
+		resourceCache.resources = map[string][]namedResource{}
+		resourceCache.listed = map[string]time.Time{}
+		resourceCache.Unlock()
+		printResultMessage(c, "Cleared "+dir)
+	default:
+		gcli.ShowCommandHelp(c, c.Command.Name)
+		exitOnUsageErr("Specify refresh or clear")
+	}
+}
@@ -0,0 +1,214 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/team"
+	"github.com/opsgenie/opsgenie-lamp/mockserver"
+	gcli "github.com/urfave/cli"
+)
+
+// writeResourceCache writes the cache file of the resources of an account and forgets the resources and the configuration read before.
+func writeResourceCache(t *testing.T, apiURL string, key string, resources ...namedResource) string {
+	t.Helper()
+	resourceCache.Lock()
+	resourceCache.resources = map[string][]namedResource{}
+	resourceCache.listed = map[string]time.Time{}
+	resourceCache.Unlock()
+	forgetSharedClients()
+
+	account := &client.Config{ApiKey: "key", OpsGenieAPIURL: client.ApiUrl(apiURL)}
+	path := filepath.Join(resourceCacheDir(account), key+".json")
+	content, _ := json.Marshal(resources)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// forgetSharedClients forgets the configuration and the clients shared by the commands run by a test.
+func forgetSharedClients() {
+	batchClientsMu.Lock()
+	batchClients = map[string]interface{}{}
+	batchClientsMu.Unlock()
+}
+
+func TestMatchResources(t *testing.T) {
+	resources := []namedResource{
+		{ID: "u1", Name: "jane@example.com", FullName: "Jane Doe"},
+		{ID: "u2", Name: "john@example.com", FullName: "John Doe"},
+		{ID: "u3", Name: "JANE@example.com"},
+		{ID: "u4", Name: "ops", FullName: "Ops"},
+		{ID: "u5", Name: "ops-bot", FullName: "Ops"},
+	}
+	matches := map[string][]string{
+		"u2":               {"u2"},
+		"jane@example.com": {"u1"},
+		"JANE@example.com": {"u3"},
+		"Jane@Example.com": {"u1", "u3"},
+		"john doe":         {"u2"},
+		"Ops":              {"u4", "u5"},
+		"nobody":           nil,
+	}
+	for value, want := range matches {
+		if ids := matchResources(resources, value); !reflect.DeepEqual(ids, want) {
+			t.Errorf("matchResources(%q) = %q, want %q", value, ids, want)
+		}
+	}
+}
+
+// resolveContext returns the context of a command with the given flags, e.g. getTeam --id sre.
+func resolveContext(t *testing.T, command string, args ...string) *gcli.Context {
+	t.Helper()
+	set := flag.NewFlagSet(command, flag.ContinueOnError)
+	for _, name := range []string{"id", "identifier", "identifierType", "teamId", "type", "config"} {
+		set.String(name, "", "")
+	}
+	set.Bool("dry-run", false, "")
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	c := gcli.NewContext(gcli.NewApp(), set, nil)
+	c.Command.Name = command
+	return c
+}
+
+func TestResolvedKind(t *testing.T) {
+	tests := []struct {
+		command string
+		args    []string
+		flag    string
+		want    string
+	}{
+		{command: "getTeam", args: []string{"--id", "sre"}, flag: "id", want: teamNames},
+		{command: "getTeam", args: []string{"--id", "sre", "--identifierType", "name"}, flag: "id", want: ""},
+		{command: "getAlert", args: []string{"--id", "a1"}, flag: "id", want: ""},
+		{command: "createAlert", args: []string{"--teamId", "sre"}, flag: "teamId", want: teamNames},
+		{command: "enable", args: []string{"--id", "i1", "--type", "integration"}, flag: "id", want: integrationNames},
+		{command: "enable", args: []string{"--id", "h1", "--type", "heartbeat"}, flag: "id", want: ""},
+		{command: "getSchedule", args: []string{"--identifier", "primary"}, flag: "identifier", want: scheduleNames},
+	}
+	for _, test := range tests {
+		if kind := resolvedKind(test.flag, resolveContext(t, test.command, test.args...)); kind != test.want {
+			t.Errorf("kind of %s %q is %q, want %q", test.command, test.args, kind, test.want)
+		}
+	}
+}
+
+func TestResolveID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-resolver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer unloadConfigFile(dir)
+	defer forgetSharedClients()
+	os.Setenv("XDG_CACHE_HOME", dir)
+	defer os.Unsetenv("XDG_CACHE_HOME")
+
+	server := httptest.NewServer(mockserver.New(mockserver.Options{}))
+	defer server.Close()
+	config := filepath.Join(dir, "lamp.conf")
+	ioutil.WriteFile(config, []byte("apiKey=key\napiUrl="+strings.TrimPrefix(server.URL, "http://")+"\n"), 0600)
+	getTeam := func(args ...string) *gcli.Context {
+		c := resolveContext(t, "getTeam", append([]string{"--config", config}, args...)...)
+		readConfigFile(c)
+		return c
+	}
+
+	// names in a fresh cache are resolved without listing the teams, ids are kept as they are
+	apiURL := strings.TrimPrefix(server.URL, "http://")
+	writeResourceCache(t, apiURL, teamNames, namedResource{ID: "t1", Name: "sre"}, namedResource{ID: "t2", Name: "SRE"})
+	if id := resolveID(getTeam(), teamNames, "sre"); id != "t1" {
+		t.Errorf("sre is resolved to %s, want t1", id)
+	}
+	const uuid = "9a9bc1e4-9d0e-4d2b-b6a5-1e9d6c1e1a2b"
+	if id := resolveID(getTeam(), teamNames, uuid); id != uuid {
+		t.Errorf("id %s is resolved to %s", uuid, id)
+	}
+
+	// an ambiguous name is a usage error
+	batchMode = true
+	defer func() { batchMode = false }()
+	if err := catchExit(func() { resolveID(getTeam(), teamNames, "Sre") }); exitCode(err) != ExitCodeUsage {
+		t.Errorf("resolving an ambiguous name exited with %v, want a usage error", err)
+	}
+
+	// names missing from the cache are listed from Opsgenie, except in dry runs
+	if id := resolveID(getTeam("--dry-run"), teamNames, "database"); id != "database" {
+		t.Errorf("dry run resolved database to %s, want the name kept", id)
+	}
+	if err := catchExit(func() { resolveID(getTeam(), teamNames, "database") }); exitCode(err) != ExitCodeNotFound {
+		t.Errorf("resolving a missing name exited with %v, want not found", err)
+	}
+	account := &client.Config{ApiKey: "key", OpsGenieAPIURL: client.ApiUrl(apiURL)}
+	teams, err := team.NewClient(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := teams.Create(context.Background(), &team.CreateTeamRequest{Name: "database"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := resolveID(getTeam(), teamNames, "database"); id != created.Id {
+		t.Errorf("database is resolved to %s, want the id %s of the listed team", id, created.Id)
+	}
+	if resources, fresh := cachedResources(account, teamNames, time.Minute); !fresh || len(resources) != 1 {
+		t.Errorf("cached %v teams after the listing (fresh %v), want the database team only", resources, fresh)
+	}
+	// the resources are cached per account
+	if resources, _ := cachedResources(&client.Config{ApiKey: "other", OpsGenieAPIURL: client.ApiUrl(apiURL)}, teamNames, time.Hour); len(resources) != 0 {
+		t.Errorf("teams %v of another API key are cached", resources)
+	}
+}
+
+func TestCacheAction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lamp-resolver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer unloadConfigFile(dir)
+	defer forgetSharedClients()
+	os.Setenv("XDG_CACHE_HOME", dir)
+	defer os.Unsetenv("XDG_CACHE_HOME")
+	config := filepath.Join(dir, "lamp.conf")
+	ioutil.WriteFile(config, []byte("apiKey=key\n"), 0600)
+	batchMode = true
+	defer func() { batchMode = false }()
+
+	cache := writeResourceCache(t, string(client.API_URL), userNames, namedResource{ID: "u1", Name: "jane@example.com"})
+	if err := catchExit(func() { CacheAction(resolveContext(t, "cache", "--config", config, "refresh", "users", "alerts")) }); exitCode(err) != ExitCodeUsage {
+		t.Errorf("refreshing an unknown kind exited with %v, want a usage error", err)
+	}
+	if err := catchExit(func() { CacheAction(resolveContext(t, "cache", "--config", config)) }); exitCode(err) != ExitCodeUsage {
+		t.Errorf("cache without a subcommand exited with %v, want a usage error", err)
+	}
+	if _, err := os.Stat(cache); err != nil {
+		t.Fatalf("cache is removed by a failing command: %v", err)
+	}
+
+	if err := catchExit(func() { CacheAction(resolveContext(t, "cache", "--config", config, "clear")) }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(cache)); !os.IsNotExist(err) {
+		t.Errorf("cache directory still exists after clear: %v", err)
+	}
+	if resources, _ := cachedResources(&client.Config{ApiKey: "key", OpsGenieAPIURL: client.API_URL}, userNames, time.Hour); len(resources) != 0 {
+		t.Errorf("resources %v are still cached in memory", resources)
+	}
+}
@@ -0,0 +1,463 @@
+package command
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/og"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/schedule"
+)
+
+// ScheduleIdentifier identifies a schedule by its ID, or by its name when IdentifierType is name.
+type ScheduleIdentifier struct {
+	ID             string
+	IdentifierType string
+}
+
+func (i ScheduleIdentifier) identifierType() schedule.Identifier {
+	if i.IdentifierType == "name" {
+		return schedule.Name
+	}
+	return schedule.Id
+}
+
+// ScheduleParticipant is a participant of a rotation or the responder of an override, Name is a username for users.
+type ScheduleParticipant struct {
+	// Type is user, team or none for rotations, and user, team or escalation for overrides.
+	Type string
+	Name string
+}
+
+type CreateScheduleOptions struct {
+	Name        string
+	Description string
+	Timezone    string
+	Enabled     bool
+	// Team is the name of the owner team.
+	Team string
+}
+
+// CreateSchedule creates a schedule at Opsgenie.
+func (a *API) CreateSchedule(ctx context.Context, opts CreateScheduleOptions) (*schedule.CreateResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	enabled := opts.Enabled
+	return cli.Create(ctx, &schedule.CreateRequest{
+		Name:        opts.Name,
+		Description: opts.Description,
+		Timezone:    opts.Timezone,
+		Enabled:     &enabled,
+		OwnerTeam:   scheduleOwnerTeam(opts.Team),
+	})
+}
+
+func scheduleOwnerTeam(name string) *og.OwnerTeam {
+	if name == "" {
+		return nil
+	}
+	return &og.OwnerTeam{Name: name}
+}
+
+// GetSchedule retrieves the details of a schedule from Opsgenie.
+func (a *API) GetSchedule(ctx context.Context, opts ScheduleIdentifier) (*schedule.GetResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Get(ctx, &schedule.GetRequest{IdentifierType: opts.identifierType(), IdentifierValue: opts.ID})
+}
+
+type ListScheduleOptions struct {
+	Expand bool
+}
+
+// ListSchedule retrieves the schedules from Opsgenie.
+func (a *API) ListSchedule(ctx context.Context, opts ListScheduleOptions) (*schedule.ListResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	expand := opts.Expand
+	return cli.List(ctx, &schedule.ListRequest{Expand: &expand})
+}
+
+type UpdateScheduleOptions struct {
+	ScheduleIdentifier
+	CreateScheduleOptions
+}
+
+// UpdateSchedule updates a schedule at Opsgenie.
+func (a *API) UpdateSchedule(ctx context.Context, opts UpdateScheduleOptions) (*schedule.UpdateResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	enabled := opts.Enabled
+	return cli.Update(ctx, &schedule.UpdateRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		Name:            opts.Name,
+		Description:     opts.Description,
+		Timezone:        opts.Timezone,
+		Enabled:         &enabled,
+		OwnerTeam:       scheduleOwnerTeam(opts.Team),
+	})
+}
+
+// DeleteSchedule deletes a schedule at Opsgenie.
+func (a *API) DeleteSchedule(ctx context.Context, opts ScheduleIdentifier) (*schedule.DeleteResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Delete(ctx, &schedule.DeleteRequest{IdentifierType: opts.identifierType(), IdentifierValue: opts.ID})
+}
+
+type GetScheduleTimelineOptions struct {
+	ScheduleIdentifier
+	// Expand is base, forwarding or override.
+	Expand string
+	// IntervalUnit is weeks by default, or days or months.
+	IntervalUnit string
+	Interval     int
+	Date         *time.Time
+}
+
+// GetScheduleTimeline retrieves the timeline of a schedule from Opsgenie.
+func (a *API) GetScheduleTimeline(ctx context.Context, opts GetScheduleTimelineOptions) (*schedule.TimelineResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	req := schedule.GetTimelineRequest{
+		IdentifierType:  opts.identifierType(),
+		IdentifierValue: opts.ID,
+		IntervalUnit:    schedule.Weeks,
+		Interval:        opts.Interval,
+		Date:            opts.Date,
+	}
+	switch opts.Expand {
+	case "base":
+		req.Expands = []schedule.ExpandType{schedule.Base}
+	case "forwarding":
+		req.Expands = []schedule.ExpandType{schedule.Forwarding}
+	case "override":
+		req.Expands = []schedule.ExpandType{schedule.Override}
+	}
+	switch opts.IntervalUnit {
+	case "days":
+		req.IntervalUnit = schedule.Days
+	case "months":
+		req.IntervalUnit = schedule.Months
+	}
+	return cli.GetTimeline(ctx, &req)
+}
+
+type RotationOptions struct {
+	Name string
+	// Type is hourly, daily or weekly.
+	Type         string
+	StartDate    *time.Time
+	EndDate      *time.Time
+	Length       uint32
+	Participants []ScheduleParticipant
+}
+
+func (o RotationOptions) rotation() *og.Rotation {
+	rotation := og.Rotation{
+		Name:      o.Name,
+		StartDate: o.StartDate,
+		EndDate:   o.EndDate,
+		Length:    o.Length,
+	}
+	switch o.Type {
+	case "hourly":
+		rotation.Type = og.Hourly
+	case "daily":
+		rotation.Type = og.Daily
+	case "weekly":
+		rotation.Type = og.Weekly
+	}
+	if o.Participants != nil {
+		rotation.Participants = []og.Participant{}
+	}
+	for _, participant := range o.Participants {
+		switch participant.Type {
+		case "user":
+			rotation.Participants = append(rotation.Participants, og.Participant{Type: og.User, Username: participant.Name})
+		case "team":
+			rotation.Participants = append(rotation.Participants, og.Participant{Type: og.Team, Name: participant.Name})
+		case "none":
+			rotation.Participants = append(rotation.Participants, og.Participant{Type: og.None})
+		}
+	}
+	return &rotation
+}
+
+type CreateScheduleRotationOptions struct {
+	ScheduleIdentifier
+	Rotation RotationOptions
+}
+
+// CreateScheduleRotation creates a rotation of a schedule at Opsgenie.
+func (a *API) CreateScheduleRotation(ctx context.Context, opts CreateScheduleRotationOptions) (*schedule.CreateRotationResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.CreateRotation(ctx, &schedule.CreateRotationRequest{
+		ScheduleIdentifierType:  opts.identifierType(),
+		ScheduleIdentifierValue: opts.ID,
+		Rotation:                opts.Rotation.rotation(),
+	})
+}
+
+type ScheduleRotationOptions struct {
+	ScheduleIdentifier
+	RotationID string
+}
+
+// GetScheduleRotation retrieves a rotation of a schedule from Opsgenie.
+func (a *API) GetScheduleRotation(ctx context.Context, opts ScheduleRotationOptions) (*schedule.GetRotationResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.GetRotation(ctx, &schedule.GetRotationRequest{
+		ScheduleIdentifierType:  opts.identifierType(),
+		ScheduleIdentifierValue: opts.ID,
+		RotationId:              opts.RotationID,
+	})
+}
+
+// ListScheduleRotations retrieves the rotations of a schedule from Opsgenie.
+func (a *API) ListScheduleRotations(ctx context.Context, opts ScheduleIdentifier) (*schedule.ListRotationsResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.ListRotations(ctx, &schedule.ListRotationsRequest{ScheduleIdentifierType: opts.identifierType(), ScheduleIdentifierValue: opts.ID})
+}
+
+type UpdateScheduleRotationOptions struct {
+	ScheduleRotationOptions
+	Rotation RotationOptions
+}
+
+// UpdateScheduleRotation updates a rotation of a schedule at Opsgenie.
+func (a *API) UpdateScheduleRotation(ctx context.Context, opts UpdateScheduleRotationOptions) (*schedule.UpdateRotationResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.UpdateRotation(ctx, &schedule.UpdateRotationRequest{
+		ScheduleIdentifierType:  opts.identifierType(),
+		ScheduleIdentifierValue: opts.ID,
+		RotationId:              opts.RotationID,
+		Rotation:                opts.Rotation.rotation(),
+	})
+}
+
+// DeleteScheduleRotation deletes a rotation of a schedule at Opsgenie.
+func (a *API) DeleteScheduleRotation(ctx context.Context, opts ScheduleRotationOptions) (*schedule.DeleteResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.DeleteRotation(ctx, &schedule.DeleteRotationRequest{
+		ScheduleIdentifierType:  opts.identifierType(),
+		ScheduleIdentifierValue: opts.ID,
+		RotationId:              opts.RotationID,
+	})
+}
+
+type ScheduleOverrideOptions struct {
+	ScheduleIdentifier
+	Alias string
+}
+
+type CreateScheduleOverrideOptions struct {
+	ScheduleOverrideOptions
+	StartDate time.Time
+	EndDate   time.Time
+	Responder ScheduleParticipant
+	// Rotations are the IDs of the rotations the override applies to, all rotations if it is empty.
+	Rotations []string
+}
+
+// UpdateScheduleOverrideOptions replace the override with the given alias.
+type UpdateScheduleOverrideOptions = CreateScheduleOverrideOptions
+
+func (o CreateScheduleOverrideOptions) responder() schedule.Responder {
+	switch o.Responder.Type {
+	case "user":
+		return schedule.Responder{Type: schedule.UserResponderType, Username: o.Responder.Name}
+	case "team":
+		return schedule.Responder{Type: schedule.TeamResponderType, Name: o.Responder.Name}
+	case "escalation":
+		return schedule.Responder{Type: schedule.EscalationResponderType, Name: o.Responder.Name}
+	}
+	return schedule.Responder{}
+}
+
+func (o CreateScheduleOverrideOptions) rotations() []schedule.RotationIdentifier {
+	if o.Rotations == nil {
+		return nil
+	}
+	rotations := []schedule.RotationIdentifier{}
+	for _, rotationID := range o.Rotations {
+		rotations = append(rotations, schedule.RotationIdentifier{Id: rotationID})
+	}
+	return rotations
+}
+
+// CreateScheduleOverride creates an override of a schedule at Opsgenie.
+func (a *API) CreateScheduleOverride(ctx context.Context, opts CreateScheduleOverrideOptions) (*schedule.CreateScheduleOverrideResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.CreateScheduleOverride(ctx, &schedule.CreateScheduleOverrideRequest{
+		ScheduleIdentifierType: opts.identifierType(),
+		ScheduleIdentifier:     opts.ID,
+		Alias:                  opts.Alias,
+		StartDate:              opts.StartDate,
+		EndDate:                opts.EndDate,
+		User:                   opts.responder(),
+		Rotations:              opts.rotations(),
+	})
+}
+
+// ListScheduleOverrides retrieves the overrides of a schedule from Opsgenie.
+func (a *API) ListScheduleOverrides(ctx context.Context, opts ScheduleIdentifier) (*schedule.ListScheduleOverrideResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.ListScheduleOverride(ctx, &schedule.ListScheduleOverrideRequest{ScheduleIdentifierType: opts.identifierType(), ScheduleIdentifier: opts.ID})
+}
+
+// GetScheduleOverride retrieves an override of a schedule from Opsgenie.
+func (a *API) GetScheduleOverride(ctx context.Context, opts ScheduleOverrideOptions) (*schedule.GetScheduleOverrideResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.GetScheduleOverride(ctx, &schedule.GetScheduleOverrideRequest{
+		ScheduleIdentifierType: opts.identifierType(),
+		ScheduleIdentifier:     opts.ID,
+		Alias:                  opts.Alias,
+	})
+}
+
+// UpdateScheduleOverride updates an override of a schedule at Opsgenie.
+func (a *API) UpdateScheduleOverride(ctx context.Context, opts UpdateScheduleOverrideOptions) (*schedule.UpdateScheduleOverrideResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.UpdateScheduleOverride(ctx, &schedule.UpdateScheduleOverrideRequest{
+		ScheduleIdentifierType: opts.identifierType(),
+		ScheduleIdentifier:     opts.ID,
+		Alias:                  opts.Alias,
+		StartDate:              opts.StartDate,
+		EndDate:                opts.EndDate,
+		User:                   opts.responder(),
+		Rotations:              opts.rotations(),
+	})
+}
+
+// DeleteScheduleOverride deletes an override of a schedule at Opsgenie.
+func (a *API) DeleteScheduleOverride(ctx context.Context, opts ScheduleOverrideOptions) (*schedule.DeleteScheduleOverrideResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.DeleteScheduleOverride(ctx, &schedule.DeleteScheduleOverrideRequest{
+		ScheduleIdentifierType: opts.identifierType(),
+		ScheduleIdentifier:     opts.ID,
+		Alias:                  opts.Alias,
+	})
+}
+
+type OnCallsOptions struct {
+	ScheduleIdentifier
+	// AtTime is the time of the on-calls, now if it is nil.
+	AtTime *time.Time
+	Flat   bool
+}
+
+// GetOnCalls retrieves the on-call participants of a schedule from Opsgenie.
+func (a *API) GetOnCalls(ctx context.Context, opts OnCallsOptions) (*schedule.GetOnCallsResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	flat := opts.Flat
+	return cli.GetOnCalls(ctx, &schedule.GetOnCallsRequest{
+		ScheduleIdentifierType: opts.identifierType(),
+		ScheduleIdentifier:     opts.ID,
+		Date:                   opts.AtTime,
+		Flat:                   &flat,
+	})
+}
+
+// GetNextOnCall retrieves the next on-call participants of a schedule from Opsgenie.
+func (a *API) GetNextOnCall(ctx context.Context, opts OnCallsOptions) (*schedule.GetNextOnCallsResult, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	flat := opts.Flat
+	return cli.GetNextOnCall(ctx, &schedule.GetNextOnCallsRequest{
+		ScheduleIdentifierType: opts.identifierType(),
+		ScheduleIdentifier:     opts.ID,
+		Date:                   opts.AtTime,
+		Flat:                   &flat,
+	})
+}
+
+type ExportOnCallsOptions struct {
+	// User is the username or the ID of the user.
+	User string
+	// ExportTo is the prefix of the path of the exported file, <ExportTo><User>.ics.
+	ExportTo string
+}
+
+// ExportOnCalls exports the on-call times of a user to an ics file and returns the path of the file.
+func (a *API) ExportOnCalls(ctx context.Context, opts ExportOnCallsOptions) (string, error) {
+	cli, release, err := a.scheduleClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	icsFile, err := cli.ExportOnCallUser(ctx, &schedule.ExportOnCallUserRequest{UserIdentifier: opts.User, ExportedFilePath: opts.ExportTo})
+	if err != nil {
+		// the SDK creates the file before sending the request
+		os.Remove(opts.ExportTo + opts.User + ".ics")
+		return "", err
+	}
+	icsFile.Close()
+	return icsFile.Name(), nil
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"context"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/service"
+)
+
+// ServiceIdentifier identifies a service by its ID.
+type ServiceIdentifier struct {
+	ID string
+}
+
+type CreateServiceOptions struct {
+	Name   string
+	TeamID string
+	// Visibility is TEAM_MEMBERS or OPSGENIE_USERS.
+	Visibility  string
+	Description string
+}
+
+// CreateService creates a service at Opsgenie.
+func (a *API) CreateService(ctx context.Context, opts CreateServiceOptions) (*service.CreateResult, error) {
+	cli, release, err := a.serviceClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Create(ctx, &service.CreateRequest{
+		Name:        opts.Name,
+		TeamId:      opts.TeamID,
+		Visibility:  service.Visibility(opts.Visibility),
+		Description: opts.Description,
+	})
+}
+
+type UpdateServiceOptions struct {
+	ServiceIdentifier
+	Name        string
+	Description string
+	Visibility  string
+}
+
+// UpdateService updates a service at Opsgenie.
+func (a *API) UpdateService(ctx context.Context, opts UpdateServiceOptions) (*service.UpdateResult, error) {
+	cli, release, err := a.serviceClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Update(ctx, &service.UpdateRequest{
+		Id:          opts.ID,
+		Name:        opts.Name,
+		Description: opts.Description,
+		Visibility:  service.Visibility(opts.Visibility),
+	})
+}
+
+// DeleteService deletes a service at Opsgenie.
+func (a *API) DeleteService(ctx context.Context, opts ServiceIdentifier) (*service.DeleteResult, error) {
+	cli, release, err := a.serviceClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Delete(ctx, &service.DeleteRequest{Id: opts.ID})
+}
+
+// GetService retrieves a service from Opsgenie.
+func (a *API) GetService(ctx context.Context, opts ServiceIdentifier) (*service.GetResult, error) {
+	cli, release, err := a.serviceClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Get(ctx, &service.GetRequest{Id: opts.ID})
+}
+
+type ListServiceOptions struct {
+	Limit  int
+	Offset int
+}
+
+// ListService retrieves a page of services from Opsgenie.
+func (a *API) ListService(ctx context.Context, opts ListServiceOptions) (*service.ListResult, error) {
+	cli, release, err := a.serviceClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.List(ctx, &service.ListRequest{Limit: opts.Limit, Offset: opts.Offset})
+}
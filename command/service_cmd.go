@@ -3,139 +3,92 @@ package command
 import (
 	"github.com/opsgenie/opsgenie-go-sdk-v2/service"
 	gcli "github.com/urfave/cli"
-	"os"
-	"strconv"
 )
 
-func NewServiceClient(c *gcli.Context) (*service.Client){
-	serviceCli, cliErr := service.NewClient(getConfigurations(c))
-	if cliErr != nil {
-		message := "Can not create the Service client. " + cliErr.Error()
-		printMessage(INFO, message)
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Service Client created.")
-	return serviceCli
+func NewServiceClient(c *gcli.Context) *service.Client {
+	cli, _, err := cliAPI(c).serviceClient(rootContext())
+	exitOnErr(err)
+	printMessage(DEBUG, "Service Client created.")
+	return cli
+}
+
+func grabServiceIdentifier(c *gcli.Context) ServiceIdentifier {
+	opts := ServiceIdentifier{}
+	opts.ID, _ = getVal("id", c)
+	return opts
 }
 
 // CreateServiceAction creates a new service in OpsGenie
 func CreateServiceAction(c *gcli.Context) {
-	cli := NewServiceClient(c)
-	
-	req := service.CreateRequest{}
-	if val, success := getVal("name", c); success {
-		req.Name = val
-	}
-
-	if val, success := getVal("teamId", c); success {
-		req.TeamId = val
-	}
+	opts := CreateServiceOptions{}
+	opts.Name, _ = getVal("name", c)
+	opts.TeamID, _ = getVal("teamId", c)
+	opts.Visibility, _ = getVal("visibility", c)
+	opts.Description, _ = getVal("description", c)
 
-	if val, success := getVal("visibility", c); success {
-		req.Visibility = service.Visibility(val)
-	}
+	printMessage(DEBUG, "Create Service Request Created. Sending to Opsgenie...")
 
-	if val, success := getVal("description", c); success {
-		req.Description = val
-	}
-
-	printMessage(DEBUG,"Create Service Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.Create(nil, &req)
+	resp, err := cliAPI(c).CreateService(rootContext(), opts)
 	exitOnErr(err)
 
-	printMessage(DEBUG,"Creating Service. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Creating Service. RequestID: "+resp.RequestId)
+	printResultMessage(c, "RequestID: "+resp.RequestId)
 }
 
 // UpdateServiceAction updates a service in OpsGenie
 func UpdateServiceAction(c *gcli.Context) {
-	cli := NewServiceClient(c)
-
-	req := service.UpdateRequest{}
-	if val, success := getVal("id", c); success {
-		req.Id = val
-	}
-
-	if val, success := getVal("name", c); success {
-		req.Name = val
-	}
+	opts := UpdateServiceOptions{ServiceIdentifier: grabServiceIdentifier(c)}
+	opts.Name, _ = getVal("name", c)
+	opts.Description, _ = getVal("description", c)
+	opts.Visibility, _ = getVal("visibility", c)
 
-	if val, success := getVal("description", c); success {
-		req.Description = val
-	}
-
-	if val, success := getVal("visibility", c); success {
-		req.Visibility = service.Visibility(val)
-	}
-
-	printMessage(DEBUG,"Update Service Request Created. Sending to Opsgenie...")
+	printMessage(DEBUG, "Update Service Request Created. Sending to Opsgenie...")
 
-	resp, err := cli.Update(nil, &req)
+	resp, err := cliAPI(c).UpdateService(rootContext(), opts)
 	exitOnErr(err)
 
-	printMessage(DEBUG,"Updating Service. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Updating Service. RequestID: "+resp.RequestId)
+	printResultMessage(c, "RequestID: "+resp.RequestId)
 }
 
 // DeleteServiceAction updates a service in OpsGenie
 func DeleteServiceAction(c *gcli.Context) {
-	cli := NewServiceClient(c)
+	printMessage(DEBUG, "Delete Service Request Created. Sending to Opsgenie...")
 
-	req := service.DeleteRequest{}
-	if val, success := getVal("id", c); success {
-		req.Id = val
-	}
-
-	printMessage(DEBUG,"Delete Service Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.Delete(nil, &req)
+	resp, err := cliAPI(c).DeleteService(rootContext(), grabServiceIdentifier(c))
 	exitOnErr(err)
 
-	printMessage(DEBUG,"Deleting Service. RequestID: " + resp.RequestId)
-	printMessage(INFO,"RequestID: " + resp.RequestId)
+	printMessage(DEBUG, "Deleting Service. RequestID: "+resp.RequestId)
+	printResultMessage(c, "RequestID: "+resp.RequestId)
 }
 
 // GetServiceAction updates a service in OpsGenie
 func GetServiceAction(c *gcli.Context) {
-	cli := NewServiceClient(c)
+	printMessage(DEBUG, "Get Service Request Created. Sending to Opsgenie...")
 
-	req := service.GetRequest{}
-	if val, success := getVal("id", c); success {
-		req.Id = val
-	}
-
-	printMessage(DEBUG,"Get Service Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.Get(nil, &req)
+	resp, err := cliAPI(c).GetService(rootContext(), grabServiceIdentifier(c))
 	renderResponse(c, resp, err)
 }
 
 // ListServiceAction updates a service in OpsGenie
 func ListServiceAction(c *gcli.Context) {
-	cli := NewServiceClient(c)
-
-	req := service.ListRequest{}
-	if val, success := getVal("limit", c); success {
-		limit, err := strconv.ParseUint(val, 10, 64)
-		if err != nil {
-			os.Exit(2)
-		}
-		req.Limit = int(limit)
-	}
-
-	if val, success := getVal("offset", c); success {
-		offset, err := strconv.Atoi(val)
-		if err != nil {
-			os.Exit(2)
-		}
-		req.Offset = offset
+	api := cliAPI(c)
+	opts := ListServiceOptions{Limit: int(grabUintFlag("limit", c)), Offset: grabIntFlag("offset", c)}
+
+	printMessage(DEBUG, "Get Service List Request Created. Sending to Opsgenie...")
+
+	if isAllPagesRequested(c) {
+		renderAllPages(c, func() (interface{}, bool, error) {
+			resp, err := api.ListService(rootContext(), opts)
+			if err != nil {
+				return nil, false, err
+			}
+			opts.Offset += len(resp.Services)
+			return resp.Services, resp.Paging.Next != "", nil
+		})
+		return
 	}
 
-	printMessage(DEBUG,"Get Service List Request Created. Sending to Opsgenie...")
-
-	resp, err := cli.List(nil, &req)
+	resp, err := api.ListService(rootContext(), opts)
 	renderResponse(c, resp, err)
-
 }
-
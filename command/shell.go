@@ -0,0 +1,422 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opsgenie/opsgenie-lamp/cfg"
+	gcli "github.com/urfave/cli"
+)
+
+const maxShellHistory = 1000
+
+var (
+	// stdinReader is shared by the shell and the confirmations asked while a command of the shell runs.
+	stdinReader = bufio.NewReader(os.Stdin)
+
+	// shellMode is set while the shell runs, confirmations can be asked although commands run in batch mode.
+	shellMode bool
+
+	// shellRendered is the result rendered by the command running in the shell, whatever the output format is.
+	shellRendered interface{}
+
+	shellVariable = regexp.MustCompile(`\$last((?:\.[A-Za-z0-9_-]+)*)`)
+)
+
+// shellSession is the state kept between the commands of a shell.
+type shellSession struct {
+	c           *gcli.Context
+	defaults    map[string]string
+	last        interface{}
+	history     []string
+	historyFile string
+}
+
+// shellWord is a word of a shell line, words with single quotes are not expanded. Quoted words are not taken for the && of macros.
+type shellWord struct {
+	text   string
+	expand bool
+	quoted bool
+}
+
+/*
+ShellAction reads commands from the standard input until exit or the end of the input, running
+them with a configuration and clients created once for the session. Besides the lamp commands,
+the shell has builtins to switch the profile, to set flag values used by every command, to list
+the previous lines and to run one of them again. $last.<field> is replaced with a field
+of the result of the previous successful command.
+*/
+func ShellAction(c *gcli.Context) {
+	session := &shellSession{c: c, defaults: map[string]string{}, historyFile: grabHistoryFile(c)}
+	session.loadHistory()
+
+	batchConfig = getConfigurations(c)
+	batchMode, shellMode = true, true
+	defer func() {
+		batchMode, shellMode = false, false
+		batchConfig = nil
+		batchClients = map[string]interface{}{}
+	}()
+
+	interactive := isTerminal(os.Stdin)
+	for {
+		if interactive {
+			fmt.Fprint(c.App.Writer, session.prompt())
+		}
+		line, err := stdinReader.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			if !session.run(line) {
+				return
+			}
+		}
+		if err == io.EOF {
+			if interactive {
+				fmt.Fprintln(c.App.Writer)
+			}
+			return
+		} else if err != nil {
+			exitOnErr(newError(ExitCodeError, "Could not read the standard input: "+err.Error()))
+		}
+	}
+}
+
+func grabHistoryFile(c *gcli.Context) string {
+	if val, success := getVal("historyFile", c); success {
+		return val
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "lamp_history")
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (s *shellSession) prompt() string {
+	if profile := cfg.Profile(); profile != "" {
+		return "lamp(" + profile + ")> "
+	}
+	return "lamp> "
+}
+
+// run runs a line of the shell and returns false when the shell should end.
+// Interrupting a line cancels it, the timeout applies to each line.
+func (s *shellSession) run(line string) bool {
+	resetRootContext()
+	if strings.HasPrefix(line, "!") {
+		recalled, err := s.recall(line)
+		if err != nil {
+			printError(err)
+			return true
+		}
+		line = recalled
+		fmt.Fprintln(s.c.App.Writer, line)
+	}
+	s.addHistory(line)
+
+	words, err := splitShellLine(line)
+	if err != nil {
+		printError(err)
+		return true
+	}
+	switch words[0].text {
+	case "exit", "quit":
+		return false
+	case "lines":
+		// history is the command listing the journal
+		for i, entry := range s.history {
+			fmt.Fprintf(s.c.App.Writer, "%5d  %s\n", i+1, entry)
+		}
+		return true
+	case "use":
+		s.useProfile(words[1:])
+		return true
+	case "set":
+		s.set(words[1:])
+		return true
+	case "unset":
+		for _, word := range words[1:] {
+			delete(s.defaults, strings.TrimLeft(word.text, "-"))
+		}
+		return true
+	}
+
+	args, err := s.expand(words)
+	if err != nil {
+		printError(err)
+		return true
+	}
+	name := args[0]
+	args = args[1:]
+	if cmd := s.c.App.Command(name); cmd != nil {
+		args = append(s.defaultArgs(cmd), args...)
+	}
+	shellRendered = nil
+	output, err := runCapturedCommand(s.c, name, args)
+	s.c.App.Writer.Write(output)
+	if err != nil {
+		printError(err)
+		return true
+	}
+	s.last = shellRendered
+	if s.last == nil {
+		s.last = shellResult(output)
+	}
+	return true
+}
+
+// recall returns the line of the history referred by !! or !<number>.
+func (s *shellSession) recall(line string) (string, error) {
+	if len(s.history) == 0 {
+		return "", newError(ExitCodeUsage, "History is empty")
+	}
+	if line == "!!" {
+		return s.history[len(s.history)-1], nil
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(line, "!"))
+	if err != nil || number < 1 || number > len(s.history) {
+		return "", newError(ExitCodeUsage, "Could not find "+line+" in the history")
+	}
+	return s.history[number-1], nil
+}
+
+// useProfile selects another profile of the configuration file, the configuration and clients of the session are created again.
+func (s *shellSession) useProfile(words []shellWord) {
+	if len(words) != 2 || words[0].text != "profile" {
+		fmt.Fprintln(s.c.App.Writer, "Profiles: "+strings.Join(cfg.Profiles(), ", "))
+		printError(newError(ExitCodeUsage, "Usage: use profile <name>"))
+		return
+	}
+	previous := s.c.String("profile")
+	s.c.Set("profile", words[1].text)
+	config := batchConfig
+	batchConfig = nil
+	err := catchExit(func() {
+		config = getConfigurations(s.c)
+	})
+	if err != nil {
+		s.c.Set("profile", previous)
+		cfg.SelectProfile(previous)
+		printError(err)
+	}
+	batchConfig = config
+	batchClientsMu.Lock()
+	batchClients = map[string]interface{}{}
+	batchClientsMu.Unlock()
+}
+
+// set sets a flag value given to every command having the flag, e.g. set output-format table. Without arguments the values are listed.
+func (s *shellSession) set(words []shellWord) {
+	if len(words) == 0 {
+		names := make([]string, 0, len(s.defaults))
+		for name := range s.defaults {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintln(s.c.App.Writer, "profile = "+cfg.Profile())
+		for _, name := range names {
+			fmt.Fprintln(s.c.App.Writer, name+" = "+s.defaults[name])
+		}
+		return
+	}
+	if len(words) != 2 {
+		printError(newError(ExitCodeUsage, "Usage: set <flag> <value>"))
+		return
+	}
+	s.defaults[strings.TrimLeft(words[0].text, "-")] = words[1].text
+}
+
+// defaultArgs returns the flags set with set which the command has, unless they are given in the line.
+func (s *shellSession) defaultArgs(cmd *gcli.Command) []string {
+	var args []string
+	for _, f := range cmd.Flags {
+		for _, name := range strings.Split(f.GetName(), ",") {
+			name = strings.TrimSpace(name)
+			value, ok := s.defaults[name]
+			if !ok {
+				continue
+			}
+			if _, isBool := f.(gcli.BoolFlag); isBool {
+				if value == "true" {
+					args = append(args, "--"+name)
+				}
+			} else {
+				args = append(args, "--"+name, value)
+			}
+		}
+	}
+	return args
+}
+
+// expand replaces the $last variables of the words with the fields of the last result.
+func (s *shellSession) expand(words []shellWord) ([]string, error) {
+	args := make([]string, len(words))
+	for i, word := range words {
+		args[i] = word.text
+		if !word.expand {
+			continue
+		}
+		var err error
+		args[i] = shellVariable.ReplaceAllStringFunc(word.text, func(variable string) string {
+			value, found := lookupShellResult(s.last, strings.TrimPrefix(strings.TrimPrefix(variable, "$last"), "."))
+			if !found {
+				err = newError(ExitCodeUsage, "Variable "+variable+" is not set by the previous command")
+				return ""
+			}
+			return formatCell(value)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// shellResult converts the output of a command not rendering a result into the value of $last. JSON outputs are
+// used as they are, from other outputs the "Name: value" lines become fields and a single line becomes the value itself.
+func shellResult(output []byte) interface{} {
+	var result interface{}
+	if json.Unmarshal(output, &result) == nil {
+		return result
+	}
+	text := strings.TrimSpace(string(output))
+	fields := map[string]interface{}{}
+	for _, line := range strings.Split(text, "\n") {
+		if parts := strings.SplitN(line, ":", 2); len(parts) == 2 && !strings.Contains(parts[0], " ") {
+			fields[parts[0]] = strings.TrimSpace(parts[1])
+		}
+	}
+	if len(fields) == 0 {
+		return text
+	}
+	return fields
+}
+
+// lookupShellResult looks up a dotted path in a result, matching field names case insensitively and list items by index.
+func lookupShellResult(value interface{}, path string) (interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	if path == "" {
+		return value, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			field, ok := v[key]
+			if !ok {
+				for name, candidate := range v {
+					if strings.EqualFold(name, key) {
+						field, ok = candidate, true
+						break
+					}
+				}
+			}
+			if !ok {
+				return nil, false
+			}
+			value = field
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// splitShellLine splits a line into words, supporting single and double quotes and backslash escapes.
+func splitShellLine(line string) ([]shellWord, error) {
+	var words []shellWord
+	var word strings.Builder
+	inWord, expand, quoted := false, true, false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord, quoted = r, true, true
+			if r == '\'' {
+				expand = false
+			}
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, shellWord{text: word.String(), expand: expand, quoted: quoted})
+				word.Reset()
+				inWord, expand, quoted = false, true, false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, newError(ExitCodeUsage, "Unterminated quote or escape in the line")
+	}
+	if inWord {
+		words = append(words, shellWord{text: word.String(), expand: expand, quoted: quoted})
+	}
+	return words, nil
+}
+
+func (s *shellSession) loadHistory() {
+	if s.historyFile == "" {
+		return
+	}
+	file, err := os.Open(s.historyFile)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			s.history = append(s.history, line)
+		}
+	}
+	if len(s.history) > maxShellHistory {
+		s.history = s.history[len(s.history)-maxShellHistory:]
+	}
+}
+
+// addHistory adds a line to the history and appends it to the history file.
+func (s *shellSession) addHistory(line string) {
+	s.history = append(s.history, line)
+	if s.historyFile == "" {
+		return
+	}
+	file, err := os.OpenFile(s.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		printMessage(DEBUG, "Could not write the shell history: "+err.Error())
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
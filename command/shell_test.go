@@ -0,0 +1,164 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	gcli "github.com/urfave/cli"
+)
+
+func TestSplitShellLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []shellWord
+		wantErr bool
+	}{
+		{line: "", want: nil},
+		{line: "  getAlert   --id a1 ", want: []shellWord{{"getAlert", true, false}, {"--id", true, false}, {"a1", true, false}}},
+		{line: "createAlert --message \"Disk is full\"", want: []shellWord{{"createAlert", true, false}, {"--message", true, false}, {"Disk is full", true, true}}},
+		{line: "echo '$last.id' \"$last.id\"", want: []shellWord{{"echo", true, false}, {"$last.id", false, true}, {"$last.id", true, true}}},
+		{line: "note Disk\\ is\\ full", want: []shellWord{{"note", true, false}, {"Disk is full", true, false}}},
+		{line: "a \"say \\\"hi\\\"\" 'it\\'", want: []shellWord{{"a", true, false}, {"say \"hi\"", true, true}, {"it\\", false, true}}},
+		{line: "a \"\"", want: []shellWord{{"a", true, false}, {"", true, true}}},
+		{line: "a\tb", want: []shellWord{{"a", true, false}, {"b", true, false}}},
+		{line: "a && b \"&&\"", want: []shellWord{{"a", true, false}, {"&&", true, false}, {"b", true, false}, {"&&", true, true}}},
+		{line: "createAlert --message \"Disk", wantErr: true},
+		{line: "createAlert --message 'Disk", wantErr: true},
+		{line: "createAlert \\", wantErr: true},
+	}
+	for _, test := range tests {
+		words, err := splitShellLine(test.line)
+		if test.wantErr {
+			if exitCode(err) != ExitCodeUsage {
+				t.Errorf("splitShellLine(%q) returned %v, %v, want a usage error", test.line, words, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(words, test.want) {
+			t.Errorf("splitShellLine(%q) returned %v, %v, want %v", test.line, words, err, test.want)
+		}
+	}
+}
+
+func TestShellExpand(t *testing.T) {
+	s := &shellSession{last: map[string]interface{}{
+		"id":     "a1",
+		"tinyId": "42",
+		"count":  float64(3),
+		"tags":   []interface{}{"disk", "prod"},
+		"owner":  map[string]interface{}{"username": "jane@example.com"},
+	}}
+	expanded := map[string]string{
+		"getAlert --id $last.id":                    "getAlert|--id|a1",
+		"note --note \"alert #$last.tinyId, seen\"": "note|--note|alert #42, seen",
+		"getAlert --id $last.TinyID":                "getAlert|--id|42",
+		"x $last.owner.username":                    "x|jane@example.com",
+		"x $last.tags.1":                            "x|prod",
+		"x $last.count":                             "x|3",
+		"x '$last.id'":                              "x|$last.id",
+	}
+	for line, want := range expanded {
+		words, _ := splitShellLine(line)
+		args, err := s.expand(words)
+		if err != nil || strings.Join(args, "|") != want {
+			t.Errorf("expand(%q) returned %q, %v, want %q", line, args, err, want)
+		}
+	}
+
+	for _, line := range []string{"x $last.alias", "x $last.tags.2", "x $last.id.more"} {
+		words, _ := splitShellLine(line)
+		if args, err := s.expand(words); exitCode(err) != ExitCodeUsage {
+			t.Errorf("expand(%q) returned %q, %v, want a usage error", line, args, err)
+		}
+	}
+
+	// the whole result is used when it is not an object, nothing is set before the first command
+	words, _ := splitShellLine("getAlert --id $last")
+	if args, err := (&shellSession{last: "a1"}).expand(words); err != nil || args[2] != "a1" {
+		t.Errorf("expand of the whole result returned %q, %v", args, err)
+	}
+	if _, err := (&shellSession{}).expand(words); exitCode(err) != ExitCodeUsage {
+		t.Errorf("expand without a previous result returned %v, want a usage error", err)
+	}
+}
+
+func TestShellResult(t *testing.T) {
+	tests := []struct {
+		output string
+		want   interface{}
+	}{
+		{output: `{"id": "a1"}`, want: map[string]interface{}{"id": "a1"}},
+		{output: "RequestID: r1\nAlertID: a1\n", want: map[string]interface{}{"RequestID": "r1", "AlertID": "a1"}},
+		{output: "r1\n", want: "r1"},
+	}
+	for _, test := range tests {
+		if got := shellResult([]byte(test.output)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("shellResult(%q) = %#v, want %#v", test.output, got, test.want)
+		}
+	}
+}
+
+func TestShellSession(t *testing.T) {
+	batchMode, shellMode = true, true
+	defer func() { batchMode, shellMode = false, false }()
+
+	var output bytes.Buffer
+	app := gcli.NewApp()
+	app.Writer = &output
+	app.Commands = []gcli.Command{
+		{Name: "shell"},
+		{
+			Name:  "createAlert",
+			Flags: []gcli.Flag{gcli.StringFlag{Name: "message"}, gcli.StringFlag{Name: "output-format"}},
+			Action: func(c *gcli.Context) {
+				fmt.Fprintf(c.App.Writer, "{\"id\": \"a1\", \"message\": %q, \"format\": %q}\n", c.String("message"), c.String("output-format"))
+			},
+		},
+		{
+			Name:  "getAlert",
+			Flags: []gcli.Flag{gcli.StringFlag{Name: "id"}},
+			Action: func(c *gcli.Context) {
+				exitOnErr(newError(ExitCodeNotFound, "Alert "+c.String("id")+" does not exist"))
+			},
+		},
+	}
+	c := gcli.NewContext(app, flag.NewFlagSet("shell", flag.ContinueOnError), nil)
+	c.Command = app.Commands[0]
+	s := &shellSession{c: c, defaults: map[string]string{}}
+
+	// the errors of the lines are logged and the shell goes on
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	for _, line := range []string{
+		"set output-format table",
+		"createAlert --message \"Disk is full\"",
+		"getAlert --id $last.id",
+		"!2",
+		"shell",
+	} {
+		if !s.run(line) {
+			t.Fatalf("shell ended at %q", line)
+		}
+	}
+	if s.run("exit") {
+		t.Error("shell goes on after exit")
+	}
+
+	created := "{\"id\": \"a1\", \"message\": \"Disk is full\", \"format\": \"table\"}\n"
+	if want := created + "createAlert --message \"Disk is full\"\n" + created; output.String() != want {
+		t.Errorf("printed %q, want %q", output.String(), want)
+	}
+	if !strings.Contains(logged.String(), "Alert a1 does not exist") || !strings.Contains(logged.String(), "can not be run in a shell") {
+		t.Errorf("logged %q, want the errors of the lines", logged.String())
+	}
+	if len(s.history) != 6 || s.history[3] != s.history[1] {
+		t.Errorf("history is %q, want the recalled line in it", s.history)
+	}
+}
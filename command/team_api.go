@@ -0,0 +1,457 @@
+package command
+
+import (
+	"context"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/team"
+)
+
+// TeamIdentifier identifies a team by its name, or by its ID when Name is empty.
+type TeamIdentifier struct {
+	ID   string
+	Name string
+}
+
+func (i TeamIdentifier) identifier() (team.Identifier, string) {
+	if i.Name != "" {
+		return team.Name, i.Name
+	}
+	return team.Id, i.ID
+}
+
+// TeamMemberOptions are a member of a team, the user is identified by its ID, or by its username when UserID is empty.
+type TeamMemberOptions struct {
+	UserID   string
+	UserName string
+	Role     string
+}
+
+type CreateTeamOptions struct {
+	Name        string
+	Description string
+	Member      TeamMemberOptions
+}
+
+// CreateTeam creates a team at Opsgenie with a member.
+func (a *API) CreateTeam(ctx context.Context, opts CreateTeamOptions) (*team.CreateTeamResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Create(ctx, &team.CreateTeamRequest{
+		Name:        opts.Name,
+		Description: opts.Description,
+		Members:     teamMembers(opts.Member),
+	})
+}
+
+type UpdateTeamOptions struct {
+	ID          string
+	Name        string
+	Description string
+	Member      TeamMemberOptions
+}
+
+// UpdateTeam updates a team at Opsgenie, the members of the team are replaced with the member.
+func (a *API) UpdateTeam(ctx context.Context, opts UpdateTeamOptions) (*team.UpdateTeamResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.Update(ctx, &team.UpdateTeamRequest{
+		Id:          opts.ID,
+		Name:        opts.Name,
+		Description: opts.Description,
+		Members:     teamMembers(opts.Member),
+	})
+}
+
+func teamMembers(member TeamMemberOptions) []team.Member {
+	return []team.Member{
+		{
+			User: team.User{
+				Username: member.UserName,
+				ID:       member.UserID,
+			},
+			Role: member.Role,
+		},
+	}
+}
+
+// GetTeam retrieves the details of a team from Opsgenie.
+func (a *API) GetTeam(ctx context.Context, opts TeamIdentifier) (*team.GetTeamResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	identifierType, identifier := opts.identifier()
+	return cli.Get(ctx, &team.GetTeamRequest{IdentifierType: identifierType, IdentifierValue: identifier})
+}
+
+// DeleteTeam deletes a team at Opsgenie.
+func (a *API) DeleteTeam(ctx context.Context, opts TeamIdentifier) (*team.DeleteTeamResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	identifierType, identifier := opts.identifier()
+	return cli.Delete(ctx, &team.DeleteTeamRequest{IdentifierType: identifierType, IdentifierValue: identifier})
+}
+
+// ListTeams retrieves the teams from Opsgenie.
+func (a *API) ListTeams(ctx context.Context) (*team.ListTeamResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cli.List(ctx, &team.ListTeamRequest{})
+}
+
+// ListRoles retrieves the roles of a team from Opsgenie.
+func (a *API) ListRoles(ctx context.Context, opts TeamIdentifier) (*team.ListTeamRoleResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	identifierType, identifier := opts.identifier()
+	return cli.ListRole(ctx, &team.ListTeamRoleRequest{TeamIdentifierType: identifierType, TeamIdentifierValue: identifier})
+}
+
+type CreateRoleOptions struct {
+	TeamIdentifier
+	RoleName string
+	// Rights are the granted rights, see ListRoleRights.
+	Rights []string
+}
+
+// CreateRole creates a role of a team at Opsgenie.
+func (a *API) CreateRole(ctx context.Context, opts CreateRoleOptions) (*team.CreateTeamRoleResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	identifierType, identifier := opts.identifier()
+	roleRights := []team.Right{}
+	granted := true
+	for _, right := range opts.Rights {
+		roleRights = append(roleRights, team.Right{
+			Right:   right,
+			Granted: &granted,
+		})
+	}
+	return cli.CreateRole(ctx, &team.CreateTeamRoleRequest{
+		TeamIdentifierType:  identifierType,
+		TeamIdentifierValue: identifier,
+		Name:                opts.RoleName,
+		Rights:              roleRights,
+	})
+}
+
+// RoleRight is a right which can be granted to the roles of teams.
+type RoleRight struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+}
+
+var roleRights = []RoleRight{
+	{
+		Name:        "manage-members",
+		Description: "Manage Team Members",
+		Category:    "Member Management",
+	},
+	{
+		Name:        "edit-team-roles",
+		Description: "reate/Update Team Roles",
+		Category:    "Member Management",
+	},
+	{
+		Name:        "delete-team-roles",
+		Description: "Delete Team Roles",
+		Category:    "Member Management",
+	},
+	{
+		Name:        "access-member-profiles",
+		Description: "Access Profiles of Team Members",
+		Category:    "Member Management",
+	},
+	{
+		Name:        "edit-member-profiles",
+		Description: "Edit Profiles of Team Members",
+		Category:    "Member Management",
+	},
+	{
+		Name:        "edit-routing-rules",
+		Description: "Create/Update Routing Rules",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "delete-routing-rules",
+		Description: "Delete Routing Rules",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "edit-escalations",
+		Description: "Create/Update Escalations",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "delete-escalations",
+		Description: "Delete Escalations",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "edit-schedules",
+		Description: "Create/Update Schedules",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "delete-schedules",
+		Description: "Delete Schedules",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "edit-integrations",
+		Description: "Create/Update Integrations",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "delete-integrations",
+		Description: "Delete Integrations",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "edit-automation-actions",
+		Description: "Create/Update Automation Actions",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "delete-automation-actions",
+		Description: "Delete Automation Actions",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "edit-heartbeats",
+		Description: "Create/Update Heartbeats",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "delete-heartbeats",
+		Description: "Delete Heartbeats",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "edit-policies",
+		Description: "Create/Update Policies",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "delete-policies",
+		Description: "Delete Policies",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "edit-maintenance",
+		Description: "Create/Update Maintenance",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "delete-maintenance",
+		Description: "Delete Maintenance",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "access-reports",
+		Description: "Access Reports",
+		Category:    "Configurations",
+	},
+	{
+		Name:        "edit-services",
+		Description: "Create/Update Services",
+		Category:    "Incident Configurations",
+	},
+	{
+		Name:        "delete-services",
+		Description: "Delete Services",
+		Category:    "Incident Configurations",
+	},
+	{
+		Name:        "edit-rooms",
+		Description: "Create/Update Rooms",
+		Category:    "Incident Configurations",
+	},
+	{
+		Name:        "delete-rooms",
+		Description: "Delete Rooms",
+		Category:    "Incident Configurations",
+	},
+	{
+		Name:        "subscription-to-services",
+		Description: "Subscription To Services",
+		Category:    "Incident Configurations",
+	},
+}
+
+// ListRoleRights returns the rights which can be granted to the roles of teams, it does not send a request.
+func (a *API) ListRoleRights() []RoleRight {
+	return roleRights
+}
+
+// ListTeamRoutingRules retrieves the routing rules of a team from Opsgenie.
+func (a *API) ListTeamRoutingRules(ctx context.Context, opts TeamIdentifier) (*team.ListRoutingRulesResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	identifierType, identifier := opts.identifier()
+	return cli.ListRoutingRules(ctx, &team.ListRoutingRulesRequest{TeamIdentifierType: identifierType, TeamIdentifierValue: identifier})
+}
+
+type RoutingRuleOptions struct {
+	TeamIdentifier
+	RuleID string
+}
+
+// DeleteTeamRoutingRule deletes a routing rule of a team at Opsgenie.
+func (a *API) DeleteTeamRoutingRule(ctx context.Context, opts RoutingRuleOptions) (*team.DeleteRoutingRuleResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	identifierType, identifier := opts.identifier()
+	return cli.DeleteRoutingRule(ctx, &team.DeleteRoutingRuleRequest{
+		TeamIdentifierType:  identifierType,
+		TeamIdentifierValue: identifier,
+		RoutingRuleId:       opts.RuleID,
+	})
+}
+
+// GetRoutingRule retrieves a routing rule of a team from Opsgenie.
+func (a *API) GetRoutingRule(ctx context.Context, opts RoutingRuleOptions) (*team.GetRoutingRuleResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	identifierType, identifier := opts.identifier()
+	return cli.GetRoutingRule(ctx, &team.GetRoutingRuleRequest{
+		TeamIdentifierType:  identifierType,
+		TeamIdentifierValue: identifier,
+		RoutingRuleId:       opts.RuleID,
+	})
+}
+
+// TeamRoleOptions identify a role of a team, by their names or by their IDs when the names are empty.
+type TeamRoleOptions struct {
+	TeamIdentifier
+	RoleID   string
+	RoleName string
+}
+
+func (o TeamRoleOptions) names() (teamID, teamName, roleID, roleName string) {
+	if o.Name != "" {
+		teamName = o.Name
+	} else {
+		teamID = o.ID
+	}
+	if o.RoleName != "" {
+		roleName = o.RoleName
+	} else {
+		roleID = o.RoleID
+	}
+	return
+}
+
+// GetTeamRole retrieves a role of a team from Opsgenie.
+func (a *API) GetTeamRole(ctx context.Context, opts TeamRoleOptions) (*team.GetTeamRoleResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	teamID, teamName, roleID, roleName := opts.names()
+	return cli.GetRole(ctx, &team.GetTeamRoleRequest{TeamID: teamID, TeamName: teamName, RoleID: roleID, RoleName: roleName})
+}
+
+// DeleteTeamRole deletes a role of a team at Opsgenie.
+func (a *API) DeleteTeamRole(ctx context.Context, opts TeamRoleOptions) (*team.DeleteTeamRoleResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	teamID, teamName, roleID, roleName := opts.names()
+	return cli.DeleteRole(ctx, &team.DeleteTeamRoleRequest{TeamID: teamID, TeamName: teamName, RoleID: roleID, RoleName: roleName})
+}
+
+type TeamMembershipOptions struct {
+	TeamIdentifier
+	Member TeamMemberOptions
+}
+
+// AddMember adds a member to a team at Opsgenie.
+func (a *API) AddMember(ctx context.Context, opts TeamMembershipOptions) (*team.AddTeamMemberResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	identifierType, identifier := opts.identifier()
+	req := team.AddTeamMemberRequest{TeamIdentifierType: identifierType, TeamIdentifierValue: identifier, Role: opts.Member.Role}
+	if opts.Member.UserID != "" {
+		req.User.ID = opts.Member.UserID
+	} else {
+		req.User.Username = opts.Member.UserName
+	}
+	return cli.AddMember(ctx, &req)
+}
+
+// RemoveMember removes a member from a team at Opsgenie.
+func (a *API) RemoveMember(ctx context.Context, opts TeamMembershipOptions) (*team.RemoveTeamMemberResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	identifierType, identifier := opts.identifier()
+	req := team.RemoveTeamMemberRequest{TeamIdentifierType: identifierType, TeamIdentifierValue: identifier}
+	if opts.Member.UserID != "" {
+		req.MemberIdentifierType, req.MemberIdentifierValue = team.Id, opts.Member.UserID
+	} else if opts.Member.UserName != "" {
+		req.MemberIdentifierType, req.MemberIdentifierValue = team.Username, opts.Member.UserName
+	}
+	return cli.RemoveMember(ctx, &req)
+}
+
+type ListTeamLogsOptions struct {
+	TeamIdentifier
+	Limit  int
+	Offset int
+	Order  string
+}
+
+// ListTeamLogs retrieves a page of the logs of a team from Opsgenie.
+func (a *API) ListTeamLogs(ctx context.Context, opts ListTeamLogsOptions) (*team.ListTeamLogsResult, error) {
+	cli, release, err := a.teamClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	identifierType, identifier := opts.identifier()
+	return cli.ListTeamLogs(ctx, &team.ListTeamLogsRequest{
+		IdentifierType:  identifierType,
+		IdentifierValue: identifier,
+		Limit:           opts.Limit,
+		Offset:          opts.Offset,
+		Order:           opts.Order,
+	})
+}
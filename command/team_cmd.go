@@ -1,534 +1,168 @@
 package command
 
 import (
-	"context"
+	"strconv"
+
 	"github.com/opsgenie/opsgenie-go-sdk-v2/team"
 	gcli "github.com/urfave/cli"
-	"os"
-	"strconv"
-	"strings"
 )
 
-
 func NewTeamClient(c *gcli.Context) *team.Client {
-	teamCli, cliErr := team.NewClient(getConfigurations(c))
-	if cliErr != nil {
-		message := "Can not create the team client. " + cliErr.Error()
-		printMessage(INFO,message)
-		os.Exit(1)
-	}
-	printMessage(DEBUG,"Team Client created.")
-	return teamCli
+	cli, _, err := cliAPI(c).teamClient(rootContext())
+	exitOnErr(err)
+	printMessage(DEBUG, "Team Client created.")
+	return cli
 }
 
-func CreateTeamAction(c *gcli.Context) {
-	teamCli := NewTeamClient(c)
-	createTeamRequest := &team.CreateTeamRequest{}
-
-	if teamName, ok := getVal("name",c );ok {
-		createTeamRequest.Name = teamName
-	}
-	if desc, ok := getVal("desc", c); ok {
-		createTeamRequest.Description = desc
-	}
-
-	userName, _ := getVal("userName",c)
-	userID, _ := getVal("userId",c)
+// grabTeamIdentifier reads the team from the given name and id flags, e.g. name and id or teamName and teamId.
+func grabTeamIdentifier(c *gcli.Context, nameFlag string, idFlag string) TeamIdentifier {
+	opts := TeamIdentifier{}
+	opts.Name, _ = getVal(nameFlag, c)
+	opts.ID, _ = getVal(idFlag, c)
+	return opts
+}
 
-	createTeamRequest.Members = []team.Member{
-		{
-			User: team.User{
-				Username: userName,
-				ID: userID,
-			},
-		},
-	}
-	if role, ok := getVal("role", c);ok {
-		createTeamRequest.Members[0].Role = role
-	}
+func grabTeamMember(c *gcli.Context) TeamMemberOptions {
+	opts := TeamMemberOptions{}
+	opts.UserID, _ = getVal("userId", c)
+	opts.UserName, _ = getVal("userName", c)
+	opts.Role, _ = getVal("role", c)
+	return opts
+}
 
-	printResponse(createTeamRequest, nil, c)
+func CreateTeamAction(c *gcli.Context) {
+	opts := CreateTeamOptions{Member: grabTeamMember(c)}
+	opts.Name, _ = getVal("name", c)
+	opts.Description, _ = getVal("desc", c)
 
-	resp, err := teamCli.Create(context.Background(), createTeamRequest)
-	printResponse(resp, err, c)
+	resp, err := cliAPI(c).CreateTeam(rootContext(), opts)
+	renderResponse(c, resp, err)
 }
 
 func UpdateTeamAction(c *gcli.Context) {
-	teamCli := NewTeamClient(c)
-	updateTeamRequest := &team.UpdateTeamRequest{}
-
-	if teamName, ok := getVal("name",c );ok {
-		updateTeamRequest.Name = teamName
-	}
-	if desc, ok := getVal("desc", c); ok {
-		updateTeamRequest.Description = desc
-	}
-	if ID, ok := getVal("id", c); ok {
-		updateTeamRequest.Id = ID
-	}
+	opts := UpdateTeamOptions{Member: grabTeamMember(c)}
+	opts.Name, _ = getVal("name", c)
+	opts.Description, _ = getVal("desc", c)
+	opts.ID, _ = getVal("id", c)
 
-	userName, _ := getVal("userName",c)
-	userID, _ := getVal("userId",c)
-	updateTeamRequest.Members = []team.Member{
-		{
-			User: team.User{
-				Username: userName,
-				ID: userID,
-			},
-		},
-	}
-	if role, ok := getVal("role", c);ok {
-		updateTeamRequest.Members[0].Role = role
-	}
-
-	resp, err := teamCli.Update(context.Background(), updateTeamRequest)
-	printResponse(resp, err, c)
+	resp, err := cliAPI(c).UpdateTeam(rootContext(), opts)
+	renderResponse(c, resp, err)
 }
 
-
 func GetTeamAction(c *gcli.Context) {
-	teamCli := NewTeamClient(c)
-	getTeamRequest := &team.GetTeamRequest{}
-
-	if teamName, ok := getVal("name", c); ok {
-		getTeamRequest = &team.GetTeamRequest{
-			IdentifierType: team.Name,
-			IdentifierValue: teamName,
-		}
-	} else if teamID, ok:= getVal("id", c); ok{
-		getTeamRequest = &team.GetTeamRequest{
-			IdentifierType: team.Id,
-			IdentifierValue: teamID,
-		}
-	}
-
-	resp, err := teamCli.Get(context.Background(), getTeamRequest)
-	printResponse(resp, err, c)
+	resp, err := cliAPI(c).GetTeam(rootContext(), grabTeamIdentifier(c, "name", "id"))
+	renderResponse(c, resp, err)
 }
 
-func DeleteTeamAction(c *gcli.Context){
-	teamCli := NewTeamClient(c)
-	var deleteTeamRequest *team.DeleteTeamRequest
-
-	if teamName, ok := getVal("name", c); ok {
-		deleteTeamRequest = &team.DeleteTeamRequest{
-			IdentifierType: team.Name,
-			IdentifierValue: teamName,
-		}
-	} else if teamID, ok:= getVal("id", c); ok{
-		deleteTeamRequest = &team.DeleteTeamRequest{
-			IdentifierType: team.Id,
-			IdentifierValue: teamID,
-		}
-	}
-	resp, err := teamCli.Delete(context.Background(), deleteTeamRequest)
-	printResponse(resp, err, c)
+func DeleteTeamAction(c *gcli.Context) {
+	resp, err := cliAPI(c).DeleteTeam(rootContext(), grabTeamIdentifier(c, "name", "id"))
+	renderResponse(c, resp, err)
 }
 
-func ListTeamsAction(c *gcli.Context){
-	teamCli := NewTeamClient(c)
-	resp, err := teamCli.List(context.Background(), &team.ListTeamRequest{})
-	printResponse(resp, err, c)
+func ListTeamsAction(c *gcli.Context) {
+	resp, err := cliAPI(c).ListTeams(rootContext())
+	renderResponse(c, resp, err)
 }
 
-func ListRolesAction(c *gcli.Context)  {
-	teamCli := NewTeamClient(c)
-	listTeamRolesRequest := &team.ListTeamRoleRequest{}
-
-	if teamName, ok := getVal("name",c); ok {
-		listTeamRolesRequest = &team.ListTeamRoleRequest{
-			TeamIdentifierType: team.Name,
-			TeamIdentifierValue: teamName,
-		}
-	} else if teamID, ok := getVal("id", c); ok {
-		listTeamRolesRequest = &team.ListTeamRoleRequest{
-			TeamIdentifierType: team.Id,
-			TeamIdentifierValue: teamID,
-		}
-	}
-
-	resp, err := teamCli.ListRole(context.Background(), listTeamRolesRequest)
-	printResponse(resp, err, c)
+func ListRolesAction(c *gcli.Context) {
+	resp, err := cliAPI(c).ListRoles(rootContext(), grabTeamIdentifier(c, "name", "id"))
+	renderResponse(c, resp, err)
 }
 
-func CreateRoleAction(c *gcli.Context)  {
-	teamCli := NewTeamClient(c)
-	createTeamRoleRequest := &team.CreateTeamRoleRequest{}
+func CreateRoleAction(c *gcli.Context) {
+	opts := CreateRoleOptions{TeamIdentifier: grabTeamIdentifier(c, "name", "id"), Rights: grabList("rights", c)}
+	opts.RoleName, _ = getVal("roleName", c)
 
-	if teamName, ok := getVal("name",c); ok {
-		createTeamRoleRequest.TeamIdentifierType = team.Name
-		createTeamRoleRequest.TeamIdentifierValue = teamName
-	} else if teamID, ok := getVal("id", c); ok {
-		createTeamRoleRequest.TeamIdentifierType = team.Id
-		createTeamRoleRequest.TeamIdentifierValue =  teamID
-	}
-	if roleName, ok := getVal("roleName",c); ok {
-		createTeamRoleRequest.Name = roleName
-	}
-
-	roleRights := []team.Right{}
-	granted := true
-	if rightsArgVal, ok := getVal("rights", c); ok {
-		rights := strings.Split(rightsArgVal, ",")
-		for _, right := range rights{
-			roleRights = append(roleRights, team.Right{
-				Right:   right,
-				Granted: &granted,
-			})
-		}
-	}
-	createTeamRoleRequest.Rights = roleRights
-
-	resp, err := teamCli.CreateRole(context.Background(), createTeamRoleRequest)
-	printResponse(resp, err, c)
+	resp, err := cliAPI(c).CreateRole(rootContext(), opts)
+	renderResponse(c, resp, err)
 }
 
-func ListRoleRightsAction(c *gcli.Context){
-	type right struct {
-		Name string `json:"name"`
-		Description string `json:"description"`
-		Category string `json:"category"`
-	}
-	roleRights := []right{
-		{
-			Name: "manage-members",
-			Description: "Manage Team Members",
-			Category: "Member Management",
-		},
-		{
-			Name: "edit-team-roles",
-			Description: "reate/Update Team Roles",
-			Category: "Member Management",
-		},
-		{
-			Name: "delete-team-roles",
-			Description: "Delete Team Roles",
-			Category: "Member Management",
-		},
-		{
-			Name: "access-member-profiles",
-			Description: "Access Profiles of Team Members",
-			Category: "Member Management",
-		},
-		{
-			Name: "edit-member-profiles",
-			Description: "Edit Profiles of Team Members",
-			Category: "Member Management",
-		},
-		{
-			Name: "edit-routing-rules",
-			Description: "Create/Update Routing Rules",
-			Category: "Configurations",
-		},
-		{
-			Name: "delete-routing-rules",
-			Description: "Delete Routing Rules",
-			Category: "Configurations",
-		},
-		{
-			Name: "edit-escalations",
-			Description: "Create/Update Escalations",
-			Category: "Configurations",
-		},
-		{
-			Name: "delete-escalations",
-			Description: "Delete Escalations",
-			Category: "Configurations",
-		},
-		{
-			Name: "edit-schedules",
-			Description: "Create/Update Schedules",
-			Category: "Configurations",
-		},
-		{
-			Name: "delete-schedules",
-			Description: "Delete Schedules",
-			Category: "Configurations",
-		},
-		{
-			Name: "edit-integrations",
-			Description: "Create/Update Integrations",
-			Category: "Configurations",
-		},
-		{
-			Name: "delete-integrations",
-			Description: "Delete Integrations",
-			Category: "Configurations",
-		},
-		{
-			Name: "edit-automation-actions",
-			Description: "Create/Update Automation Actions",
-			Category: "Configurations",
-		},
-		{
-			Name: "delete-automation-actions",
-			Description: "Delete Automation Actions",
-			Category: "Configurations",
-		},
-		{
-			Name: "edit-heartbeats",
-			Description: "Create/Update Heartbeats",
-			Category: "Configurations",
-		},
-		{
-			Name: "delete-heartbeats",
-			Description: "Delete Heartbeats",
-			Category: "Configurations",
-		},
-		{
-			Name: "edit-policies",
-			Description: "Create/Update Policies",
-			Category: "Configurations",
-		},
-		{
-			Name: "delete-policies",
-			Description: "Delete Policies",
-			Category: "Configurations",
-		},
-		{
-			Name: "edit-maintenance",
-			Description: "Create/Update Maintenance",
-			Category: "Configurations",
-		},
-		{
-			Name: "delete-maintenance",
-			Description: "Delete Maintenance",
-			Category: "Configurations",
-		},
-		{
-			Name: "access-reports",
-			Description: "Access Reports",
-			Category: "Configurations",
-		},
-		{
-			Name: "edit-services",
-			Description: "Create/Update Services",
-			Category: "Incident Configurations",
-		},
-		{
-			Name: "delete-services",
-			Description: "Delete Services",
-			Category: "Incident Configurations",
-		},
-		{
-			Name: "edit-rooms",
-			Description: "Create/Update Rooms",
-			Category: "Incident Configurations",
-		},
-		{
-			Name: "delete-rooms",
-			Description: "Delete Rooms",
-			Category: "Incident Configurations",
-		},
-		{
-			Name: "subscription-to-services",
-			Description: "Subscription To Services",
-			Category: "Incident Configurations",
-		},
-	}
-	printResponse(struct {
-		Rights []right `json:"rights"`
-	}{roleRights}, nil , c)
+func ListRoleRightsAction(c *gcli.Context) {
+	renderResult(c, struct {
+		Rights []RoleRight `json:"rights"`
+	}{cliAPI(c).ListRoleRights()})
 }
 
-func ListTeamRoutingRulesAction(c *gcli.Context)  {
-	teamCli:= NewTeamClient(c)
-	listRoutingRulesRequest := &team.ListRoutingRulesRequest{}
-
-	if teamName, ok := getVal("name",c); ok {
-		listRoutingRulesRequest = &team.ListRoutingRulesRequest{
-			TeamIdentifierType: team.Name,
-			TeamIdentifierValue: teamName,
-		}
-	} else if teamID, ok := getVal("id", c); ok {
-		listRoutingRulesRequest = &team.ListRoutingRulesRequest{
-			TeamIdentifierType: team.Id,
-			TeamIdentifierValue: teamID,
-		}
-	}
-
-	resp, err := teamCli.ListRoutingRules(context.Background(), listRoutingRulesRequest)
-	printResponse(resp, err, c)
+func ListTeamRoutingRulesAction(c *gcli.Context) {
+	resp, err := cliAPI(c).ListTeamRoutingRules(rootContext(), grabTeamIdentifier(c, "name", "id"))
+	renderResponse(c, resp, err)
 }
 
-func DeleteTeamRoutingRuleAction(c *gcli.Context)  {
-	teamCli := NewTeamClient(c)
-	deleteRoutingRuleRequest := &team.DeleteRoutingRuleRequest{}
+func DeleteTeamRoutingRuleAction(c *gcli.Context) {
+	opts := RoutingRuleOptions{TeamIdentifier: grabTeamIdentifier(c, "name", "id")}
+	opts.RuleID, _ = getVal("ruleId", c)
 
-	if teamName, ok := getVal("name",c); ok {
-		deleteRoutingRuleRequest = &team.DeleteRoutingRuleRequest{
-			TeamIdentifierType: team.Name,
-			TeamIdentifierValue: teamName,
-		}
-	} else if teamID, ok := getVal("id", c); ok {
-		deleteRoutingRuleRequest = &team.DeleteRoutingRuleRequest{
-			TeamIdentifierType: team.Id,
-			TeamIdentifierValue: teamID,
-		}
-	}
-	if ruleID, ok := getVal("ruleId", c); ok {
-		deleteRoutingRuleRequest.RoutingRuleId = ruleID
-	}
-
-	resp, err := teamCli.DeleteRoutingRule(context.Background(), deleteRoutingRuleRequest)
-	printResponse(resp, err, c)
+	resp, err := cliAPI(c).DeleteTeamRoutingRule(rootContext(), opts)
+	renderResponse(c, resp, err)
 }
 
+func grabTeamRoleOptions(c *gcli.Context) TeamRoleOptions {
+	opts := TeamRoleOptions{TeamIdentifier: grabTeamIdentifier(c, "teamName", "teamId")}
+	opts.RoleName, _ = getVal("roleName", c)
+	opts.RoleID, _ = getVal("roleId", c)
+	return opts
+}
 
 func GetTeamRoleAction(c *gcli.Context) {
-	teamCli := NewTeamClient(c)
-	getTeamRoleRequest := &team.GetTeamRoleRequest{}
-
-	if teamName, ok := getVal("teamName",c); ok {
-		getTeamRoleRequest.TeamName = teamName
-	} else if teamID, ok := getVal("teamId",c);ok {
-		getTeamRoleRequest.TeamID = teamID
-	}
-
-	if roleName, ok := getVal("roleName",c); ok {
-		getTeamRoleRequest.RoleName = roleName
-	} else if roleID, ok := getVal("roleId",c);ok {
-		getTeamRoleRequest.RoleID = roleID
-	}
-
-	resp, err := teamCli.GetRole(context.Background(), getTeamRoleRequest)
-	printResponse(resp, err, c)
+	resp, err := cliAPI(c).GetTeamRole(rootContext(), grabTeamRoleOptions(c))
+	renderResponse(c, resp, err)
 }
 
-
 func DeleteTeamRoleAction(c *gcli.Context) {
-	teamCli := NewTeamClient(c)
-	deleteTeamRoleRequest := &team.DeleteTeamRoleRequest{}
-
-	if teamName, ok := getVal("teamName",c); ok {
-		deleteTeamRoleRequest.TeamName = teamName
-	} else if teamID, ok := getVal("teamId",c);ok {
-		deleteTeamRoleRequest.TeamID = teamID
-	}
-
-	if roleName, ok := getVal("roleName",c); ok {
-		deleteTeamRoleRequest.RoleName = roleName
-	} else if roleID, ok := getVal("roleId",c);ok {
-		deleteTeamRoleRequest.RoleID = roleID
-	}
-
-	resp, err := teamCli.DeleteRole(context.Background(), deleteTeamRoleRequest)
-	printResponse(resp, err, c)
+	resp, err := cliAPI(c).DeleteTeamRole(rootContext(), grabTeamRoleOptions(c))
+	renderResponse(c, resp, err)
 }
 
 func AddMemberAction(c *gcli.Context) {
-	teamCli := NewTeamClient(c)
-	addMemberRequest := &team.AddTeamMemberRequest{}
-
-	if teamName, ok := getVal("teamName",c); ok {
-		addMemberRequest.TeamIdentifierType = team.Name
-		addMemberRequest.TeamIdentifierValue = teamName
-	} else if teamID, ok := getVal("teamId", c); ok {
-		addMemberRequest.TeamIdentifierType = team.Id
-		addMemberRequest.TeamIdentifierValue = teamID
-	}
-
-	if role, ok := getVal("role",c); ok {
-		addMemberRequest.Role = role
-	}
-
-	if userID, ok := getVal("userId",c); ok {
-		addMemberRequest.User.ID = userID
-	} else if userName, ok := getVal("userName",c); ok {
-		addMemberRequest.User.Username = userName
-	}
+	opts := TeamMembershipOptions{TeamIdentifier: grabTeamIdentifier(c, "teamName", "teamId"), Member: grabTeamMember(c)}
 
-	resp, err := teamCli.AddMember(context.Background(), addMemberRequest)
-	printResponse(resp, err, c)
+	resp, err := cliAPI(c).AddMember(rootContext(), opts)
+	renderResponse(c, resp, err)
 }
 
 func RemoveMemberAction(c *gcli.Context) {
-	teamCli := NewTeamClient(c)
-	removeMemberRequest := &team.RemoveTeamMemberRequest{}
-
-	if teamName, ok := getVal("teamName",c); ok {
-		removeMemberRequest.TeamIdentifierType = team.Name
-		removeMemberRequest.TeamIdentifierValue = teamName
-	} else if teamID, ok := getVal("teamId", c); ok {
-		removeMemberRequest.TeamIdentifierType = team.Id
-		removeMemberRequest.TeamIdentifierValue = teamID
-	}
-
-	if userID, ok := getVal("userId",c); ok {
-		removeMemberRequest.MemberIdentifierType = team.Id
-		removeMemberRequest.MemberIdentifierValue = userID
-	} else if userName, ok := getVal("userName",c); ok {
-		removeMemberRequest.MemberIdentifierType = team.Username
-		removeMemberRequest.MemberIdentifierValue = userName
-	}
+	opts := TeamMembershipOptions{TeamIdentifier: grabTeamIdentifier(c, "teamName", "teamId"), Member: grabTeamMember(c)}
 
-	resp, err := teamCli.RemoveMember(context.Background(), removeMemberRequest)
-	printResponse(resp, err, c)
+	resp, err := cliAPI(c).RemoveMember(rootContext(), opts)
+	renderResponse(c, resp, err)
 }
 
 func GetRoutingRuleAction(c *gcli.Context) {
-	teamCli := NewTeamClient(c)
-	getRoutingRuleRequest := &team.GetRoutingRuleRequest{}
+	opts := RoutingRuleOptions{TeamIdentifier: grabTeamIdentifier(c, "teamName", "teamId")}
+	opts.RuleID, _ = getVal("ruleId", c)
 
-	if teamName, ok := getVal("teamName",c); ok {
-		getRoutingRuleRequest.TeamIdentifierType = team.Name
-		getRoutingRuleRequest.TeamIdentifierValue = teamName
-	} else if teamID, ok := getVal("teamId", c); ok {
-		getRoutingRuleRequest.TeamIdentifierType = team.Id
-		getRoutingRuleRequest.TeamIdentifierValue = teamID
-	}
-
-	if ruleID, ok := getVal("ruleId",c); ok {
-		getRoutingRuleRequest.RoutingRuleId = ruleID
-	}
-
-	resp, err := teamCli.GetRoutingRule(context.Background(), getRoutingRuleRequest)
-	printResponse(resp, err, c)
+	resp, err := cliAPI(c).GetRoutingRule(rootContext(), opts)
+	renderResponse(c, resp, err)
 }
 
 func ListTeamLogsAction(c *gcli.Context) {
-	teamCli := NewTeamClient(c)
-	listLogsRequest := &team.ListTeamLogsRequest{}
-
-	if teamName, ok := getVal("name", c); ok {
-			listLogsRequest.IdentifierType = team.Name
-			listLogsRequest.IdentifierValue = teamName
-	} else if teamID, ok:= getVal("id", c); ok{
-		listLogsRequest.IdentifierType = team.Id
-		listLogsRequest.IdentifierValue = teamID
-	}
-
-	if limit, ok := getVal("limit", c); ok {
-		limit, err := strconv.Atoi(limit)
-		if err != nil {
-			os.Exit(2)
-		}
-		listLogsRequest.Limit = limit
-	}
-	if offset, ok := getVal("offset", c); ok {
-		offset, err := strconv.Atoi(offset)
-		if err != nil {
-			os.Exit(2)
-		}
-		listLogsRequest.Offset = offset
-	}
-	if order, ok := getVal("order", c); ok {
-		listLogsRequest.Order = order
-	}
-	resp, err := teamCli.ListTeamLogs(context.Background(), listLogsRequest)
-	printResponse(resp, err, c)
-}
-
-func printResponse(resp interface{},err error, c *gcli.Context) {
-	if err != nil {
-		os.Exit(1)
-	}
-
-	isPretty := c.IsSet("pretty")
-	output, err := resultToJSON(resp, isPretty)
-	if err != nil {
-		printMessage(ERROR,err.Error())
-		os.Exit(1)
-	}
-	printMessage(INFO,output)
+	api := cliAPI(c)
+	opts := ListTeamLogsOptions{
+		TeamIdentifier: grabTeamIdentifier(c, "name", "id"),
+		Limit:          grabIntFlag("limit", c),
+		Offset:         grabIntFlag("offset", c),
+	}
+	opts.Order, _ = getVal("order", c)
+
+	if isAllPagesRequested(c) {
+		renderAllPages(c, func() (interface{}, bool, error) {
+			resp, err := api.ListTeamLogs(rootContext(), opts)
+			if err != nil {
+				return nil, false, err
+			}
+			if resp.Offset == "" || len(resp.Logs) == 0 {
+				return resp.Logs, false, nil
+			}
+			offset, err := strconv.Atoi(resp.Offset)
+			if err != nil {
+				return nil, false, err
+			}
+			opts.Offset = offset
+			return resp.Logs, true, nil
+		})
+		return
+	}
+
+	resp, err := api.ListTeamLogs(rootContext(), opts)
+	renderResponse(c, resp, err)
 }
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/user"
+)
+
+type ExportUsersOptions struct {
+	Query string
+	// DestinationPath is the directory of the result.csv file, the current directory if it is empty.
+	DestinationPath string
+}
+
+// ExportUsers writes the users matching the query to a CSV file, it returns the path of the file.
+func (a *API) ExportUsers(ctx context.Context, opts ExportUsersOptions) (string, error) {
+	cli, release, err := a.userClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	var users []user.User
+	req := user.ListRequest{Limit: 100, Query: opts.Query}
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		resp, err := cli.List(ctx, &req)
+		if err != nil {
+			return "", err
+		}
+		users = append(users, resp.Users...)
+		if len(resp.Users) < req.Limit {
+			break
+		}
+		req.Offset += req.Limit
+	}
+
+	destinationPath := opts.DestinationPath
+	if destinationPath == "" {
+		destinationPath = "."
+	}
+	path := filepath.Join(destinationPath, "result.csv")
+	err = writeFileSafely(path, func(w io.Writer) error {
+		_, err := w.Write(createCsv(users))
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
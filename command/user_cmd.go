@@ -2,109 +2,45 @@ package command
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
-	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
-	"github.com/opsgenie/opsgenie-go-sdk-v2/user"
-	gcli "github.com/urfave/cli"
-	"os"
 	"strconv"
 	"time"
-)
 
-var configurations *client.Config
+	"github.com/opsgenie/opsgenie-go-sdk-v2/user"
+	gcli "github.com/urfave/cli"
+)
 
 func NewUserClient(c *gcli.Context) (*user.Client, error) {
-	configurations = getConfigurations(c)
-	userCli, cliErr := user.NewClient(configurations)
-	if cliErr != nil {
-		message := "Can not create the user client. " + cliErr.Error()
-		printMessage(INFO,message)
-		return nil, errors.New(message)
+	cli, _, err := cliAPI(c).userClient(rootContext())
+	if err != nil {
+		return nil, err
 	}
-	printMessage(DEBUG,"User Client created.")
-	return userCli, nil
+	printMessage(DEBUG, "User Client created.")
+	return cli, nil
 }
 
 // ListUsersAction retrieves users from Opsgenie.
 func ExportUsersAction(c *gcli.Context) {
-	cli, err := NewUserClient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	printMessage(DEBUG,"List users request prepared from flags, sending request to Opsgenie..")
-
-	var users []user.User
-	var offset = 0
-
-	req := generateListUsersRequest(c)
-	for {
-		req.Offset = offset
-		resp, err := cli.List(nil, &req)
-
-		if err != nil {
-			printMessage(ERROR,err.Error())
-			os.Exit(1)
-		}
-		users = append(users, resp.Users...)
-
-		if len(resp.Users) < req.Limit {
-			break
-		} else {
-			offset = offset + req.Limit
-		}
-	}
-	writeCsv(c, users)
-}
-
-func createFile(p string) *os.File {
-	f, err := os.Create(p)
-	if err != nil {
-		configurations.Logger.Fatal("Cannot create file", err)
-	}
-	return f
-}
-
-func generateListUsersRequest(c *gcli.Context) user.ListRequest {
-	req := user.ListRequest{}
-	req.Limit = 100
-
+	opts := ExportUsersOptions{}
 	if val, success := getVal("query", c); success {
-		req.Query = val
-		printMessage(DEBUG,"Listing users with given query")
+		opts.Query = val
+		printMessage(DEBUG, "Listing users with given query")
 	}
-
-	return req
-}
-
-func writeCsv(c *gcli.Context, users []user.User) {
-	csv, err := createCsv(users)
-
-	if err != nil {
-		configurations.Logger.Fatal(err)
+	if val, success := getVal("destinationPath", c); success {
+		opts.DestinationPath = val
+		printMessage(DEBUG, fmt.Sprintf("Creating report file under: %s", opts.DestinationPath))
 	} else {
-		destinationPath := "."
-		if val, success := getVal("destinationPath", c); success {
-			destinationPath = val
-			printMessage(DEBUG,fmt.Sprintf("Creating report file under: %s", destinationPath))
-		} else {
-			printMessage(DEBUG,"Creating report file into current directory..")
-		}
-		file := createFile(destinationPath + "/result.csv")
-		defer file.Close()
+		printMessage(DEBUG, "Creating report file into current directory..")
+	}
 
-		_, err := file.Write(csv)
+	printMessage(DEBUG, "List users request prepared from flags, sending request to Opsgenie..")
 
-		if err != nil {
-			configurations.Logger.Fatal(err)
-		} else {
-			printMessage(DEBUG,"The output file named result.csv has just been created.")
-		}
-	}
+	_, err := cliAPI(c).ExportUsers(rootContext(), opts)
+	exitOnErr(err)
+	printMessage(DEBUG, "The output file named result.csv has just been created.")
 }
 
-func createCsv(users []user.User) ([]byte, error) {
+func createCsv(users []user.User) []byte {
 	var buf bytes.Buffer
 	headers := []string{"id", "blocked", "verified", "username", "fullname", "roleName", "timezone",
 		"locale", "country", "state", "city", "line", "zipcode", "createdAt"}
@@ -117,7 +53,7 @@ func createCsv(users []user.User) ([]byte, error) {
 		buf.WriteString("\n")
 	}
 
-	return buf.Bytes(), nil
+	return buf.Bytes()
 }
 
 func writeHeaders(buf *bytes.Buffer, headers []string) {
@@ -0,0 +1,198 @@
+package command
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-go-sdk-v2/incident"
+	"github.com/sirupsen/logrus"
+	gcli "github.com/urfave/cli"
+)
+
+const (
+	defaultWaitTimeout = time.Minute
+	minPollInterval    = 500 * time.Millisecond
+	maxPollInterval    = 5 * time.Second
+)
+
+// RequestOutcome is printed when an asynchronous request is processed, with the ids of the alert or incident.
+type RequestOutcome struct {
+	RequestID   string `json:"requestId"`
+	Action      string `json:"action"`
+	Success     bool   `json:"success"`
+	Status      string `json:"status"`
+	AlertID     string `json:"alertId,omitempty"`
+	IncidentID  string `json:"incidentId,omitempty"`
+	TinyID      string `json:"tinyId,omitempty"`
+	Alias       string `json:"alias,omitempty"`
+	ProcessedAt string `json:"processedAt,omitempty"`
+}
+
+// statusFetcher gets the status of a request once, it returns nil while the request is not processed.
+type statusFetcher func(ctx context.Context) (*RequestOutcome, error)
+
+func isWaitRequested(c *gcli.Context) bool {
+	return c.Bool("wait")
+}
+
+func grabWaitTimeout(c *gcli.Context) time.Duration {
+	if timeout, set := grabDuration("wait-timeout", c); set {
+		return timeout
+	}
+	return defaultWaitTimeout
+}
+
+// isRequestNotProcessed checks whether the request status endpoint responded that the request is not processed yet.
+func isRequestNotProcessed(err error) bool {
+	apiErr, ok := err.(*client.ApiError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+/*
+pollRequestStatus gets the status of a request until it is processed, waiting longer between
+each poll up to maxPollInterval and logging the polls to the logger. It fails with ExitCodeTimeout
+if the request is not processed within the timeout, defaultWaitTimeout if it is 0.
+*/
+func pollRequestStatus(ctx context.Context, logger *logrus.Logger, timeout time.Duration, requestID string, fetchStatus statusFetcher) (*RequestOutcome, error) {
+	if timeout == 0 {
+		timeout = defaultWaitTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	interval := minPollInterval
+	for {
+		outcome, err := fetchStatus(ctx)
+		if err != nil && !isRequestNotProcessed(err) {
+			if ctx.Err() != nil {
+				return nil, newError(ExitCodeTimeout, "Request "+requestID+" is not processed in "+timeout.String())
+			}
+			return nil, err
+		}
+		if outcome != nil {
+			return outcome, nil
+		}
+		logger.Debug("Request " + requestID + " is not processed yet, will check again in " + interval.String())
+		select {
+		case <-ctx.Done():
+			return nil, newError(ExitCodeTimeout, "Request "+requestID+" is not processed in "+timeout.String())
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+}
+
+// alertStatusFetcher gets the status of an alert request and the tiny id of the alert once it is processed, each
+// fetch takes a client of the API so that waiting does not hold one.
+func alertStatusFetcher(a *API, requestID string) statusFetcher {
+	return func(ctx context.Context) (*RequestOutcome, error) {
+		cli, release, err := a.alertClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		status, err := cli.GetRequestStatus(ctx, &alert.GetRequestStatusRequest{RequestId: requestID})
+		if err != nil {
+			return nil, err
+		}
+		outcome := &RequestOutcome{
+			RequestID: requestID,
+			Action:    status.Action,
+			Success:   status.IsSuccess,
+			Status:    status.Status,
+			AlertID:   status.AlertID,
+			Alias:     status.Alias,
+		}
+		if !status.ProcessedAt.IsZero() {
+			outcome.ProcessedAt = status.ProcessedAt.Format(time.RFC3339Nano)
+		}
+		if status.IsSuccess && status.AlertID != "" {
+			if a, err := cli.Get(ctx, &alert.GetAlertRequest{IdentifierType: alert.ALERTID, IdentifierValue: status.AlertID}); err == nil {
+				outcome.TinyID = a.TinyId
+			}
+		}
+		return outcome, nil
+	}
+}
+
+// incidentStatusFetcher gets the status of an incident request and the tiny id of the incident once it is processed.
+func incidentStatusFetcher(a *API, requestID string) statusFetcher {
+	return func(ctx context.Context) (*RequestOutcome, error) {
+		cli, release, err := a.incidentClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		status, err := cli.GetRequestStatus(ctx, &incident.RequestStatusRequest{Id: requestID})
+		if err != nil {
+			return nil, err
+		}
+		outcome := &RequestOutcome{
+			RequestID:   requestID,
+			Action:      status.Action,
+			Success:     status.IsSuccess || status.Success,
+			Status:      status.Status,
+			IncidentID:  status.IncidentId,
+			ProcessedAt: status.ProcessedAt,
+		}
+		if outcome.Success && status.IncidentId != "" {
+			if i, err := cli.Get(ctx, &incident.GetRequest{Id: status.IncidentId, Identifier: incident.Id}); err == nil {
+				outcome.TinyID = i.TinyId
+			}
+		}
+		return outcome, nil
+	}
+}
+
+// printRequestOutcome renders the outcome of a processed request, the command fails if the processing failed.
+func printRequestOutcome(c *gcli.Context, outcome *RequestOutcome) {
+	renderResult(c, outcome)
+	if !outcome.Success {
+		exitOnErr(newError(ExitCodeError, "Request "+outcome.RequestID+" is processed with failure: "+outcome.Status))
+	}
+}
+
+// printAlertRequest prints the id of an alert request, or waits until it is processed when the wait flag is given.
+func printAlertRequest(c *gcli.Context, api *API, requestID string, message string) {
+	if !isWaitRequested(c) {
+		printResult(c, message, logFields{"requestId": requestID})
+		return
+	}
+	outcome, err := api.WaitForAlertRequest(rootContext(), requestID, grabWaitTimeout(c))
+	exitOnErr(err)
+	printRequestOutcome(c, outcome)
+}
+
+// printIncidentRequest prints the id of an incident request, or waits until it is processed when the wait flag is given.
+func printIncidentRequest(c *gcli.Context, api *API, requestID string, message string) {
+	if !isWaitRequested(c) {
+		printResult(c, message, logFields{"requestId": requestID})
+		return
+	}
+	outcome, err := api.WaitForIncidentRequest(rootContext(), requestID, grabWaitTimeout(c))
+	exitOnErr(err)
+	printRequestOutcome(c, outcome)
+}
+
+// GetRequestStatusAction gets the status of an asynchronous alert or incident request, waiting for it when the wait flag is given.
+func GetRequestStatusAction(c *gcli.Context) {
+	requestID, success := getVal("id", c)
+	if !success {
+		exitOnUsageErr("Request id should be given with id")
+	}
+
+	requestType := c.String("type")
+	if requestType != "" && requestType != "alert" && requestType != "incident" {
+		exitOnUsageErr("Invalid type " + requestType + ", specify alert or incident")
+	}
+	opts := GetRequestStatusOptions{RequestID: requestID, Type: requestType, Wait: isWaitRequested(c), WaitTimeout: grabWaitTimeout(c)}
+
+	outcome, err := cliAPI(c).GetRequestStatus(rootContext(), opts)
+	exitOnErr(err)
+	printRequestOutcome(c, outcome)
+}
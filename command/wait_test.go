@@ -0,0 +1,89 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+	"github.com/opsgenie/opsgenie-lamp/mockserver"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPollRequestStatus(t *testing.T) {
+	notProcessed := &client.ApiError{StatusCode: http.StatusNotFound, Message: "Request not processed"}
+	polls := 0
+	started := time.Now()
+	var logged bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &logged
+	logger.SetLevel(logrus.DebugLevel)
+	outcome, err := pollRequestStatus(context.Background(), logger, 0, "r1", func(ctx context.Context) (*RequestOutcome, error) {
+		if polls++; polls < 3 {
+			return nil, notProcessed
+		}
+		return &RequestOutcome{RequestID: "r1", Success: true}, nil
+	})
+	if err != nil || outcome.RequestID != "r1" {
+		t.Fatalf("pollRequestStatus returned %+v, %v", outcome, err)
+	}
+	// the second wait is twice as long as the first one
+	if elapsed := time.Since(started); polls != 3 || elapsed < 3*minPollInterval {
+		t.Errorf("polled %d times in %s, want 3 polls after waiting %s", polls, elapsed, 3*minPollInterval)
+	}
+	if n := strings.Count(logged.String(), "Request r1 is not processed yet"); n != 2 {
+		t.Errorf("logged %q, want the two polls of the unprocessed request", logged.String())
+	}
+
+	// errors other than the request not being processed end the polling
+	failure := errors.New("connection refused")
+	if _, err := pollRequestStatus(context.Background(), quietLogger, 0, "r2", func(ctx context.Context) (*RequestOutcome, error) {
+		return nil, failure
+	}); err != failure {
+		t.Errorf("pollRequestStatus returned %v, want %v", err, failure)
+	}
+}
+
+func TestPollRequestStatusTimeout(t *testing.T) {
+	timeout := grabWaitTimeout(newSettingsContext(t, map[string]string{"wait-timeout": "200ms"}))
+	_, err := pollRequestStatus(context.Background(), quietLogger, timeout, "r1", func(ctx context.Context) (*RequestOutcome, error) {
+		return nil, &client.ApiError{StatusCode: http.StatusNotFound}
+	})
+	if exitCode(err) != ExitCodeTimeout || err.Error() != "Request r1 is not processed in 200ms" {
+		t.Errorf("pollRequestStatus returned %v, want a timeout", err)
+	}
+}
+
+func TestAlertStatusFetcher(t *testing.T) {
+	server := httptest.NewServer(mockserver.New(mockserver.Options{ProcessingDelay: 300 * time.Millisecond}))
+	defer server.Close()
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	api, err := NewAPI(Config{APIKey: "key", APIURL: server.URL, Logger: logger, RetryCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := api.CreateAlert(context.Background(), CreateAlertOptions{Message: "Disk is full", Alias: "disk-full"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetchStatus := alertStatusFetcher(api, created.RequestId)
+	if _, err := fetchStatus(context.Background()); !isRequestNotProcessed(err) {
+		t.Fatalf("status of the new request is %v, want it not processed", err)
+	}
+
+	outcome, err := pollRequestStatus(context.Background(), quietLogger, 0, created.RequestId, fetchStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !outcome.Success || outcome.Alias != "disk-full" || outcome.AlertID == "" || outcome.TinyID == "" || outcome.ProcessedAt == "" {
+		t.Errorf("outcome is %+v, want the processed alert with its ids", outcome)
+	}
+}
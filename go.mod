@@ -5,9 +5,11 @@ go 1.12
 require (
 	github.com/ccding/go-config-reader v0.0.0-20130817225950-8b6c2b50197f
 	github.com/hashicorp/go-cleanhttp v0.5.1 // indirect
-	github.com/hashicorp/go-retryablehttp v0.5.4 // indirect
+	github.com/hashicorp/go-retryablehttp v0.5.4
 	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
 	github.com/opsgenie/opsgenie-go-sdk-v2 v1.2.8
+	github.com/sirupsen/logrus v1.4.2
 	github.com/urfave/cli v1.21.0
+	golang.org/x/term v0.0.0-20201126162022-7de9c90e9dd1
 	gopkg.in/yaml.v2 v2.2.2
 )
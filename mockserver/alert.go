@@ -0,0 +1,460 @@
+package mockserver
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const alerts = "alerts"
+
+func (s *Server) registerAlertRoutes() {
+	s.handle(http.MethodPost, "/v2/alerts", s.createAlert)
+	s.handle(http.MethodGet, "/v2/alerts", s.listAlerts)
+	s.handle(http.MethodGet, "/v2/alerts/count", s.countAlerts)
+	s.handle(http.MethodGet, "/v2/alerts/requests/{requestId}", s.handleRequestStatus)
+	s.handle(http.MethodGet, "/v2/alerts/{identifier}", s.getAlert)
+	s.handle(http.MethodDelete, "/v2/alerts/{identifier}", s.deleteAlert)
+
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/acknowledge", s.alertAction("Acknowledge", func(alert record, body record, r *http.Request) error {
+		alert["acknowledged"] = true
+		report := stringMap(alert["report"])
+		report["acknowledgedBy"] = str(body, "user")
+		alert["report"] = report
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/unacknowledge", s.alertAction("UnAcknowledge", func(alert record, body record, r *http.Request) error {
+		alert["acknowledged"] = false
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/close", s.alertAction("Close", func(alert record, body record, r *http.Request) error {
+		alert["status"] = "closed"
+		report := stringMap(alert["report"])
+		report["closedBy"] = str(body, "user")
+		alert["report"] = report
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/snooze", s.alertAction("Snooze", func(alert record, body record, r *http.Request) error {
+		if str(body, "endTime") == "" {
+			return errors.New("endTime should be provided")
+		}
+		alert["snoozed"] = true
+		alert["snoozedUntil"] = body["endTime"]
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/escalate", s.alertAction("EscalateToNext", func(alert record, body record, r *http.Request) error {
+		return addResponder(alert, body["escalation"], "escalation")
+	}))
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/assign", s.alertAction("AssignOwnership", func(alert record, body record, r *http.Request) error {
+		owner := stringMap(body["owner"])
+		if str(owner, "username") == "" && str(owner, "id") == "" {
+			return errors.New("owner should be provided")
+		}
+		alert["owner"] = str(owner, "username")
+		if alert["owner"] == "" {
+			alert["owner"] = str(owner, "id")
+		}
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/teams", s.alertAction("AddTeam", func(alert record, body record, r *http.Request) error {
+		return addResponder(alert, body["team"], "team")
+	}))
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/responders", s.alertAction("AddResponder", func(alert record, body record, r *http.Request) error {
+		responder := stringMap(body["responder"])
+		return addResponder(alert, responder, str(responder, "type"))
+	}))
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/actions/{action}", s.alertAction("ExecuteCustomAction", func(alert record, body record, r *http.Request) error {
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/tags", s.alertAction("AddTags", func(alert record, body record, r *http.Request) error {
+		alert["tags"] = union(stringList(alert["tags"]), stringList(body["tags"]))
+		return nil
+	}))
+	s.handle(http.MethodDelete, "/v2/alerts/{identifier}/tags", s.alertAction("RemoveTags", func(alert record, body record, r *http.Request) error {
+		alert["tags"] = difference(stringList(alert["tags"]), strings.Split(r.URL.Query().Get("tags"), ","))
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/details", s.alertAction("AddDetails", func(alert record, body record, r *http.Request) error {
+		details := stringMap(alert["details"])
+		for key, value := range stringMap(body["details"]) {
+			details[key] = value
+		}
+		alert["details"] = details
+		return nil
+	}))
+	s.handle(http.MethodDelete, "/v2/alerts/{identifier}/details", s.alertAction("RemoveDetails", func(alert record, body record, r *http.Request) error {
+		details := stringMap(alert["details"])
+		for _, key := range strings.Split(r.URL.Query().Get("keys"), ",") {
+			delete(details, key)
+		}
+		alert["details"] = details
+		return nil
+	}))
+	s.handle(http.MethodPut, "/v2/alerts/{identifier}/message", s.alertAction("UpdateMessage", func(alert record, body record, r *http.Request) error {
+		alert["message"] = str(body, "message")
+		return nil
+	}))
+	s.handle(http.MethodPut, "/v2/alerts/{identifier}/priority", s.alertAction("UpdatePriority", func(alert record, body record, r *http.Request) error {
+		alert["priority"] = str(body, "priority")
+		return nil
+	}))
+	s.handle(http.MethodPut, "/v2/alerts/{identifier}/description", s.alertAction("UpdateDescription", func(alert record, body record, r *http.Request) error {
+		alert["description"] = str(body, "description")
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/notes", s.alertAction("AddNote", func(alert record, body record, r *http.Request) error {
+		if str(body, "note") == "" {
+			return errors.New("note should be provided")
+		}
+		return nil
+	}))
+	s.handle(http.MethodGet, "/v2/alerts/{identifier}/notes", s.listEntries(alerts, "Alert", "notes"))
+	s.handle(http.MethodGet, "/v2/alerts/{identifier}/logs", s.listEntries(alerts, "Alert", "logs"))
+	s.handle(http.MethodGet, "/v2/alerts/{identifier}/recipients", s.listAlertRecipients)
+
+	s.handle(http.MethodPost, "/v2/alerts/{identifier}/attachments", s.createAlertAttachment)
+	s.handle(http.MethodGet, "/v2/alerts/{identifier}/attachments", s.listAlertAttachments)
+	s.handle(http.MethodGet, "/v2/alerts/{identifier}/attachments/{attachmentId}", s.getAlertAttachment)
+	s.handle(http.MethodDelete, "/v2/alerts/{identifier}/attachments/{attachmentId}", s.deleteAlertAttachment)
+	s.handle(http.MethodGet, "/mock/attachments/{attachmentId}", s.downloadAlertAttachment)
+}
+
+// createAlert creates a new alert, or increases the count of the open alert with the same alias.
+func (s *Server) createAlert(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	body := readBody(r)
+	if str(body, "message") == "" {
+		s.writeError(w, http.StatusUnprocessableEntity, "Message can not be empty.")
+		return
+	}
+	s.accept(w, "alertId", "Create", func() (string, string, error) {
+		if alias := str(body, "alias"); alias != "" {
+			if alert := s.find(alerts, alias); alert != nil && str(alert, "status") == "open" {
+				alert["count"] = alert["count"].(int) + 1
+				alert["lastOccurredAt"] = now()
+				s.addLog(alerts, alert, "Alert is deduplicated", "system", str(body, "user"))
+				return str(alert, "id"), alias, nil
+			}
+		}
+		s.tinyIDSequence++
+		alert := merge(record{
+			"tinyId":       strconv.Itoa(s.tinyIDSequence),
+			"status":       "open",
+			"acknowledged": false,
+			"isSeen":       false,
+			"snoozed":      false,
+			"count":        1,
+			"priority":     "P3",
+			"source":       r.RemoteAddr,
+			"owner":        "",
+			"tags":         []string{},
+			"details":      map[string]interface{}{},
+			"integration":  record{"id": "mock-integration", "name": "Default API", "type": "API"},
+			"report":       record{},
+		}, body)
+		delete(alert, "note")
+		delete(alert, "user")
+		alert = s.insert(alerts, alert)
+		if str(alert, "alias") == "" {
+			alert["alias"] = alert["id"]
+		}
+		alert["lastOccurredAt"] = alert["createdAt"]
+		s.addLog(alerts, alert, "Alert is created", "system", str(body, "user"))
+		if note := str(body, "note"); note != "" {
+			s.addNote(alerts, alert, note, str(body, "user"))
+		}
+		return str(alert, "id"), str(alert, "alias"), nil
+	})
+}
+
+// alertAction builds the handler of an asynchronous alert action, the change is applied when the request is processed.
+func (s *Server) alertAction(action string, apply func(alert record, body record, r *http.Request) error) handler {
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		body := readBody(r)
+		identifier := params["identifier"]
+		action := action
+		if customAction, ok := params["action"]; ok {
+			action = "Custom action " + customAction
+		}
+		s.accept(w, "alertId", action, func() (string, string, error) {
+			alert := s.find(alerts, identifier)
+			if alert == nil {
+				return "", "", errors.New("Alert does not exist")
+			}
+			if err := apply(alert, body, r); err != nil {
+				return str(alert, "id"), str(alert, "alias"), err
+			}
+			alert["updatedAt"] = now()
+			s.addLog(alerts, alert, action+" is executed", "system", str(body, "user"))
+			if note := str(body, "note"); note != "" {
+				s.addNote(alerts, alert, note, str(body, "user"))
+			}
+			return str(alert, "id"), str(alert, "alias"), nil
+		})
+	}
+}
+
+func (s *Server) deleteAlert(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	identifier := params["identifier"]
+	s.accept(w, "alertId", "Delete", func() (string, string, error) {
+		alert := s.find(alerts, identifier)
+		if alert == nil {
+			return "", "", errors.New("Alert does not exist")
+		}
+		s.remove(alerts, str(alert, "id"))
+		return str(alert, "id"), str(alert, "alias"), nil
+	})
+}
+
+func (s *Server) getAlert(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	alert := s.find(alerts, params["identifier"])
+	if alert == nil {
+		s.writeNotFound(w, "Alert", params["identifier"])
+		return
+	}
+	s.writeData(w, http.StatusOK, alert)
+}
+
+func (s *Server) matchingAlerts(r *http.Request) []record {
+	var matching []record
+	for _, alert := range s.records[alerts] {
+		if matchesQuery(alert, r.URL.Query().Get("query")) {
+			matching = append(matching, alert)
+		}
+	}
+	return matching
+}
+
+func (s *Server) listAlerts(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	sortField := r.URL.Query().Get("sort")
+	if sortField == "" {
+		sortField = "createdAt"
+	}
+	order := r.URL.Query().Get("order")
+	if order == "" {
+		order = "desc"
+	}
+	page, _ := paginate(r, sortRecords(s.matchingAlerts(r), sortField, order), 20)
+	s.writeData(w, http.StatusOK, nonNil(page))
+}
+
+func (s *Server) countAlerts(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	s.writeData(w, http.StatusOK, record{"count": len(s.matchingAlerts(r))})
+}
+
+func (s *Server) addLog(kind string, entity record, log string, logType string, owner string) {
+	if owner == "" {
+		owner = "System"
+	}
+	s.addChild(childKey(kind, entity, "logs"), record{"log": log, "type": logType, "owner": owner, "offset": s.nextID()})
+}
+
+func (s *Server) addNote(kind string, entity record, note string, owner string) {
+	if owner == "" {
+		owner = "System"
+	}
+	s.addChild(childKey(kind, entity, "notes"), record{"note": note, "owner": owner, "offset": s.nextID()})
+}
+
+// listEntries lists the notes or logs of an alert or incident, paging with the offset of the last item like Opsgenie.
+func (s *Server) listEntries(kind string, label string, name string) handler {
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		entity := s.find(kind, params["identifier"])
+		if entity == nil {
+			s.writeNotFound(w, label, params["identifier"])
+			return
+		}
+		items := s.children[childKey(kind, entity, name)]
+		if strings.EqualFold(r.URL.Query().Get("order"), "desc") {
+			items = reversed(items)
+		}
+		direction := r.URL.Query().Get("direction")
+		if direction == "" {
+			direction = "next"
+		}
+		limit := queryInt(r, "limit", 100)
+		start, end := 0, len(items)
+		if offset := r.URL.Query().Get("offset"); offset != "" {
+			for i, item := range items {
+				if str(item, "offset") == offset {
+					if direction == "prev" {
+						end = i
+					} else {
+						start = i + 1
+					}
+				}
+			}
+		}
+		more := false
+		if limit > 0 && end-start > limit {
+			more = true
+			if direction == "prev" {
+				start = end - limit
+			} else {
+				end = start + limit
+			}
+		}
+		page := items[start:end]
+		paging := map[string]string{}
+		if more {
+			query := r.URL.Query()
+			query.Set("offset", str(page[len(page)-1], "offset"))
+			if direction == "prev" {
+				query.Set("offset", str(page[0], "offset"))
+			}
+			paging[direction] = r.URL.Path + "?" + query.Encode()
+		}
+		s.writeJSON(w, http.StatusOK, record{"data": nonNil(page), "paging": paging})
+	}
+}
+
+// listAlertRecipients lists the users among the responders of the alert as its recipients.
+func (s *Server) listAlertRecipients(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	alert := s.find(alerts, params["identifier"])
+	if alert == nil {
+		s.writeNotFound(w, "Alert", params["identifier"])
+		return
+	}
+	recipients := []record{}
+	for _, responder := range responders(alert) {
+		if str(responder, "type") == "user" {
+			recipients = append(recipients, record{
+				"user":      record{"id": str(responder, "id"), "username": str(responder, "username")},
+				"state":     "notactive",
+				"method":    "",
+				"createdAt": alert["createdAt"],
+				"updatedAt": alert["updatedAt"],
+			})
+		}
+	}
+	s.writeData(w, http.StatusOK, recipients)
+}
+
+func (s *Server) createAlertAttachment(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	alert := s.find(alerts, params["identifier"])
+	if alert == nil {
+		s.writeNotFound(w, "Alert", params["identifier"])
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "File should be provided. "+err.Error())
+		return
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.sequence++
+	id := s.sequence
+	s.attachments[strconv.FormatInt(id, 10)] = content
+	s.addChild(childKey(alerts, alert, "attachments"), record{"id": id, "name": header.Filename})
+	s.writeJSON(w, http.StatusOK, record{"result": "Attachment created", "data": record{"id": strconv.FormatInt(id, 10)}})
+}
+
+func (s *Server) listAlertAttachments(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	alert := s.find(alerts, params["identifier"])
+	if alert == nil {
+		s.writeNotFound(w, "Alert", params["identifier"])
+		return
+	}
+	s.writeData(w, http.StatusOK, nonNil(s.children[childKey(alerts, alert, "attachments")]))
+}
+
+func (s *Server) getAlertAttachment(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	alert := s.find(alerts, params["identifier"])
+	if alert == nil {
+		s.writeNotFound(w, "Alert", params["identifier"])
+		return
+	}
+	attachment := s.findChild(childKey(alerts, alert, "attachments"), params["attachmentId"])
+	if attachment == nil {
+		s.writeNotFound(w, "Attachment", params["attachmentId"])
+		return
+	}
+	s.writeData(w, http.StatusOK, record{
+		"name": attachment["name"],
+		"url":  s.baseURL + "/mock/attachments/" + params["attachmentId"],
+	})
+}
+
+func (s *Server) deleteAlertAttachment(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	alert := s.find(alerts, params["identifier"])
+	if alert == nil || !s.removeChild(childKey(alerts, alert, "attachments"), params["attachmentId"]) {
+		s.writeNotFound(w, "Attachment", params["attachmentId"])
+		return
+	}
+	delete(s.attachments, params["attachmentId"])
+	s.writeResult(w, "Deleted")
+}
+
+func (s *Server) downloadAlertAttachment(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	content, ok := s.attachments[params["attachmentId"]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(content)
+}
+
+func responders(entity record) []record {
+	var list []record
+	if values, ok := entity["responders"].([]interface{}); ok {
+		for _, value := range values {
+			if responder, ok := value.(map[string]interface{}); ok {
+				list = append(list, responder)
+			}
+		}
+	}
+	return list
+}
+
+// addResponder adds the team, user, escalation or schedule to the responders of the alert or incident.
+func addResponder(entity record, value interface{}, responderType string) error {
+	responder := stringMap(value)
+	if str(responder, "id") == "" && str(responder, "name") == "" && str(responder, "username") == "" {
+		return errors.New(responderType + " should be provided")
+	}
+	responder["type"] = responderType
+	list, _ := entity["responders"].([]interface{})
+	entity["responders"] = append(list, responder)
+	return nil
+}
+
+func union(list []string, values []string) []string {
+	return append(difference(list, values), values...)
+}
+
+func difference(list []string, values []string) []string {
+	result := []string{}
+	for _, item := range list {
+		found := false
+		for _, value := range values {
+			found = found || item == value
+		}
+		if !found {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func reversed(records []record) []record {
+	result := make([]record, len(records))
+	for i, rec := range records {
+		result[len(records)-1-i] = rec
+	}
+	return result
+}
+
+// nonNil makes empty lists encode as [] instead of null.
+func nonNil(records []record) []record {
+	if records == nil {
+		return []record{}
+	}
+	return records
+}
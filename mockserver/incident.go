@@ -0,0 +1,158 @@
+package mockserver
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const incidents = "incidents"
+
+func (s *Server) registerIncidentRoutes() {
+	s.handle(http.MethodPost, "/v1/incidents/create", s.createIncident)
+	s.handle(http.MethodGet, "/v1/incidents", s.listIncidents)
+	s.handle(http.MethodGet, "/v1/incidents/requests/{requestId}", s.handleRequestStatus)
+	s.handle(http.MethodGet, "/v1/incidents/{identifier}", s.getIncident)
+	s.handle(http.MethodDelete, "/v1/incidents/{identifier}", s.incidentAction("Delete", func(incident record, body record, r *http.Request) error {
+		s.remove(incidents, str(incident, "id"))
+		return nil
+	}))
+
+	s.handle(http.MethodPost, "/v1/incidents/{identifier}/close", s.incidentAction("Close", func(incident record, body record, r *http.Request) error {
+		incident["status"] = "closed"
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v1/incidents/{identifier}/responders", s.incidentAction("AddResponder", func(incident record, body record, r *http.Request) error {
+		for _, responder := range responders(body) {
+			if err := addResponder(incident, responder, str(responder, "type")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v1/incidents/{identifier}/tags", s.incidentAction("AddTags", func(incident record, body record, r *http.Request) error {
+		incident["tags"] = union(stringList(incident["tags"]), stringList(body["tags"]))
+		return nil
+	}))
+	s.handle(http.MethodDelete, "/v1/incidents/{identifier}/tags", s.incidentAction("RemoveTags", func(incident record, body record, r *http.Request) error {
+		incident["tags"] = difference(stringList(incident["tags"]), strings.Split(r.URL.Query().Get("tags"), ","))
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v1/incidents/{identifier}/details", s.incidentAction("AddDetails", func(incident record, body record, r *http.Request) error {
+		details := stringMap(incident["extraProperties"])
+		for key, value := range stringMap(body["details"]) {
+			details[key] = value
+		}
+		incident["extraProperties"] = details
+		return nil
+	}))
+	s.handle(http.MethodDelete, "/v1/incidents/{identifier}/details", s.incidentAction("RemoveDetails", func(incident record, body record, r *http.Request) error {
+		details := stringMap(incident["extraProperties"])
+		for _, key := range strings.Split(r.URL.Query().Get("keys"), ",") {
+			delete(details, key)
+		}
+		incident["extraProperties"] = details
+		return nil
+	}))
+	s.handle(http.MethodPut, "/v1/incidents/{identifier}/priority", s.incidentAction("UpdatePriority", func(incident record, body record, r *http.Request) error {
+		incident["priority"] = str(body, "priority")
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v1/incidents/{identifier}/message", s.incidentAction("UpdateMessage", func(incident record, body record, r *http.Request) error {
+		incident["message"] = str(body, "message")
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v1/incidents/{identifier}/description", s.incidentAction("UpdateDescription", func(incident record, body record, r *http.Request) error {
+		incident["description"] = str(body, "description")
+		return nil
+	}))
+	s.handle(http.MethodPost, "/v1/incidents/{identifier}/notes", s.incidentAction("AddNote", func(incident record, body record, r *http.Request) error {
+		if str(body, "note") == "" {
+			return errors.New("note should be provided")
+		}
+		return nil
+	}))
+	s.handle(http.MethodGet, "/v1/incidents/{identifier}/notes", s.listEntries(incidents, "Incident", "notes"))
+	s.handle(http.MethodGet, "/v1/incidents/{identifier}/logs", s.listEntries(incidents, "Incident", "logs"))
+}
+
+func (s *Server) createIncident(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	body := readBody(r)
+	if str(body, "message") == "" {
+		s.writeError(w, http.StatusUnprocessableEntity, "Message can not be empty.")
+		return
+	}
+	s.accept(w, "incidentId", "Create", func() (string, string, error) {
+		s.tinyIDSequence++
+		incident := merge(record{
+			"tinyId":          strconv.Itoa(s.tinyIDSequence),
+			"status":          "open",
+			"priority":        "P3",
+			"tags":            []string{},
+			"ownerTeam":       "",
+			"extraProperties": stringMap(body["details"]),
+		}, body)
+		delete(incident, "details")
+		delete(incident, "note")
+		delete(incident, "statusPageEntry")
+		delete(incident, "notifyStakeholders")
+		incident = s.insert(incidents, incident)
+		s.addLog(incidents, incident, "Incident is created", "system", "")
+		if note := str(body, "note"); note != "" {
+			s.addNote(incidents, incident, note, "")
+		}
+		return str(incident, "id"), "", nil
+	})
+}
+
+// incidentAction builds the handler of an asynchronous incident action, the change is applied when the request is processed.
+func (s *Server) incidentAction(action string, apply func(incident record, body record, r *http.Request) error) handler {
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		body := readBody(r)
+		identifier := params["identifier"]
+		s.accept(w, "incidentId", action, func() (string, string, error) {
+			incident := s.find(incidents, identifier)
+			if incident == nil {
+				return "", "", errors.New("Incident does not exist")
+			}
+			if err := apply(incident, body, r); err != nil {
+				return str(incident, "id"), "", err
+			}
+			incident["updatedAt"] = now()
+			s.addLog(incidents, incident, action+" is executed", "system", str(body, "user"))
+			if note := str(body, "note"); note != "" {
+				s.addNote(incidents, incident, note, str(body, "user"))
+			}
+			return str(incident, "id"), "", nil
+		})
+	}
+}
+
+func (s *Server) getIncident(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	incident := s.find(incidents, params["identifier"])
+	if incident == nil {
+		s.writeNotFound(w, "Incident", params["identifier"])
+		return
+	}
+	s.writeData(w, http.StatusOK, incident)
+}
+
+func (s *Server) listIncidents(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	var matching []record
+	for _, incident := range s.records[incidents] {
+		if matchesQuery(incident, r.URL.Query().Get("query")) {
+			matching = append(matching, incident)
+		}
+	}
+	sortField := r.URL.Query().Get("sort")
+	if sortField == "" {
+		sortField = "createdAt"
+	}
+	order := r.URL.Query().Get("order")
+	if order == "" {
+		order = "desc"
+	}
+	page, more := paginate(r, sortRecords(matching, sortField, order), 20)
+	s.writeJSON(w, http.StatusOK, record{"data": nonNil(page), "paging": pagingLinks(r, len(page), more)})
+}
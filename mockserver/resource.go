@@ -0,0 +1,185 @@
+package mockserver
+
+import (
+	"net/http"
+	"strings"
+)
+
+// resource describes an entity served with the usual create, list, get, update and delete endpoints.
+type resource struct {
+	kind          string
+	label         string
+	updateMethods []string
+	// defaults returns the fields of a new entity that are not given in the create request.
+	defaults func() record
+	// listKey wraps the list in an object with the key, e.g. heartbeats, when it is not empty.
+	listKey string
+	// get replaces the default get handler when it is not nil.
+	get handler
+	// changed is called with the entity and a description of the change after each change, when it is not nil.
+	changed func(entity record, change string)
+}
+
+func (res resource) notifyChange(entity record, change string) {
+	if res.changed != nil {
+		res.changed(entity, change)
+	}
+}
+
+func (res resource) newRecord(body record) record {
+	rec := record{}
+	if res.defaults != nil {
+		rec = res.defaults()
+	}
+	return merge(rec, body)
+}
+
+// registerResource registers the create and list endpoints on the path, and get, update and delete under path/{identifier}.
+func (s *Server) registerResource(path string, res resource) {
+	s.handle(http.MethodPost, path, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		body := readBody(r)
+		if name := str(body, "name"); name != "" && s.find(res.kind, name) != nil {
+			s.writeError(w, http.StatusConflict, res.label+" with name ["+name+"] already exists")
+			return
+		}
+		entity := s.insert(res.kind, res.newRecord(body))
+		res.notifyChange(entity, res.label+" is created")
+		s.writeData(w, http.StatusCreated, entity)
+	})
+	s.handle(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		page, more := paginate(r, s.records[res.kind], 0)
+		if res.listKey != "" {
+			s.writeData(w, http.StatusOK, record{res.listKey: nonNil(page)})
+			return
+		}
+		s.writeJSON(w, http.StatusOK, record{"data": nonNil(page), "paging": pagingLinks(r, len(page), more)})
+	})
+
+	itemPath := path + "/{identifier}"
+	if res.get != nil {
+		s.handle(http.MethodGet, itemPath, res.get)
+	} else {
+		s.handle(http.MethodGet, itemPath, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			entity := s.find(res.kind, params["identifier"])
+			if entity == nil {
+				s.writeNotFound(w, res.label, params["identifier"])
+				return
+			}
+			s.writeData(w, http.StatusOK, entity)
+		})
+	}
+	for _, method := range res.updateMethods {
+		s.handle(method, itemPath, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			entity := s.find(res.kind, params["identifier"])
+			if entity == nil {
+				s.writeNotFound(w, res.label, params["identifier"])
+				return
+			}
+			merge(entity, readBody(r))
+			res.notifyChange(entity, res.label+" is updated")
+			s.writeData(w, http.StatusOK, entity)
+		})
+	}
+	s.handle(http.MethodDelete, itemPath, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		if !s.remove(res.kind, params["identifier"]) {
+			s.writeNotFound(w, res.label, params["identifier"])
+			return
+		}
+		s.writeResult(w, "Deleted")
+	})
+}
+
+/*
+registerChildResource registers the endpoints of the entities nested under a parent entity, such
+as the rotations of a schedule, on path and path/{childIdentifier}. The path should contain the
+{identifier} of the parent.
+*/
+func (s *Server) registerChildResource(path string, parent resource, child resource) {
+	findParent := func(w http.ResponseWriter, params map[string]string) record {
+		entity := s.find(parent.kind, params["identifier"])
+		if entity == nil {
+			s.writeNotFound(w, parent.label, params["identifier"])
+		}
+		return entity
+	}
+
+	s.handle(http.MethodPost, path, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		entity := findParent(w, params)
+		if entity == nil {
+			return
+		}
+		rec := child.newRecord(readBody(r))
+		if str(rec, "id") == "" {
+			rec["id"] = s.nextID()
+		}
+		rec["_parent"] = record{"id": entity["id"], "name": entity["name"], "enabled": entity["enabled"]}
+		s.addChild(childKey(parent.kind, entity, child.kind), rec)
+		parent.notifyChange(entity, child.label+" "+str(rec, "name")+" is created")
+		s.writeData(w, http.StatusCreated, rec)
+	})
+	s.handle(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		if entity := findParent(w, params); entity != nil {
+			s.writeData(w, http.StatusOK, nonNil(s.children[childKey(parent.kind, entity, child.kind)]))
+		}
+	})
+
+	itemPath := path + "/{childIdentifier}"
+	s.handle(http.MethodGet, itemPath, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		entity := findParent(w, params)
+		if entity == nil {
+			return
+		}
+		rec := s.findChild(childKey(parent.kind, entity, child.kind), params["childIdentifier"])
+		if rec == nil {
+			s.writeNotFound(w, child.label, params["childIdentifier"])
+			return
+		}
+		s.writeData(w, http.StatusOK, rec)
+	})
+	for _, method := range child.updateMethods {
+		s.handle(method, itemPath, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			entity := findParent(w, params)
+			if entity == nil {
+				return
+			}
+			rec := s.findChild(childKey(parent.kind, entity, child.kind), params["childIdentifier"])
+			if rec == nil {
+				s.writeNotFound(w, child.label, params["childIdentifier"])
+				return
+			}
+			merge(rec, readBody(r))
+			parent.notifyChange(entity, child.label+" "+str(rec, "name")+" is updated")
+			s.writeData(w, http.StatusOK, rec)
+		})
+	}
+	s.handle(http.MethodDelete, itemPath, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		entity := findParent(w, params)
+		if entity == nil {
+			return
+		}
+		if !s.removeChild(childKey(parent.kind, entity, child.kind), params["childIdentifier"]) {
+			s.writeNotFound(w, child.label, params["childIdentifier"])
+			return
+		}
+		parent.notifyChange(entity, child.label+" "+params["childIdentifier"]+" is deleted")
+		s.writeResult(w, "Deleted")
+	})
+}
+
+// registerToggle registers the enable and disable endpoints of a resource, e.g. /v2/integrations/{identifier}/enable.
+func (s *Server) registerToggle(path string, res resource, respond func(w http.ResponseWriter, entity record)) {
+	for _, action := range []string{"enable", "disable"} {
+		enabled := action == "enable"
+		s.handle(http.MethodPost, path+"/{identifier}/"+action, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			entity := s.find(res.kind, params["identifier"])
+			if entity == nil {
+				s.writeNotFound(w, res.label, params["identifier"])
+				return
+			}
+			entity["enabled"] = enabled
+			entity["updatedAt"] = now()
+			res.notifyChange(entity, res.label+" is "+strings.TrimSuffix(action, "e")+"ed")
+			respond(w, entity)
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package mockserver
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	heartbeats   = "heartbeats"
+	integrations = "integrations"
+	policies     = "policies"
+)
+
+// registerResourceRoutes registers the endpoints of the entities without asynchronous processing.
+func (s *Server) registerResourceRoutes() {
+	s.registerResource("/v2/escalations", resource{
+		kind:          "escalations",
+		label:         "Escalation",
+		updateMethods: []string{http.MethodPatch},
+		defaults: func() record {
+			return record{"description": "", "rules": []interface{}{}}
+		},
+	})
+	s.registerResource("/v1/services", resource{
+		kind:          "services",
+		label:         "Service",
+		updateMethods: []string{http.MethodPatch},
+		defaults: func() record {
+			return record{"description": "", "tags": []interface{}{}}
+		},
+	})
+	s.registerResource("/v2/users", resource{
+		kind:          "users",
+		label:         "User",
+		updateMethods: []string{http.MethodPatch},
+		defaults: func() record {
+			return record{"role": record{"id": "User", "name": "User"}, "blocked": false, "verified": true}
+		},
+	})
+	s.registerHeartbeatRoutes()
+	s.registerIntegrationRoutes()
+	s.registerPolicyRoutes()
+
+	s.handle(http.MethodGet, "/v2/account", s.getAccount)
+	s.handle(http.MethodGet, "/v2/logs/list", s.listLogFiles)
+	s.handle(http.MethodGet, "/v2/logs/list/{marker}", s.listLogFiles)
+	s.handle(http.MethodGet, "/v2/logs/download/{fileName}", s.getLogFileLink)
+	s.handle(http.MethodGet, "/mock/logs/{fileName}", s.downloadLogFile)
+}
+
+func (s *Server) registerHeartbeatRoutes() {
+	heartbeat := resource{
+		kind:          heartbeats,
+		label:         "Heartbeat",
+		updateMethods: []string{http.MethodPatch},
+		listKey:       "heartbeats",
+		defaults: func() record {
+			return record{"description": "", "enabled": true, "expired": false, "interval": 10, "intervalUnit": "minutes"}
+		},
+	}
+	s.handle(http.MethodGet, "/v2/heartbeats/{identifier}/ping", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		entity := s.find(heartbeats, params["identifier"])
+		if entity == nil {
+			s.writeNotFound(w, "Heartbeat", params["identifier"])
+			return
+		}
+		entity["lastPingTime"] = now()
+		entity["expired"] = false
+		s.writeJSON(w, http.StatusAccepted, record{"result": "PONG - Heartbeat received"})
+	})
+	s.registerResource("/v2/heartbeats", heartbeat)
+	s.registerToggle("/v2/heartbeats", heartbeat, func(w http.ResponseWriter, entity record) {
+		s.writeData(w, http.StatusOK, record{"name": entity["name"], "enabled": entity["enabled"], "expired": entity["expired"]})
+	})
+}
+
+func (s *Server) registerIntegrationRoutes() {
+	integration := resource{
+		kind:          integrations,
+		label:         "Integration",
+		updateMethods: []string{http.MethodPut},
+		defaults: func() record {
+			return record{"enabled": true, "apiKey": s.nextID()}
+		},
+	}
+	s.registerResource("/v2/integrations", integration)
+	s.registerToggle("/v2/integrations", integration, func(w http.ResponseWriter, entity record) {
+		s.writeData(w, http.StatusOK, entity)
+	})
+
+	s.handle(http.MethodGet, "/v2/integrations/{identifier}/actions", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		entity := s.find(integrations, params["identifier"])
+		if entity == nil {
+			s.writeNotFound(w, "Integration", params["identifier"])
+			return
+		}
+		actions := stringMap(entity["actions"])
+		s.writeData(w, http.StatusOK, actions)
+	})
+	for _, method := range []string{http.MethodPost, http.MethodPut} {
+		replace := method == http.MethodPut
+		s.handle(method, "/v2/integrations/{identifier}/actions", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			entity := s.find(integrations, params["identifier"])
+			if entity == nil {
+				s.writeNotFound(w, "Integration", params["identifier"])
+				return
+			}
+			actions := stringMap(entity["actions"])
+			if replace {
+				actions = map[string]interface{}{}
+			}
+			for actionType, value := range readBody(r) {
+				existing, _ := actions[actionType].([]interface{})
+				added, _ := value.([]interface{})
+				actions[actionType] = append(existing, added...)
+			}
+			entity["actions"] = actions
+			entity["updatedAt"] = now()
+			s.writeData(w, http.StatusOK, actions)
+		})
+	}
+}
+
+func (s *Server) registerPolicyRoutes() {
+	policy := resource{
+		kind:          policies,
+		label:         "Policy",
+		updateMethods: []string{http.MethodPut},
+		defaults: func() record {
+			return record{"enabled": true, "description": "", "policyDescription": ""}
+		},
+	}
+	for _, policyType := range []string{"alert", "notification"} {
+		policyType := policyType
+		s.handle(http.MethodGet, "/v2/policies/"+policyType, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			matching := []record{}
+			for _, entity := range s.records[policies] {
+				if str(entity, "type") == policyType {
+					matching = append(matching, entity)
+				}
+			}
+			s.writeData(w, http.StatusOK, matching)
+		})
+	}
+	s.registerResource("/v2/policies", policy)
+	s.registerToggle("/v2/policies", policy, func(w http.ResponseWriter, entity record) {
+		if entity["enabled"] == true {
+			s.writeResult(w, "Enabled")
+			return
+		}
+		s.writeResult(w, "Disabled")
+	})
+	s.handle(http.MethodPost, "/v2/policies/{identifier}/change-order", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		entity := s.find(policies, params["identifier"])
+		if entity == nil {
+			s.writeNotFound(w, "Policy", params["identifier"])
+			return
+		}
+		order := intValue(readBody(r)["targetIndex"])
+		if order < 0 {
+			order = 0
+		}
+		remaining, _ := removeRecord(s.records[policies], str(entity, "id"))
+		if order > len(remaining) {
+			order = len(remaining)
+		}
+		s.records[policies] = append(remaining[:order:order], append([]record{entity}, remaining[order:]...)...)
+		s.writeResult(w, "Changed")
+	})
+}
+
+// getAccount returns a fixed account, with the number of users in the store.
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	s.writeData(w, http.StatusOK, record{
+		"name":      "mock",
+		"userCount": len(s.records["users"]),
+		"plan":      record{"maxUserCount": 1000, "name": "Mock", "isYearly": false},
+	})
+}
+
+// listLogFiles lists the request log files after the marker, which is the name of a file with or without
+// the .json extension. The name of the last file listed is returned as the marker of the next page.
+func (s *Server) listLogFiles(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	var fileNames []string
+	for fileName := range s.logFiles {
+		if strings.TrimSuffix(fileName, ".json") > strings.TrimSuffix(params["marker"], ".json") {
+			fileNames = append(fileNames, fileName)
+		}
+	}
+	sort.Strings(fileNames)
+	if limit := queryInt(r, "limit", 0); limit > 0 && limit < len(fileNames) {
+		fileNames = fileNames[:limit]
+	}
+
+	files := []record{}
+	marker := ""
+	for _, fileName := range fileNames {
+		date, _ := time.Parse("2006-01-02-15-04-05.json", fileName)
+		files = append(files, record{"filename": fileName, "date": date.UnixNano() / int64(time.Millisecond), "size": len(s.logFiles[fileName])})
+		marker = fileName
+	}
+	s.writeJSON(w, http.StatusOK, record{"data": files, "marker": marker})
+}
+
+// getLogFileLink returns the download link of a log file as plain text, like the Opsgenie API.
+func (s *Server) getLogFileLink(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := s.logFiles[params["fileName"]]; !ok {
+		s.writeNotFound(w, "Log file", params["fileName"])
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(s.baseURL + "/mock/logs/" + params["fileName"]))
+}
+
+func (s *Server) downloadLogFile(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	content, ok := s.logFiles[params["fileName"]]
+	if !ok {
+		s.writeNotFound(w, "Log file", params["fileName"])
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Write(content)
+}
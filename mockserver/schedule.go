@@ -0,0 +1,307 @@
+package mockserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const schedules = "schedules"
+
+func (s *Server) registerScheduleRoutes() {
+	schedule := resource{
+		kind:          schedules,
+		label:         "Schedule",
+		updateMethods: []string{http.MethodPatch},
+		defaults: func() record {
+			return record{"description": "", "timezone": "UTC", "enabled": true}
+		},
+		get: s.getSchedule,
+	}
+	s.handle(http.MethodGet, "/v2/schedules/on-calls/{fileName}", s.exportUserOnCalls)
+	s.registerResource("/v2/schedules", schedule)
+	s.registerChildResource("/v2/schedules/{identifier}/rotations", schedule, resource{
+		kind:          "rotations",
+		label:         "Rotation",
+		updateMethods: []string{http.MethodPatch},
+		defaults: func() record {
+			return record{"type": "weekly", "length": 1, "startDate": now(), "participants": []interface{}{}}
+		},
+	})
+	s.handle(http.MethodPost, "/v2/schedules/{identifier}/overrides", s.createScheduleOverride)
+	s.registerChildResource("/v2/schedules/{identifier}/overrides", schedule, resource{
+		kind:          "overrides",
+		label:         "Override",
+		updateMethods: []string{http.MethodPut},
+	})
+	s.handle(http.MethodGet, "/v2/schedules/{identifier}/timeline", s.getScheduleTimeline)
+	s.handle(http.MethodGet, "/v2/schedules/{identifier}/on-calls", s.getOnCalls)
+	s.handle(http.MethodGet, "/v2/schedules/{identifier}/next-on-calls", s.getNextOnCalls)
+}
+
+// getSchedule serves the schedule, or exports it as an iCalendar file when the identifier ends with .ics.
+func (s *Server) getSchedule(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	identifier := params["identifier"]
+	entity := s.find(schedules, strings.TrimSuffix(identifier, ".ics"))
+	if entity == nil {
+		s.writeNotFound(w, "Schedule", identifier)
+		return
+	}
+	if strings.HasSuffix(identifier, ".ics") {
+		start := time.Now().UTC()
+		s.writeCalendar(w, s.schedulePeriods(entity, start, start.AddDate(0, 0, 14)))
+		return
+	}
+	s.writeData(w, http.StatusOK, entity)
+}
+
+// createScheduleOverride creates an override, its alias is generated when it is not given.
+func (s *Server) createScheduleOverride(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	entity := s.find(schedules, params["identifier"])
+	if entity == nil {
+		s.writeNotFound(w, "Schedule", params["identifier"])
+		return
+	}
+	override := readBody(r)
+	if str(override, "alias") == "" {
+		override["alias"] = s.nextID()
+	}
+	override["_parent"] = record{"id": entity["id"], "name": entity["name"], "enabled": entity["enabled"]}
+	s.addChild(childKey(schedules, entity, "overrides"), override)
+	s.writeData(w, http.StatusCreated, record{"alias": override["alias"]})
+}
+
+// period is the time a participant is on call in a rotation of a schedule.
+type period struct {
+	rotation  record
+	start     time.Time
+	end       time.Time
+	recipient record
+}
+
+func rotationLength(rotation record) time.Duration {
+	length := intValue(rotation["length"])
+	if length <= 0 {
+		length = 1
+	}
+	switch str(rotation, "type") {
+	case "hourly":
+		return time.Duration(length) * time.Hour
+	case "daily":
+		return time.Duration(length) * 24 * time.Hour
+	default:
+		return time.Duration(length) * 7 * 24 * time.Hour
+	}
+}
+
+func participant(value interface{}) record {
+	p := stringMap(value)
+	name := str(p, "username")
+	if name == "" {
+		name = str(p, "name")
+	}
+	if name == "" {
+		name = str(p, "id")
+	}
+	participantType := str(p, "type")
+	if participantType == "" {
+		participantType = "user"
+	}
+	return record{"id": str(p, "id"), "name": name, "username": str(p, "username"), "type": participantType}
+}
+
+// schedulePeriods returns the periods of the rotations between start and end, overrides replace the rotation participants.
+func (s *Server) schedulePeriods(entity record, start time.Time, end time.Time) []period {
+	var periods []period
+	for _, rotation := range s.children[childKey(schedules, entity, "rotations")] {
+		participants, _ := rotation["participants"].([]interface{})
+		if len(participants) == 0 {
+			continue
+		}
+		rotationStart, err := time.Parse(time.RFC3339, str(rotation, "startDate"))
+		if err != nil {
+			rotationStart = start
+		}
+		length := rotationLength(rotation)
+		index := 0
+		periodStart := rotationStart
+		if start.After(rotationStart) {
+			index = int(start.Sub(rotationStart) / length)
+			periodStart = rotationStart.Add(time.Duration(index) * length)
+		}
+		for ; periodStart.Before(end); periodStart = periodStart.Add(length) {
+			recipient := s.overrideRecipient(entity, periodStart)
+			if recipient == nil {
+				recipient = participant(participants[index%len(participants)])
+			}
+			periods = append(periods, period{rotation: rotation, start: periodStart, end: periodStart.Add(length), recipient: recipient})
+			index++
+		}
+	}
+	return periods
+}
+
+func (s *Server) overrideRecipient(entity record, at time.Time) record {
+	for _, override := range s.children[childKey(schedules, entity, "overrides")] {
+		start, startErr := time.Parse(time.RFC3339, str(override, "startDate"))
+		end, endErr := time.Parse(time.RFC3339, str(override, "endDate"))
+		if startErr == nil && endErr == nil && !at.Before(start) && at.Before(end) {
+			return participant(override["user"])
+		}
+	}
+	return nil
+}
+
+// onCallsAt returns the recipients on call at the given time, one per rotation.
+func (s *Server) onCallsAt(entity record, at time.Time) []record {
+	recipients := []record{}
+	for _, p := range s.schedulePeriods(entity, at, at.Add(time.Nanosecond)) {
+		recipients = append(recipients, p.recipient)
+	}
+	return recipients
+}
+
+func queryDate(r *http.Request) time.Time {
+	if date, err := time.Parse(time.RFC3339, r.URL.Query().Get("date")); err == nil {
+		return date
+	}
+	return time.Now().UTC()
+}
+
+func scheduleParent(entity record) record {
+	return record{"id": entity["id"], "name": entity["name"], "enabled": entity["enabled"]}
+}
+
+func recipientNames(recipients []record) []string {
+	names := []string{}
+	for _, recipient := range recipients {
+		names = append(names, str(recipient, "name"))
+	}
+	return names
+}
+
+func (s *Server) getOnCalls(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	entity := s.find(schedules, params["identifier"])
+	if entity == nil {
+		s.writeNotFound(w, "Schedule", params["identifier"])
+		return
+	}
+	recipients := s.onCallsAt(entity, queryDate(r))
+	data := record{"_parent": scheduleParent(entity), "onCallParticipants": recipients}
+	if r.URL.Query().Get("flat") == "true" {
+		data = record{"_parent": scheduleParent(entity), "onCallRecipients": recipientNames(recipients)}
+	}
+	s.writeData(w, http.StatusOK, data)
+}
+
+// getNextOnCalls returns the recipients of the periods following the current ones.
+func (s *Server) getNextOnCalls(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	entity := s.find(schedules, params["identifier"])
+	if entity == nil {
+		s.writeNotFound(w, "Schedule", params["identifier"])
+		return
+	}
+	date := queryDate(r)
+	recipients := []record{}
+	for _, current := range s.schedulePeriods(entity, date, date.Add(time.Nanosecond)) {
+		next := s.schedulePeriods(entity, current.end, current.end.Add(time.Nanosecond))
+		for _, p := range next {
+			if str(p.rotation, "id") == str(current.rotation, "id") {
+				recipients = append(recipients, p.recipient)
+			}
+		}
+	}
+	data := record{
+		"_parent":                   scheduleParent(entity),
+		"nextOnCallRecipients":      recipients,
+		"exactNextOnCallRecipients": recipients,
+	}
+	if r.URL.Query().Get("flat") == "true" {
+		data = record{
+			"_parent":                     scheduleParent(entity),
+			"nextOnCallParticipants":      recipientNames(recipients),
+			"exactNextOnCallParticipants": recipientNames(recipients),
+		}
+	}
+	s.writeData(w, http.StatusOK, data)
+}
+
+// getScheduleTimeline returns the periods of the rotations in the interval starting at date, one week by default.
+func (s *Server) getScheduleTimeline(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	entity := s.find(schedules, params["identifier"])
+	if entity == nil {
+		s.writeNotFound(w, "Schedule", params["identifier"])
+		return
+	}
+	start := queryDate(r)
+	interval := queryInt(r, "interval", 1)
+	var end time.Time
+	switch r.URL.Query().Get("intervalUnit") {
+	case "days":
+		end = start.AddDate(0, 0, interval)
+	case "months":
+		end = start.AddDate(0, interval, 0)
+	default:
+		end = start.AddDate(0, 0, 7*interval)
+	}
+
+	rotations := []record{}
+	byRotation := map[string]record{}
+	for _, p := range s.schedulePeriods(entity, start, end) {
+		rotationID := str(p.rotation, "id")
+		timelineRotation, ok := byRotation[rotationID]
+		if !ok {
+			timelineRotation = record{"id": rotationID, "name": p.rotation["name"], "order": len(rotations) + 1, "periods": []record{}}
+			byRotation[rotationID] = timelineRotation
+			rotations = append(rotations, timelineRotation)
+		}
+		timelineRotation["periods"] = append(timelineRotation["periods"].([]record), record{
+			"startDate": p.start.Format(time.RFC3339),
+			"endDate":   p.end.Format(time.RFC3339),
+			"type":      "default",
+			"recipient": p.recipient,
+		})
+	}
+	timeline := record{"rotations": rotations}
+	s.writeData(w, http.StatusOK, record{
+		"_parent":            scheduleParent(entity),
+		"description":        entity["description"],
+		"ownerTeam":          entity["ownerTeam"],
+		"startDate":          start.Format(time.RFC3339),
+		"endDate":            end.Format(time.RFC3339),
+		"finalTimeline":      timeline,
+		"baseTimeline":       timeline,
+		"overrideTimeline":   record{"rotations": []record{}},
+		"forwardingTimeline": record{"rotations": []record{}},
+	})
+}
+
+// exportUserOnCalls exports the on-call periods of a user in all schedules as an iCalendar file.
+func (s *Server) exportUserOnCalls(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	user := strings.TrimSuffix(params["fileName"], ".ics")
+	start := time.Now().UTC()
+	var periods []period
+	for _, entity := range s.records[schedules] {
+		for _, p := range s.schedulePeriods(entity, start, start.AddDate(0, 0, 14)) {
+			if str(p.recipient, "username") == user || str(p.recipient, "id") == user || str(p.recipient, "name") == user {
+				periods = append(periods, p)
+			}
+		}
+	}
+	s.writeCalendar(w, periods)
+}
+
+func (s *Server) writeCalendar(w http.ResponseWriter, periods []period) {
+	var calendar strings.Builder
+	calendar.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//lamp//mock-server//EN\r\n")
+	for i, p := range periods {
+		fmt.Fprintf(&calendar, "BEGIN:VEVENT\r\nUID:%s-%s\r\nDTSTART:%s\r\nDTEND:%s\r\nSUMMARY:%s on call (%s)\r\nEND:VEVENT\r\n",
+			str(p.rotation, "id"), strconv.Itoa(i), p.start.Format("20060102T150405Z"), p.end.Format("20060102T150405Z"),
+			str(p.recipient, "name"), str(p.rotation, "name"))
+	}
+	calendar.WriteString("END:VCALENDAR\r\n")
+	w.Header().Set("Content-Type", "text/calendar")
+	w.Write([]byte(calendar.String()))
+}
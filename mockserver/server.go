@@ -0,0 +1,273 @@
+/*
+Package mockserver implements an in-memory stand-in for the parts of the Opsgenie REST API used by
+lamp, so that commands can be tried and scripts can be tested without an Opsgenie account.
+
+The server keeps alerts, incidents, teams, schedules, escalations, heartbeats, services,
+integrations, policies and users in memory and answers with the same response shapes as
+Opsgenie. Alert and incident mutations are processed asynchronously like the real API: they are
+accepted with 202 and a request id, and their status can be queried from the request status
+endpoints once they are processed.
+*/
+package mockserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configures the behaviour of the mock server.
+type Options struct {
+	// APIKey is the only API key accepted by the server, any API key is accepted if it is empty.
+	APIKey string
+	// ProcessingDelay is how long asynchronous requests stay in the RequestNotProcessed state.
+	ProcessingDelay time.Duration
+	// RateLimit is how many requests of an API family, alert, configuration or logs, are accepted in RateLimitPeriod,
+	// the other requests are throttled with 429 like Opsgenie does. Requests are not limited if it is 0.
+	RateLimit       int
+	RateLimitPeriod time.Duration
+}
+
+// Server is an http.Handler serving the mock Opsgenie API.
+type Server struct {
+	options Options
+	routes  []route
+
+	mu             sync.Mutex
+	records        map[string][]record
+	children       map[string][]record
+	requests       map[string]*asyncRequest
+	pending        []*asyncRequest
+	logFiles       map[string][]byte
+	attachments    map[string][]byte
+	baseURL        string
+	sequence       int64
+	tinyIDSequence int
+	rateWindows    map[string]*rateWindow
+}
+
+// rateWindow counts the requests of an API family since the start of the current rate limit period.
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+type record map[string]interface{}
+
+type handler func(w http.ResponseWriter, r *http.Request, params map[string]string)
+
+type route struct {
+	method   string
+	segments []string
+	handle   handler
+}
+
+// New creates a mock server with an empty in-memory store.
+func New(options Options) *Server {
+	s := &Server{
+		options:     options,
+		records:     map[string][]record{},
+		children:    map[string][]record{},
+		requests:    map[string]*asyncRequest{},
+		logFiles:    map[string][]byte{},
+		attachments: map[string][]byte{},
+		rateWindows: map[string]*rateWindow{},
+	}
+	s.registerAlertRoutes()
+	s.registerIncidentRoutes()
+	s.registerTeamRoutes()
+	s.registerScheduleRoutes()
+	s.registerResourceRoutes()
+	return s
+}
+
+// ListenAndServe serves the mock API on the given address until the listener fails.
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve serves the mock API on the listener, the address of the listener is used in the download links.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	s.baseURL = "http://" + listener.Addr().String()
+	s.mu.Unlock()
+	return http.Serve(listener, s)
+}
+
+func (s *Server) handle(method string, pattern string, h handler) {
+	s.routes = append(s.routes, route{method: method, segments: strings.Split(strings.Trim(pattern, "/"), "/"), handle: h})
+}
+
+// ServeHTTP authenticates the request and dispatches it to the first route matching its method and path.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.baseURL == "" {
+		s.baseURL = "http://" + r.Host
+	}
+	s.processDueRequests()
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	isDownload := len(segments) > 0 && segments[0] == "mock"
+	if !isDownload && !s.authorized(r) {
+		s.writeError(w, http.StatusUnauthorized, "Could not authenticate")
+		return
+	}
+	if !isDownload && s.throttled(w, r) {
+		s.writeError(w, http.StatusTooManyRequests, "Request is throttled")
+		return
+	}
+
+	pathFound := false
+	for _, route := range s.routes {
+		params, ok := matchSegments(route.segments, segments)
+		if !ok {
+			continue
+		}
+		pathFound = true
+		if route.method == r.Method {
+			s.logRequest(r)
+			route.handle(w, r, params)
+			return
+		}
+	}
+	if pathFound {
+		s.writeError(w, http.StatusMethodNotAllowed, "Method "+r.Method+" is not allowed for "+r.URL.Path)
+		return
+	}
+	s.writeError(w, http.StatusNotFound, "Endpoint "+r.URL.Path+" is not supported by the mock server")
+}
+
+func matchSegments(pattern []string, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, segment := range pattern {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params[segment[1:len(segment)-1]] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// throttled counts the request in the rate limit period of its API family and sets the rate limit headers of Opsgenie.
+func (s *Server) throttled(w http.ResponseWriter, r *http.Request) bool {
+	if s.options.RateLimit <= 0 || s.options.RateLimitPeriod <= 0 {
+		return false
+	}
+	family := "configuration"
+	if strings.HasPrefix(r.URL.Path, "/v2/logs") {
+		family = "logs"
+	} else if strings.Contains(r.URL.Path, "/alerts") || strings.Contains(r.URL.Path, "/incidents") {
+		family = "alert"
+	}
+	window, ok := s.rateWindows[family]
+	now := time.Now()
+	if !ok || now.Sub(window.start) >= s.options.RateLimitPeriod {
+		window = &rateWindow{start: now}
+		s.rateWindows[family] = window
+	}
+	window.count++
+	period := int((s.options.RateLimitPeriod + time.Second - 1) / time.Second)
+	w.Header().Set("X-RateLimit-Period-In-Sec", strconv.Itoa(period))
+	if window.count > s.options.RateLimit {
+		w.Header().Set("X-RateLimit-State", "THROTTLED")
+		w.Header().Set("X-RateLimit-Reason", "ACCOUNT")
+		return true
+	}
+	w.Header().Set("X-RateLimit-State", "OK")
+	return false
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	apiKey := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "GenieKey"))
+	if apiKey == "" {
+		return false
+	}
+	return s.options.APIKey == "" || apiKey == s.options.APIKey
+}
+
+// logRequest keeps one log file per hour with the requests served, they are listed by the logs endpoints.
+func (s *Server) logRequest(r *http.Request) {
+	now := time.Now().UTC()
+	fileName := now.Truncate(time.Hour).Format("2006-01-02-15-04-05") + ".json"
+	line, _ := json.Marshal(map[string]interface{}{
+		"time":   now.Format(time.RFC3339),
+		"method": r.Method,
+		"path":   r.URL.Path,
+	})
+	s.logFiles[fileName] = append(append(s.logFiles[fileName], line...), '\n')
+}
+
+func (s *Server) nextID() string {
+	s.sequence++
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(s.sequence, 10)
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, body record) {
+	requestID, ok := body["requestId"].(string)
+	if !ok {
+		requestID = s.nextID()
+	}
+	body["took"] = 0.001
+	body["requestId"] = requestID
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Request-Id", requestID)
+	w.Header().Set("X-Response-Time", "0.001")
+	if w.Header().Get("X-RateLimit-State") == "" {
+		w.Header().Set("X-RateLimit-State", "OK")
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func (s *Server) writeData(w http.ResponseWriter, status int, data interface{}) {
+	s.writeJSON(w, status, record{"data": data})
+}
+
+func (s *Server) writeResult(w http.ResponseWriter, result string) {
+	s.writeJSON(w, http.StatusOK, record{"result": result})
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, message string) {
+	s.writeJSON(w, status, record{"message": message})
+}
+
+func (s *Server) writeNotFound(w http.ResponseWriter, kind string, identifier string) {
+	s.writeError(w, http.StatusNotFound, kind+" with identifier ["+identifier+"] does not exist")
+}
+
+// readBody decodes the JSON body of the request, a missing or invalid body is an empty record.
+func readBody(r *http.Request) record {
+	body := record{}
+	content, err := ioutil.ReadAll(r.Body)
+	if err == nil && len(content) != 0 {
+		json.Unmarshal(content, &body)
+	}
+	return body
+}
+
+// queryInt returns the integer query parameter with the given name or the default value.
+func queryInt(r *http.Request, name string, defaultValue int) int {
+	if val, err := strconv.Atoi(r.URL.Query().Get(name)); err == nil && val >= 0 {
+		return val
+	}
+	return defaultValue
+}
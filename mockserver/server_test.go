@@ -0,0 +1,247 @@
+package mockserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// call sends a request with the API key to the server and decodes the JSON response.
+func call(t *testing.T, s *Server, method string, path string, body interface{}) (int, record) {
+	t.Helper()
+	var content []byte
+	if body != nil {
+		content, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(content))
+	req.Header.Set("Authorization", "GenieKey key")
+	resp := httptest.NewRecorder()
+	s.ServeHTTP(resp, req)
+	decoded := record{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("%s %s answered %d with invalid JSON %q", method, path, resp.Code, resp.Body.String())
+	}
+	return resp.Code, decoded
+}
+
+func data(response record) record {
+	return stringMap(response["data"])
+}
+
+func TestAsyncRoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		createPath  string
+		createBody  record
+		requestPath string
+		entityField string
+		path        string
+	}{
+		{
+			name:        "alert",
+			createPath:  "/v2/alerts",
+			createBody:  record{"message": "Disk is full", "alias": "disk-full", "tags": []string{"disk"}},
+			requestPath: "/v2/alerts/requests/",
+			entityField: "alertId",
+			path:        "/v2/alerts",
+		},
+		{
+			name:        "incident",
+			createPath:  "/v1/incidents/create",
+			createBody:  record{"message": "Checkout is down", "priority": "P1"},
+			requestPath: "/v1/incidents/requests/",
+			entityField: "incidentId",
+			path:        "/v1/incidents",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := New(Options{})
+
+			status, created := call(t, s, http.MethodPost, test.createPath, test.createBody)
+			if status != http.StatusAccepted || str(created, "requestId") == "" {
+				t.Fatalf("create answered %d %v, want 202 with a request id", status, created)
+			}
+			status, processed := call(t, s, http.MethodGet, test.requestPath+str(created, "requestId"), nil)
+			id := str(data(processed), test.entityField)
+			if status != http.StatusOK || id == "" || data(processed)["success"] != true {
+				t.Fatalf("request status answered %d %v, want a processed request with the %s", status, processed, test.entityField)
+			}
+
+			status, got := call(t, s, http.MethodGet, test.path+"/"+id, nil)
+			if status != http.StatusOK || str(data(got), "message") != str(test.createBody, "message") || str(data(got), "status") != "open" {
+				t.Fatalf("get answered %d %v, want the open %s", status, got, test.name)
+			}
+
+			status, listed := call(t, s, http.MethodGet, test.path, nil)
+			items, _ := listed["data"].([]interface{})
+			if status != http.StatusOK || len(items) != 1 || str(stringMap(items[0]), "id") != id {
+				t.Fatalf("list answered %d %v, want the created %s", status, listed, test.name)
+			}
+
+			status, closed := call(t, s, http.MethodPost, test.path+"/"+id+"/close", record{"note": "Fixed"})
+			if status != http.StatusAccepted {
+				t.Fatalf("close answered %d %v, want 202", status, closed)
+			}
+			_, processed = call(t, s, http.MethodGet, test.requestPath+str(closed, "requestId"), nil)
+			if str(data(processed), "action") != "Close" || data(processed)["success"] != true {
+				t.Fatalf("close request status is %v, want a successful Close", processed)
+			}
+			_, got = call(t, s, http.MethodGet, test.path+"/"+id, nil)
+			if str(data(got), "status") != "closed" {
+				t.Fatalf("get after close answered %v, want a closed %s", got, test.name)
+			}
+		})
+	}
+}
+
+func TestResourceRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body record
+	}{
+		{name: "team", path: "/v2/teams", body: record{"name": "sre", "description": "Site reliability"}},
+		{name: "schedule", path: "/v2/schedules", body: record{"name": "primary", "timezone": "Europe/Istanbul"}},
+		{name: "service", path: "/v1/services", body: record{"name": "checkout", "teamId": "team-1"}},
+		{name: "escalation", path: "/v2/escalations", body: record{"name": "sre-escalation"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := New(Options{})
+
+			status, created := call(t, s, http.MethodPost, test.path, test.body)
+			id := str(data(created), "id")
+			if status != http.StatusCreated || id == "" {
+				t.Fatalf("create answered %d %v, want 201 with an id", status, created)
+			}
+			if status, _ := call(t, s, http.MethodPost, test.path, test.body); status != http.StatusConflict {
+				t.Errorf("create with a taken name answered %d, want 409", status)
+			}
+
+			for _, identifier := range []string{id, str(test.body, "name")} {
+				status, got := call(t, s, http.MethodGet, test.path+"/"+identifier, nil)
+				if status != http.StatusOK || str(data(got), "id") != id {
+					t.Errorf("get %s answered %d %v, want the created %s", identifier, status, got, test.name)
+				}
+			}
+
+			status, listed := call(t, s, http.MethodGet, test.path, nil)
+			items, _ := listed["data"].([]interface{})
+			if status != http.StatusOK || len(items) != 1 {
+				t.Fatalf("list answered %d %v, want the created %s", status, listed, test.name)
+			}
+
+			if status, _ := call(t, s, http.MethodDelete, test.path+"/"+id, nil); status != http.StatusOK {
+				t.Fatalf("delete answered %d, want 200", status)
+			}
+			if status, _ := call(t, s, http.MethodGet, test.path+"/"+id, nil); status != http.StatusNotFound {
+				t.Errorf("get after delete answered %d, want 404", status)
+			}
+		})
+	}
+}
+
+func TestRequestStatusBeforeProcessing(t *testing.T) {
+	s := New(Options{ProcessingDelay: time.Hour})
+	_, created := call(t, s, http.MethodPost, "/v2/alerts", record{"message": "Disk is full"})
+	req := httptest.NewRequest(http.MethodGet, "/v2/alerts/requests/"+str(created, "requestId"), nil)
+	req.Header.Set("Authorization", "GenieKey key")
+	resp := httptest.NewRecorder()
+	s.ServeHTTP(resp, req)
+	if resp.Code != http.StatusNotFound || resp.Header().Get("X-Opsgenie-Errortype") != "RequestNotProcessed" {
+		t.Errorf("status of an unprocessed request answered %d %q, want 404 RequestNotProcessed", resp.Code, resp.Header().Get("X-Opsgenie-Errortype"))
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		header string
+		want   int
+	}{
+		{name: "any key", apiKey: "", header: "GenieKey anything", want: http.StatusOK},
+		{name: "configured key", apiKey: "secret", header: "GenieKey secret", want: http.StatusOK},
+		{name: "wrong key", apiKey: "secret", header: "GenieKey other", want: http.StatusUnauthorized},
+		{name: "no key", apiKey: "", header: "", want: http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v2/alerts", nil)
+			req.Header.Set("Authorization", test.header)
+			resp := httptest.NewRecorder()
+			New(Options{APIKey: test.apiKey}).ServeHTTP(resp, req)
+			if resp.Code != test.want {
+				t.Errorf("answered %d, want %d", resp.Code, test.want)
+			}
+		})
+	}
+}
+
+func TestListPaging(t *testing.T) {
+	s := New(Options{})
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		call(t, s, http.MethodPost, "/v2/teams", record{"name": name})
+	}
+	tests := []struct {
+		query    string
+		want     []string
+		wantNext string
+	}{
+		{query: "?limit=2", want: []string{"a", "b"}, wantNext: "/v2/teams?limit=2&offset=2"},
+		{query: "?limit=2&offset=2", want: []string{"c", "d"}, wantNext: "/v2/teams?limit=2&offset=4"},
+		{query: "?limit=2&offset=4", want: []string{"e"}},
+		{query: "?offset=9", want: []string{}},
+		{query: "", want: []string{"a", "b", "c", "d", "e"}},
+	}
+	for _, test := range tests {
+		_, listed := call(t, s, http.MethodGet, "/v2/teams"+test.query, nil)
+		items, _ := listed["data"].([]interface{})
+		names := []string{}
+		for _, item := range items {
+			names = append(names, str(stringMap(item), "name"))
+		}
+		if strings.Join(names, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s listed %v, want %v", test.query, names, test.want)
+		}
+		if next := str(stringMap(listed["paging"]), "next"); next != test.wantNext {
+			t.Errorf("%s has the next link %q, want %q", test.query, next, test.wantNext)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s := New(Options{APIKey: "key", RateLimit: 2, RateLimitPeriod: 200 * time.Millisecond})
+	send := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "GenieKey key")
+		resp := httptest.NewRecorder()
+		s.ServeHTTP(resp, req)
+		return resp
+	}
+
+	var states []string
+	for i := 0; i < 3; i++ {
+		resp := send("/v2/alerts")
+		states = append(states, resp.Header().Get("X-RateLimit-State"))
+		if want := i < 2; (resp.Code == http.StatusOK) != want {
+			t.Errorf("alert request %d answered %d", i+1, resp.Code)
+		}
+	}
+	if strings.Join(states, " ") != "OK OK THROTTLED" {
+		t.Errorf("rate limit states are %q", states)
+	}
+	// the families are limited separately and the quota is reset after the period
+	if resp := send("/v2/teams"); resp.Code != http.StatusOK || resp.Header().Get("X-RateLimit-Period-In-Sec") != "1" {
+		t.Errorf("team request answered %d with the period %q", resp.Code, resp.Header().Get("X-RateLimit-Period-In-Sec"))
+	}
+	time.Sleep(200 * time.Millisecond)
+	if resp := send("/v2/alerts"); resp.Code != http.StatusOK {
+		t.Errorf("alert request after the period answered %d", resp.Code)
+	}
+}
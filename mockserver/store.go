@@ -0,0 +1,281 @@
+package mockserver
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// identifierFields are the fields an entity can be referred with in the request paths.
+var identifierFields = []string{"id", "name", "alias", "tinyId", "username"}
+
+// insert stores the record under the kind, assigning an id and the creation time if missing.
+func (s *Server) insert(kind string, rec record) record {
+	if id, _ := rec["id"].(string); id == "" {
+		rec["id"] = s.nextID()
+	}
+	if _, ok := rec["createdAt"]; !ok {
+		rec["createdAt"] = now()
+	}
+	rec["updatedAt"] = rec["createdAt"]
+	s.records[kind] = append(s.records[kind], rec)
+	return rec
+}
+
+// find returns the record of the kind matching the identifier with its id, name, alias, tiny id or username.
+func (s *Server) find(kind string, identifier string) record {
+	_, rec := findRecord(s.records[kind], identifier)
+	return rec
+}
+
+func (s *Server) remove(kind string, identifier string) bool {
+	records, ok := removeRecord(s.records[kind], identifier)
+	s.records[kind] = records
+	return ok
+}
+
+// childKey is the key of the records nested under an entity, e.g. the notes of an alert.
+func childKey(kind string, parent record, name string) string {
+	return kind + "/" + str(parent, "id") + "/" + name
+}
+
+func (s *Server) addChild(key string, rec record) record {
+	if _, ok := rec["createdAt"]; !ok {
+		rec["createdAt"] = now()
+	}
+	s.children[key] = append(s.children[key], rec)
+	return rec
+}
+
+func (s *Server) findChild(key string, identifier string) record {
+	_, rec := findRecord(s.children[key], identifier)
+	return rec
+}
+
+func (s *Server) removeChild(key string, identifier string) bool {
+	records, ok := removeRecord(s.children[key], identifier)
+	s.children[key] = records
+	return ok
+}
+
+func findRecord(records []record, identifier string) (int, record) {
+	for _, field := range identifierFields {
+		for i, rec := range records {
+			if value := str(rec, field); value != "" && value == identifier {
+				return i, rec
+			}
+		}
+	}
+	return -1, nil
+}
+
+func removeRecord(records []record, identifier string) ([]record, bool) {
+	i, rec := findRecord(records, identifier)
+	if rec == nil {
+		return records, false
+	}
+	return append(records[:i:i], records[i+1:]...), true
+}
+
+// merge copies the fields of the update into the record, the id of the record is kept.
+func merge(rec record, update record) record {
+	for key, value := range update {
+		if key != "id" {
+			rec[key] = value
+		}
+	}
+	rec["updatedAt"] = now()
+	return rec
+}
+
+func str(rec record, field string) string {
+	switch value := rec[field].(type) {
+	case string:
+		return value
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func stringList(value interface{}) []string {
+	var list []string
+	switch values := value.(type) {
+	case []interface{}:
+		for _, v := range values {
+			list = append(list, fmt.Sprint(v))
+		}
+	case []string:
+		list = append(list, values...)
+	}
+	return list
+}
+
+func stringMap(value interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	values, ok := value.(map[string]interface{})
+	if rec, isRecord := value.(record); isRecord {
+		values, ok = rec, true
+	}
+	if ok {
+		for k, v := range values {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// sortRecords sorts the records by the string value of the field, ascending unless order is desc.
+func sortRecords(records []record, field string, order string) []record {
+	sorted := append([]record{}, records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if strings.EqualFold(order, "desc") {
+			return str(sorted[i], field) > str(sorted[j], field)
+		}
+		return str(sorted[i], field) < str(sorted[j], field)
+	})
+	return sorted
+}
+
+// paginate returns the records in the page given with the offset and limit query parameters and whether there are more.
+func paginate(r *http.Request, records []record, defaultLimit int) ([]record, bool) {
+	offset := queryInt(r, "offset", 0)
+	limit := queryInt(r, "limit", defaultLimit)
+	if offset > len(records) {
+		offset = len(records)
+	}
+	end := len(records)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return records[offset:end], end < len(records)
+}
+
+// pagingLinks builds the paging object of list responses, next is only set when there are more results.
+func pagingLinks(r *http.Request, pageSize int, more bool) map[string]string {
+	links := map[string]string{"first": r.URL.Path}
+	if more {
+		query := r.URL.Query()
+		query.Set("offset", strconv.Itoa(queryInt(r, "offset", 0)+pageSize))
+		links["next"] = r.URL.Path + "?" + query.Encode()
+	}
+	return links
+}
+
+/*
+matchesQuery implements a small subset of the Opsgenie search syntax: terms separated with AND
+(or spaces), each one being field=value, field:value or a free text searched in the message.
+Tags match when one of the tags equals the value.
+*/
+func matchesQuery(rec record, query string) bool {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return true
+	}
+	for _, term := range strings.Fields(strings.Replace(query, " AND ", " ", -1)) {
+		field, value := "", term
+		if i := strings.IndexAny(term, "=:"); i > 0 {
+			field, value = term[:i], strings.Trim(term[i+1:], "\"")
+		}
+		if !matchesTerm(rec, field, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesTerm(rec record, field string, value string) bool {
+	switch field {
+	case "":
+		return strings.Contains(strings.ToLower(str(rec, "message")), strings.ToLower(value))
+	case "tag", "tags":
+		for _, tag := range stringList(rec["tags"]) {
+			if tag == value {
+				return true
+			}
+		}
+		return false
+	default:
+		return strings.EqualFold(str(rec, field), value)
+	}
+}
+
+// asyncRequest is an alert or incident request accepted with 202 and processed after the processing delay.
+type asyncRequest struct {
+	id          string
+	action      string
+	entityField string
+	acceptedAt  time.Time
+	apply       func() (entityID string, alias string, err error)
+
+	processed   bool
+	success     bool
+	status      string
+	entityID    string
+	alias       string
+	processedAt string
+}
+
+// accept queues the request and responds with 202 like the asynchronous Opsgenie endpoints.
+func (s *Server) accept(w http.ResponseWriter, entityField string, action string, apply func() (string, string, error)) {
+	request := &asyncRequest{
+		id:          s.nextID(),
+		action:      action,
+		entityField: entityField,
+		acceptedAt:  time.Now(),
+		apply:       apply,
+	}
+	s.requests[request.id] = request
+	s.pending = append(s.pending, request)
+	s.processDueRequests()
+	s.writeJSON(w, http.StatusAccepted, record{"result": "Request will be processed", "requestId": request.id})
+}
+
+// processDueRequests applies the pending requests whose processing delay has passed, in the order they were accepted.
+func (s *Server) processDueRequests() {
+	for len(s.pending) != 0 {
+		request := s.pending[0]
+		if time.Since(request.acceptedAt) < s.options.ProcessingDelay {
+			return
+		}
+		s.pending = s.pending[1:]
+		entityID, alias, err := request.apply()
+		request.processed = true
+		request.processedAt = now()
+		request.entityID = entityID
+		request.alias = alias
+		request.success = err == nil
+		request.status = request.action + " is processed successfully"
+		if err != nil {
+			request.status = err.Error()
+		}
+	}
+}
+
+// handleRequestStatus serves the request status endpoint, unprocessed requests are 404 with the RequestNotProcessed error type.
+func (s *Server) handleRequestStatus(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	request, ok := s.requests[params["requestId"]]
+	if !ok {
+		s.writeNotFound(w, "Request", params["requestId"])
+		return
+	}
+	if !request.processed {
+		w.Header().Set("X-Opsgenie-Errortype", "RequestNotProcessed")
+		s.writeError(w, http.StatusNotFound, "Request has not been processed yet")
+		return
+	}
+	s.writeData(w, http.StatusOK, record{
+		"success":           request.success,
+		"isSuccess":         request.success,
+		"action":            request.action,
+		"processedAt":       request.processedAt,
+		"integrationId":     "mock-integration",
+		"status":            request.status,
+		request.entityField: request.entityID,
+		"alias":             request.alias,
+	})
+}
@@ -0,0 +1,140 @@
+package mockserver
+
+import (
+	"net/http"
+	"strconv"
+)
+
+const teams = "teams"
+
+func (s *Server) registerTeamRoutes() {
+	team := resource{
+		kind:          teams,
+		label:         "Team",
+		updateMethods: []string{http.MethodPatch},
+		defaults: func() record {
+			return record{"description": "", "members": []interface{}{}}
+		},
+		changed: func(entity record, change string) {
+			s.addChild(childKey(teams, entity, "logs"), record{"log": change, "owner": "System", "createdDate": now()})
+		},
+	}
+	s.registerResource("/v2/teams", team)
+
+	s.handle(http.MethodPost, "/v2/teams/{identifier}/members", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		entity := s.find(teams, params["identifier"])
+		if entity == nil {
+			s.writeNotFound(w, "Team", params["identifier"])
+			return
+		}
+		body := readBody(r)
+		user := stringMap(body["user"])
+		if str(user, "id") == "" && str(user, "username") == "" {
+			s.writeError(w, http.StatusUnprocessableEntity, "User should be provided")
+			return
+		}
+		role := str(body, "role")
+		if role == "" {
+			role = "user"
+		}
+		members, _ := entity["members"].([]interface{})
+		entity["members"] = append(members, map[string]interface{}{"user": user, "role": role})
+		team.notifyChange(entity, "Member "+str(user, "username")+str(user, "id")+" is added")
+		s.writeData(w, http.StatusOK, record{"id": entity["id"], "name": entity["name"]})
+	})
+	s.handle(http.MethodDelete, "/v2/teams/{identifier}/members/{member}", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		entity := s.find(teams, params["identifier"])
+		if entity == nil {
+			s.writeNotFound(w, "Team", params["identifier"])
+			return
+		}
+		members, _ := entity["members"].([]interface{})
+		remaining := []interface{}{}
+		for _, member := range members {
+			user := stringMap(stringMap(member)["user"])
+			if str(user, "id") != params["member"] && str(user, "username") != params["member"] {
+				remaining = append(remaining, member)
+			}
+		}
+		if len(remaining) == len(members) {
+			s.writeNotFound(w, "Member", params["member"])
+			return
+		}
+		entity["members"] = remaining
+		team.notifyChange(entity, "Member "+params["member"]+" is removed")
+		s.writeData(w, http.StatusOK, record{"id": entity["id"], "name": entity["name"]})
+	})
+
+	s.registerChildResource("/v2/teams/{identifier}/roles", team, resource{
+		kind:          "roles",
+		label:         "Role",
+		updateMethods: []string{http.MethodPatch},
+		defaults: func() record {
+			return record{"rights": []interface{}{}}
+		},
+	})
+	s.handle(http.MethodPost, "/v2/teams/{identifier}/routing-rules/{childIdentifier}/change-order", s.changeRoutingRuleOrder)
+	s.registerChildResource("/v2/teams/{identifier}/routing-rules", team, resource{
+		kind:          "routing-rules",
+		label:         "Routing rule",
+		updateMethods: []string{http.MethodPatch},
+		defaults: func() record {
+			return record{"isDefault": false, "criteria": record{"type": "match-all"}}
+		},
+	})
+	s.handle(http.MethodGet, "/v2/teams/{identifier}/logs", s.listTeamLogs)
+}
+
+func (s *Server) changeRoutingRuleOrder(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	entity := s.find(teams, params["identifier"])
+	if entity == nil {
+		s.writeNotFound(w, "Team", params["identifier"])
+		return
+	}
+	key := childKey(teams, entity, "routing-rules")
+	rule := s.findChild(key, params["childIdentifier"])
+	if rule == nil {
+		s.writeNotFound(w, "Routing rule", params["childIdentifier"])
+		return
+	}
+	order := intValue(readBody(r)["order"])
+	if order < 0 {
+		order = 0
+	}
+	rules, _ := removeRecord(s.children[key], str(rule, "id"))
+	if order > len(rules) {
+		order = len(rules)
+	}
+	s.children[key] = append(rules[:order:order], append([]record{rule}, rules[order:]...)...)
+	s.writeResult(w, "Changed")
+}
+
+// listTeamLogs lists the logs of a team, the offset of the next page is returned while there are more logs.
+func (s *Server) listTeamLogs(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	entity := s.find(teams, params["identifier"])
+	if entity == nil {
+		s.writeNotFound(w, "Team", params["identifier"])
+		return
+	}
+	logs := s.children[childKey(teams, entity, "logs")]
+	if r.URL.Query().Get("order") != "asc" {
+		logs = reversed(logs)
+	}
+	page, more := paginate(r, logs, 20)
+	offset := ""
+	if more {
+		offset = strconv.Itoa(queryInt(r, "offset", 0) + len(page))
+	}
+	s.writeData(w, http.StatusOK, record{"offset": offset, "logs": nonNil(page)})
+}
+
+func intValue(value interface{}) int {
+	switch number := value.(type) {
+	case float64:
+		return int(number)
+	case string:
+		val, _ := strconv.Atoi(number)
+		return val
+	}
+	return 0
+}